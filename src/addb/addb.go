@@ -4,28 +4,32 @@ import (
 	"bytes"
 	"errors"
 	"io"
-	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"schema"
+
 	"gopkg.in/yaml.v3"
 )
 
 // TODO: Feature - ADDB indexes all entries. This lets entity ID to be independent of its path in ADDB.
 
 type ADDB struct {
-	Location string
-	index    map[string]*ADDBComponent
+	Location       string
+	Repository     string // Set only when ADDB is sourced from a git repository
+	Revision       string // Commit checked out from 'Repository'
+	index          map[string]*ADDBComponent
+	files          map[string]string // maps component ID to the file it is specified in
+	sbomMappings   []SBOMMapping
+	strideMappings []STRIDEMapping
+	frameworks     []ControlFramework
+	catalog        *Catalog // loaded on first use
+	Warnings       []error  // problems in entries that were skipped
 }
 
 func (db *ADDB) Init(addb_path string) error {
-	if strings.HasPrefix(addb_path, "~") { // If path is relative to home directory
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		addb_path = home + strings.TrimPrefix(addb_path, "~")
-	}
+	addb_path = expandHome(addb_path)
 	addb_path = strings.TrimSuffix(addb_path, "/") // Remove trailing slash
 
 	if _, err := os.Stat(addb_path); os.IsNotExist(err) {
@@ -92,6 +96,9 @@ func (db *ADDB) buildindex() error {
 	if db.index == nil {
 		db.index = make(map[string]*ADDBComponent)
 	}
+	if db.files == nil {
+		db.files = make(map[string]string)
+	}
 
 	files, err := traverse(db.Location, nil)
 	if err != nil {
@@ -99,33 +106,56 @@ func (db *ADDB) buildindex() error {
 	}
 
 	for _, file := range files {
+		if filepath.Base(file) == SBOMMappingFile {
+			if err := db.loadSBOMMappings(file); err != nil {
+				return err
+			}
+			continue
+		}
+		if filepath.Base(file) == STRIDEMappingFile {
+			if err := db.loadSTRIDEMappings(file); err != nil {
+				return err
+			}
+			continue
+		}
+		if filepath.Base(file) == ControlCatalogFile {
+			if err := db.loadControls(file); err != nil {
+				return err
+			}
+			continue
+		}
+
 		content, err := os.ReadFile(file)
 		if err != nil {
 			return err
 		}
-		//var addb_component ADDBComponent
-		//err = yaml.Unmarshal(content, &addb_component)
-		components, err := unmarshalYamlBlocks(content)
-		if err != nil {
-			return err
+		// Invalid entries are skipped, so that the rest of ADDB remains usable
+		components, errs := unmarshalYamlBlocks(content)
+		if len(errs) != 0 {
+			db.Warnings = append(db.Warnings, invalidEntries(file, errs))
 		}
 
 		for _, addb_component := range components {
 			switch addb_component.Type {
-			case "human", "program", "system", "flow":
+			case "human", "program", "system", "role", "flow":
 				if _, present := db.index[addb_component.Id]; present {
 					return errors.New("Found multiple entries in ADDB for '" + addb_component.Id + "'")
 				}
 
-				// Replace relative paths with absolute paths for ADM files
+				// Replace relative paths with absolute paths for ADM files. Inline ADM is left as is.
 				var newPaths []string
 				for _, adm_path := range addb_component.ADM {
+					if IsInlineADM(adm_path) {
+						newPaths = append(newPaths, adm_path)
+						continue
+					}
 					newPath := getBasePath(file) + "/" + adm_path
 					newPaths = append(newPaths, newPath)
 				}
 				addb_component.ADM = newPaths
 
 				db.index[addb_component.Id] = addb_component
+				db.files[addb_component.Id] = file
 
 			default:
 				return errors.New("unknown component type - " + string(addb_component.Type))
@@ -136,21 +166,56 @@ func (db *ADDB) buildindex() error {
 	return nil
 }
 
-func unmarshalYamlBlocks(content []byte) ([]*ADDBComponent, error) {
+// Decode entries (one per YAML document) that are valid as per the schema.
+// Returns problems in the rest. Documents after a YAML syntax error cannot
+// be read.
+func unmarshalYamlBlocks(content []byte) ([]*ADDBComponent, []error) {
 	var out []*ADDBComponent
+	var errs []error
 	read := bytes.NewReader(content)
 	decoder := yaml.NewDecoder(read)
 	for {
-		var addb_component ADDBComponent
-		if err := decoder.Decode(&addb_component); err != nil {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
 			if err != io.EOF {
-				return nil, err
+				errs = append(errs, err)
 			}
 			break
 		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if problems := schema.ValidateComponent(&doc); len(problems) != 0 {
+			errs = append(errs, problems...)
+			continue
+		}
+		var addb_component ADDBComponent
+		if err := doc.Decode(&addb_component); err != nil {
+			errs = append(errs, err)
+			continue
+		}
 		out = append(out, &addb_component)
 	}
-	return out, nil
+	return out, errs
+}
+
+// Error listing problems in entries of an ADDB file
+func invalidEntries(file string, errs []error) error {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return errors.New("invalid ADDB entries in '" + file + "' - " + strings.Join(messages, "; "))
+}
+
+// Replace '~' with home directory, if path is relative to home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + strings.TrimPrefix(path, "~")
+		}
+	}
+	return path
 }
 
 func getBasePath(filePath string) string {
@@ -162,10 +227,8 @@ func getBasePath(filePath string) string {
 ////////////////////////////////////////
 // Helper functions
 
-func traverse(path string, ignoreList []string) ([]string, error) {
-	var files, ignore []string
-
-	ignore = append(ignore, ignoreList...)
+func traverse(path string, ignoreList ignoreList) ([]string, error) {
+	var files []string
 
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -173,37 +236,28 @@ func traverse(path string, ignoreList []string) ([]string, error) {
 	}
 
 	if fileInfo.IsDir() {
-		items, _ := ioutil.ReadDir(path)
-		// first process .git and .gitignore
+		// rules from this directory's '.gitignore' / '.addbignore' apply to it and its subdirectories only
+		ignore, err := ignoreList.extend(path)
+		if err != nil {
+			return nil, err
+		}
+		items, _ := os.ReadDir(path)
 		for _, item := range items {
+			itemPath := path + "/" + item.Name()
 			if item.Name() == ".git" { // don't process '.git' folder
-				ignore = append(ignore, ".git")
 				continue
-			} else if item.Name() == ".gitignore" { // skip everything listed in .gitignore
-				ignore = append(ignore, ".gitignore")
-				content, err := os.ReadFile(path + "/.gitignore")
+			}
+			if ignore.ignored(itemPath, item.IsDir()) {
+				continue
+			}
+			if item.IsDir() { // subdirectories
+				f, err := traverse(itemPath, ignore)
 				if err != nil {
 					return nil, err
 				}
-				ignoreFiles := string(content)
-				ignore = append(ignore, strings.Split(ignoreFiles, "\n")...)
-			} else { // Only pick files with extension .smspec
-				fileparts := strings.Split(item.Name(), ".")
-				fileExtension := fileparts[len(fileparts)-1]
-				if contains(item.Name(), ignore) {
-					continue
-				}
-				if item.IsDir() { // subdirectories
-					f, err := traverse(path+"/"+item.Name(), ignore)
-					if err != nil {
-						return nil, err
-					}
-					files = append(files, f...)
-				} else if fileExtension != "smspec" {
-					continue
-				} else {
-					files = append(files, path+"/"+item.Name())
-				}
+				files = append(files, f...)
+			} else if filepath.Ext(item.Name()) == ".smspec" || item.Name() == SBOMMappingFile || item.Name() == STRIDEMappingFile || item.Name() == ControlCatalogFile { // Only pick specifications, mapping tables and control catalogs
+				files = append(files, itemPath)
 			}
 		}
 	} else { // received a single file's path
@@ -212,13 +266,3 @@ func traverse(path string, ignoreList []string) ([]string, error) {
 
 	return files, nil
 }
-
-func contains(item string, list []string) bool {
-	for _, x := range list {
-		if x == item {
-			return true
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,81 @@
+package addb
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Inline ADM content has at least one line starting with one of these keywords
+var inlineADM = regexp.MustCompile(`(?m)^\s*(Model|Attack|Defense):`)
+
+// Check if an item in 'adm' is ADM content (usually a YAML literal string)
+// instead of a path to an '.adm' file.
+func IsInlineADM(adm string) bool {
+	return inlineADM.MatchString(adm)
+}
+
+// Content of an item in 'adm'. Inline ADM is returned as is, while paths are
+// read from the filesystem.
+func ReadADM(adm string) ([]byte, error) {
+	if IsInlineADM(adm) {
+		return []byte(adm), nil
+	}
+	return os.ReadFile(adm)
+}
+
+// Name of an item in 'adm' for use in messages and reports. Paths are used as
+// is, while inline ADM is named after its model (if it has one).
+func ADMSource(adm string) string {
+	if !IsInlineADM(adm) {
+		return adm
+	}
+	for _, line := range strings.Split(adm, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Model:") {
+			return "inline ADM '" + strings.TrimSpace(strings.TrimPrefix(line, "Model:")) + "'"
+		}
+	}
+	return "inline ADM"
+}
+
+// Tags of each attack in ADM content, by attack title. Tags placed before
+// 'Model:' apply to all attacks in the content.
+func AttackTags(content string) map[string][]string {
+	return itemTags(content, "Attack:")
+}
+
+// Tags of each defense in ADM content, by defense title. Tags placed before
+// 'Model:' apply to all defenses in the content.
+func DefenseTags(content string) map[string][]string {
+	return itemTags(content, "Defense:")
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// Tags of ADM items starting with 'keyword', by their title
+func itemTags(content string, keyword string) map[string][]string {
+	tags := make(map[string][]string)
+	var modelTags, pending []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "@"):
+			if i := strings.Index(line, "#"); i >= 0 { // trailing comment
+				line = line[:i]
+			}
+			pending = append(pending, strings.Fields(line)...)
+		case strings.HasPrefix(line, "Model:"):
+			modelTags, pending = pending, nil
+		case strings.HasPrefix(line, keyword):
+			title := strings.TrimSpace(strings.TrimPrefix(line, keyword))
+			tags[title] = append(tags[title], modelTags...)
+			tags[title] = append(tags[title], pending...)
+			pending = nil
+		case line != "" && !strings.HasPrefix(line, "#"):
+			pending = nil // tags of other items
+		}
+	}
+	return tags
+}
@@ -0,0 +1,260 @@
+package addb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Directory (under ADDB's root) containing catalogs of CAPEC attack patterns,
+// ATT&CK techniques and CWE weaknesses. Catalogs are the dumps published by
+// MITRE - STIX 2.x bundles ('.json', like 'enterprise-attack.json' or
+// 'stix-capec.json') and CSV files ('.csv', like CAPEC's and CWE's '1000.csv').
+const CatalogDir = "catalog"
+
+// Kinds of catalog entries
+const (
+	CAPEC  = "CAPEC"
+	ATTACK = "ATT&CK"
+	CWE    = "CWE"
+)
+
+// Prefixes of ADM tags that refer to catalog entries, like '@capec:66',
+// '@attack:T1190' or '@cwe:CWE-89'
+var catalogTags = map[string]string{
+	"@capec:":  CAPEC,
+	"@attack:": ATTACK,
+	"@cwe:":    CWE,
+}
+
+var (
+	capecId  = regexp.MustCompile(`(?i)^(capec-)?(\d+)$`)
+	attackId = regexp.MustCompile(`(?i)^(t\d{4})(\.\d{3})?$`)
+	cweId    = regexp.MustCompile(`(?i)^(cwe-)?(\d+)$`)
+)
+
+// CAPEC attack pattern, ATT&CK technique or CWE weakness
+type CatalogEntry struct {
+	Id         string // like 'CAPEC-66', 'T1059.001' or 'CWE-89'
+	Kind       string
+	Name       string
+	URL        string
+	Deprecated bool
+}
+
+// Entries of all catalogs in ADDB, by ID
+type Catalog struct {
+	entries  map[string]*CatalogEntry
+	kinds    map[string]bool // kinds of entries loaded
+	Warnings []error         // files in the catalog directory that were skipped
+}
+
+// Catalog of CAPEC, ATT&CK and CWE entries in ADDB. Catalogs are large, so
+// they are loaded on first use. An empty catalog is returned if ADDB has no
+// catalog directory.
+func (db *ADDB) Catalog() (*Catalog, error) {
+	if db.catalog != nil {
+		return db.catalog, nil
+	}
+	catalog := Catalog{entries: make(map[string]*CatalogEntry), kinds: make(map[string]bool)}
+	dir := filepath.Join(db.Location, CatalogDir)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		db.catalog = &catalog
+		return db.catalog, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			err = catalog.loadSTIX(path)
+		case ".csv":
+			err = catalog.loadCSV(path)
+		}
+		if err == errNotSTIXBundle { // other JSON files, like metadata of a bundle
+			catalog.Warnings = append(catalog.Warnings, errors.New("skipped '"+path+"' - "+err.Error()))
+			return nil
+		}
+		if err != nil {
+			return errors.New("invalid catalog '" + path + "' - " + err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.catalog = &catalog
+	return db.catalog, nil
+}
+
+// Find an entry by its ID (see 'ParseCatalogTag')
+func (c *Catalog) Lookup(id string) (*CatalogEntry, bool) {
+	entry, found := c.entries[id]
+	return entry, found
+}
+
+// Check if entries of a kind were loaded. IDs of kinds without a catalog
+// cannot be validated.
+func (c *Catalog) Has(kind string) bool {
+	return c.kinds[kind]
+}
+
+// ID and kind of the catalog entry an ADM tag refers to. IDs are normalized -
+// '@capec:66' refers to 'CAPEC-66', '@cwe:89' to 'CWE-89' and '@attack:t1190'
+// to 'T1190'. 'ok' is false for other tags, while 'err' is set for tags with
+// a malformed ID.
+func ParseCatalogTag(tag string) (id string, kind string, ok bool, err error) {
+	for prefix, k := range catalogTags {
+		if !strings.HasPrefix(strings.ToLower(tag), prefix) {
+			continue
+		}
+		value := tag[len(prefix):]
+		switch k {
+		case CAPEC:
+			if m := capecId.FindStringSubmatch(value); m != nil {
+				return "CAPEC-" + m[2], k, true, nil
+			}
+		case ATTACK:
+			if attackId.MatchString(value) {
+				return strings.ToUpper(value), k, true, nil
+			}
+		case CWE:
+			if m := cweId.FindStringSubmatch(value); m != nil {
+				return "CWE-" + m[2], k, true, nil
+			}
+		}
+		return "", k, true, errors.New("malformed " + k + " ID in tag '" + tag + "'")
+	}
+	return "", "", false, nil
+}
+
+// Link to the entry on MITRE's website
+func CatalogURL(id string) string {
+	switch {
+	case strings.HasPrefix(id, "CAPEC-"):
+		return "https://capec.mitre.org/data/definitions/" + strings.TrimPrefix(id, "CAPEC-") + ".html"
+	case strings.HasPrefix(id, "CWE-"):
+		return "https://cwe.mitre.org/data/definitions/" + strings.TrimPrefix(id, "CWE-") + ".html"
+	}
+	return "https://attack.mitre.org/techniques/" + strings.ReplaceAll(id, ".", "/") + "/"
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (c *Catalog) add(entry CatalogEntry) {
+	if entry.URL == "" {
+		entry.URL = CatalogURL(entry.Id)
+	}
+	c.entries[entry.Id] = &entry
+	c.kinds[entry.Kind] = true
+}
+
+var errNotSTIXBundle = errors.New("not a STIX bundle")
+
+// STIX bundle. Each attack pattern's first external reference is its ID in
+// CAPEC or ATT&CK. Revoked objects are skipped.
+func (c *Catalog) loadSTIX(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var bundle struct {
+		Type    string `json:"type"`
+		Objects []struct {
+			Type       string `json:"type"`
+			Name       string `json:"name"`
+			Revoked    bool   `json:"revoked"`
+			Deprecated bool   `json:"x_mitre_deprecated"`
+			Status     string `json:"x_capec_status"`
+			References []struct {
+				Source string `json:"source_name"`
+				Id     string `json:"external_id"`
+				URL    string `json:"url"`
+			} `json:"external_references"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return errNotSTIXBundle
+		}
+		return err
+	}
+	if bundle.Type != "bundle" {
+		return errNotSTIXBundle
+	}
+	for _, o := range bundle.Objects {
+		if o.Type != "attack-pattern" || o.Revoked || len(o.References) == 0 {
+			continue
+		}
+		ref := o.References[0]
+		entry := CatalogEntry{Id: ref.Id, Name: o.Name, URL: ref.URL, Deprecated: o.Deprecated || o.Status == "Deprecated"}
+		switch ref.Source {
+		case "mitre-attack":
+			entry.Kind = ATTACK
+		case "capec":
+			entry.Kind = CAPEC
+		default:
+			continue
+		}
+		c.add(entry)
+	}
+	return nil
+}
+
+// CSV files published by CAPEC (first column 'ID') and CWE (first column
+// 'CWE-ID'). IDs are listed without their prefix.
+func (c *Catalog) loadCSV(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	var kind, prefix string
+	switch strings.TrimPrefix(strings.TrimPrefix(header[0], "\ufeff"), "'") {
+	case "ID":
+		kind, prefix = CAPEC, "CAPEC-"
+	case "CWE-ID":
+		kind, prefix = CWE, "CWE-"
+	default:
+		return errors.New("unknown CSV format. Expected CAPEC or CWE CSV files")
+	}
+	status := -1
+	for i, column := range header {
+		if column == "Status" {
+			status = i
+		}
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) < 2 || record[0] == "" {
+			continue
+		}
+		entry := CatalogEntry{Id: prefix + strings.TrimSpace(record[0]), Kind: kind, Name: strings.TrimSpace(record[1])}
+		if status >= 0 && status < len(record) {
+			entry.Deprecated = record[status] == "Deprecated"
+		}
+		c.add(entry)
+	}
+	return nil
+}
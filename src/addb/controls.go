@@ -0,0 +1,115 @@
+package addb
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"schema"
+)
+
+// Name of files containing control catalogs. A control catalog lists controls
+// of one or more compliance frameworks and can be placed in any directory of
+// ADDB.
+const ControlCatalogFile = "controls.yaml"
+
+// Prefix of tags that refer to controls, like '@control:iso-27001:A.8.24'.
+// ADM defenses use them as tags, while mitigations in smspec include them in
+// their text.
+const ControlTag = "@control:"
+
+// Frameworks in control catalogs of ADDB. Frameworks listed in more than one
+// catalog are combined.
+func (db *ADDB) Frameworks() []ControlFramework {
+	return db.frameworks
+}
+
+// Find a control by its framework and ID. Both are matched ignoring case.
+func (db *ADDB) LookupControl(framework string, id string) (*Control, bool) {
+	for _, f := range db.frameworks {
+		if !strings.EqualFold(f.Id, framework) {
+			continue
+		}
+		for i := range f.Controls {
+			if strings.EqualFold(f.Controls[i].Id, id) {
+				return &f.Controls[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Framework and control ID a tag refers to. 'ok' is false for other tags, while
+// 'err' is set for tags without a framework or control ID.
+func ParseControlTag(tag string) (framework string, id string, ok bool, err error) {
+	if !strings.HasPrefix(strings.ToLower(tag), ControlTag) {
+		return "", "", false, nil
+	}
+	framework, id, found := strings.Cut(tag[len(ControlTag):], ":")
+	if !found || framework == "" || id == "" {
+		return "", "", true, errors.New("malformed control reference '" + tag + "'. Expected '" + ControlTag + "<framework>:<control ID>'")
+	}
+	return strings.ToLower(framework), id, true, nil
+}
+
+// Control tags in a mitigation along with its text without them
+func MitigationControls(mitigation string) (text string, tags []string) {
+	var words []string
+	for _, word := range strings.Fields(mitigation) {
+		if strings.HasPrefix(strings.ToLower(word), ControlTag) {
+			tags = append(tags, word)
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), tags
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (db *ADDB) loadControls(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var frameworks []ControlFramework
+	if err := schema.Unmarshal(content, &frameworks); err != nil {
+		return errors.New("invalid control catalog '" + file + "' - " + err.Error())
+	}
+	for _, f := range frameworks {
+		if f.Id == "" || strings.ContainsAny(f.Id, ": \t") {
+			return errors.New("control catalog '" + file + "' contains a framework without a 'framework' name or with ':' or spaces in it")
+		}
+		f.Id = strings.ToLower(f.Id)
+		framework := db.addFramework(f)
+		for _, c := range f.Controls {
+			if c.Id == "" {
+				return errors.New("control catalog '" + file + "' contains a control without 'id' in framework '" + f.Id + "'")
+			}
+			if _, found := db.LookupControl(f.Id, c.Id); found {
+				return errors.New("control catalog '" + file + "' lists control '" + c.Id + "' of framework '" + f.Id + "' more than once")
+			}
+			framework.Controls = append(framework.Controls, c)
+		}
+	}
+	return nil
+}
+
+// Framework in 'db' with the same ID as 'framework', after filling in its name
+// and profile. Controls are added by the caller.
+func (db *ADDB) addFramework(framework ControlFramework) *ControlFramework {
+	for i := range db.frameworks {
+		if db.frameworks[i].Id == framework.Id {
+			if db.frameworks[i].Name == "" {
+				db.frameworks[i].Name = framework.Name
+			}
+			if db.frameworks[i].Profile == "" {
+				db.frameworks[i].Profile = framework.Profile
+			}
+			return &db.frameworks[i]
+		}
+	}
+	db.frameworks = append(db.frameworks, ControlFramework{Id: framework.Id, Name: framework.Name, Profile: framework.Profile})
+	return &db.frameworks[len(db.frameworks)-1]
+}
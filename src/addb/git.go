@@ -0,0 +1,134 @@
+package addb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Environment variable that overrides the directory used to cache ADDB checkouts.
+const CacheDirEnv = "ADSM_CACHE_DIR"
+
+var commitPattern = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// Initialize ADDB from revision 'ref' of the git repository at 'repo'.
+// 'repo' can be a URL or path to a local (bare) repository. The revision
+// is checked out into the ADDB cache, so subsequent runs against the same
+// commit don't need access to the repository.
+func (db *ADDB) InitFromGit(repo string, ref string) error {
+	repo = expandHome(repo)
+	if _, err := os.Stat(repo); err == nil { // local repositories are cached by their absolute path
+		if absPath, err := filepath.Abs(repo); err == nil {
+			repo = absPath
+		}
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	cacheDir, err := cacheDirectory()
+	if err != nil {
+		return err
+	}
+
+	location, commit, err := checkout(repo, ref, cacheDir)
+	if err != nil {
+		return err
+	}
+	db.Repository = repo
+	db.Revision = commit
+
+	return db.Init(location)
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// Mirror 'repo' into the cache and check out 'ref' in a directory
+// named after the commit it resolves to. Returns the checkout's
+// location and the commit ID.
+func checkout(repo string, ref string, cacheDir string) (string, string, error) {
+	repoHash := sha256.Sum256([]byte(repo))
+	repoCache := filepath.Join(cacheDir, hex.EncodeToString(repoHash[:8]))
+	mirror := filepath.Join(repoCache, "mirror.git")
+
+	if _, err := os.Stat(mirror); os.IsNotExist(err) {
+		if err := os.MkdirAll(repoCache, 0700); err != nil {
+			return "", "", err
+		}
+		if _, err := git("", "clone", "--quiet", "--mirror", "--", repo, mirror); err != nil {
+			return "", "", err
+		}
+	} else if !commitPattern.MatchString(ref) || !hasCommit(mirror, ref) {
+		// Branches and tags may have moved. Commits already in the mirror don't need a fetch.
+		if _, err := git(mirror, "fetch", "--quiet", "--prune", "--force", "origin"); err != nil {
+			return "", "", err
+		}
+	}
+
+	commit, err := git(mirror, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", "", errors.New("cannot find revision '" + ref + "' in ADDB repository '" + repo + "'")
+	}
+
+	location := filepath.Join(repoCache, commit)
+	if _, err := os.Stat(location); err == nil { // already checked out
+		return location, commit, nil
+	}
+
+	// Checkout into a temporary directory first, so that an interrupted
+	// checkout is never mistaken for a complete one.
+	tmp, err := os.MkdirTemp(repoCache, "checkout-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := git("", "clone", "--quiet", "--no-checkout", "--", mirror, tmp); err != nil {
+		return "", "", err
+	}
+	if _, err := git(tmp, "checkout", "--quiet", "--detach", commit); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(tmp, location); err != nil {
+		return "", "", err
+	}
+
+	return location, commit, nil
+}
+
+func hasCommit(gitDir string, commit string) bool {
+	_, err := git(gitDir, "cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
+
+// Run the local 'git' binary and return its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		return "", errors.New("git " + args[0] + " failed - " + message)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func cacheDirectory() (string, error) {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return filepath.Join(expandHome(dir), "addb"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adsm", "addb"), nil
+}
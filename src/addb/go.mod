@@ -2,4 +2,8 @@ module addb
 
 go 1.18
 
+replace schema => ../schema
+
 require gopkg.in/yaml.v3 v3.0.1 // direct
+
+require schema v0.0.0-00010101000000-000000000000
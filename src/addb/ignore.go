@@ -0,0 +1,141 @@
+package addb
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Files that list paths to be skipped when indexing ADDB. Both follow
+// gitignore syntax. '.addbignore' is read after '.gitignore', so its
+// patterns take precedence when both files are present in a directory.
+var ignoreFiles = []string{".gitignore", ".addbignore"}
+
+// A single pattern from a '.gitignore' / '.addbignore' file.
+type ignoreRule struct {
+	base     string   // directory containing the ignore file
+	segments []string // pattern split along '/'
+	negate   bool     // pattern started with '!'
+	dirOnly  bool     // pattern ended with '/'
+	anchored bool     // pattern contained a '/' before its end. Matched relative to 'base'.
+}
+
+// Ordered list of rules. Later rules override earlier ones.
+type ignoreList []ignoreRule
+
+// Read ignore files in 'dir' and return a new list containing rules
+// inherited from parent directories followed by rules from 'dir'.
+// The parent list is never modified, so rules do not leak across
+// sibling directories.
+func (l ignoreList) extend(dir string) (ignoreList, error) {
+	rules := make(ignoreList, len(l))
+	copy(rules, l)
+
+	for _, name := range ignoreFiles {
+		file, err := os.Open(dir + "/" + name)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text(), dir); ok {
+				rules = append(rules, rule)
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+// Check if 'path' must be skipped. As in git, the last matching rule wins.
+func (l ignoreList) ignored(path string, isDir bool) bool {
+	ignore := false
+	for _, rule := range l {
+		if rule.matches(path, isDir) {
+			ignore = !rule.negate
+		}
+	}
+	return ignore
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func parseIgnoreRule(line string, base string) (rule ignoreRule, ok bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, "\\ ") { // trailing spaces are ignored unless escaped
+		line = strings.TrimRight(line, " \t")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+
+	rule.base = base
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+func (r ignoreRule) matches(path string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	rel, err := filepath.Rel(r.base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false // rules only apply to the directory containing the ignore file and below
+	}
+	pathSegments := strings.Split(filepath.ToSlash(rel), "/")
+	if !r.anchored { // patterns without a slash match a name at any depth
+		return matchSegments(r.segments, pathSegments[len(pathSegments)-1:])
+	}
+	return matchSegments(r.segments, pathSegments)
+}
+
+// Glob match along path segments. '**' matches zero or more segments.
+func matchSegments(pattern []string, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 { // trailing '**' matches everything inside, but not the directory itself
+			return len(path) > 0
+		}
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,190 @@
+package addb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lock records the exact ADDB content used by a security model. It is
+// stored next to the model as '<model>.lock'.
+type Lock struct {
+	Addb     string      `yaml:"addb"`
+	Revision string      `yaml:"revision,omitempty"`
+	Entries  []LockEntry `yaml:"entries"`
+}
+
+// A single ADDB entry along with hashes of all files it pulls in.
+type LockEntry struct {
+	Id   string       `yaml:"id"`
+	File string       `yaml:"file"`
+	Hash string       `yaml:"hash"`
+	ADM  []LockedFile `yaml:"adm,omitempty"`
+}
+
+type LockedFile struct {
+	File string `yaml:"file"`
+	Hash string `yaml:"hash"`
+}
+
+// Build a lock for ADDB entries listed in 'ids'. 'source' identifies
+// the ADDB as specified in the model.
+func (db *ADDB) Lock(source string, ids []string) (*Lock, error) {
+	lock := Lock{Addb: source, Revision: db.Revision}
+
+	for _, id := range uniqueIds(ids) {
+		entry, err := db.lockEntry(id)
+		if err != nil {
+			return nil, err
+		}
+		lock.Entries = append(lock.Entries, *entry)
+	}
+
+	return &lock, nil
+}
+
+// Compare ADDB content with the lock. Each returned error describes
+// one difference. 'ids' is the list of ADDB entries currently used by
+// the model.
+func (l *Lock) Verify(db *ADDB, source string, ids []string) (drift []error) {
+	if l.Addb != source {
+		drift = append(drift, errors.New("ADDB changed from '"+l.Addb+"' to '"+source+"'"))
+	}
+	if l.Revision != db.Revision {
+		drift = append(drift, errors.New("ADDB revision changed from '"+l.Revision+"' to '"+db.Revision+"'"))
+	}
+
+	locked := make(map[string]LockEntry)
+	for _, entry := range l.Entries {
+		locked[entry.Id] = entry
+	}
+	for _, id := range uniqueIds(ids) {
+		if _, present := locked[id]; !present {
+			drift = append(drift, errors.New("ADDB entry '"+id+"' is not in the lock file"))
+		}
+	}
+
+	for _, lockedEntry := range l.Entries {
+		current, err := db.lockEntry(lockedEntry.Id)
+		if err != nil {
+			drift = append(drift, err)
+			continue
+		}
+		if current.File != lockedEntry.File {
+			drift = append(drift, errors.New("ADDB entry '"+lockedEntry.Id+"' moved from '"+lockedEntry.File+"' to '"+current.File+"'"))
+		} else if current.Hash != lockedEntry.Hash {
+			drift = append(drift, errors.New("ADDB file '"+current.File+"' (used by '"+lockedEntry.Id+"') changed"))
+		}
+		drift = append(drift, compareFiles(lockedEntry.Id, lockedEntry.ADM, current.ADM)...)
+	}
+
+	return drift
+}
+
+// Read a lock from the file at 'path'.
+func ReadLockFile(path string) (*Lock, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock Lock
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, errors.New("invalid lock file '" + path + "' - " + err.Error())
+	}
+	return &lock, nil
+}
+
+// Write the lock to the file at 'path'.
+func (l *Lock) WriteFile(path string) error {
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	header := "# Generated by 'adsm lock'. Do not edit.\n"
+	return os.WriteFile(path, append([]byte(header), content...), 0644)
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (db *ADDB) lockEntry(id string) (*LockEntry, error) {
+	component := db.index[id]
+	if component == nil {
+		return nil, errors.New("ADDB entry '" + id + "' no longer exists")
+	}
+
+	file := db.files[id]
+	hash, err := hashFile(file)
+	if err != nil {
+		return nil, err
+	}
+	entry := LockEntry{Id: id, File: db.relativePath(file), Hash: hash}
+
+	for _, adm := range component.ADM {
+		if IsInlineADM(adm) { // part of the entry's file, which is already hashed
+			continue
+		}
+		hash, err := hashFile(adm)
+		if err != nil {
+			return nil, errors.New("cannot read ADM file '" + db.relativePath(adm) + "' used by ADDB entry '" + id + "'")
+		}
+		entry.ADM = append(entry.ADM, LockedFile{File: db.relativePath(adm), Hash: hash})
+	}
+
+	return &entry, nil
+}
+
+// Path relative to ADDB root. Keeps lock files independent of where ADDB is located.
+func (db *ADDB) relativePath(path string) string {
+	return strings.TrimPrefix(path, db.Location+"/")
+}
+
+func compareFiles(id string, locked []LockedFile, current []LockedFile) (drift []error) {
+	currentHashes := make(map[string]string)
+	for _, f := range current {
+		currentHashes[f.File] = f.Hash
+	}
+	lockedFiles := make(map[string]bool)
+	for _, f := range locked {
+		lockedFiles[f.File] = true
+		if hash, present := currentHashes[f.File]; !present {
+			drift = append(drift, errors.New("ADM file '"+f.File+"' is no longer used by '"+id+"'"))
+		} else if hash != f.Hash {
+			drift = append(drift, errors.New("ADM file '"+f.File+"' (used by '"+id+"') changed"))
+		}
+	}
+	for _, f := range current {
+		if !lockedFiles[f.File] {
+			drift = append(drift, errors.New("ADM file '"+f.File+"' is now used by '"+id+"'"))
+		}
+	}
+	return
+}
+
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Sorted list of IDs without the 'addb:' prefix and duplicates.
+func uniqueIds(ids []string) (unique []string) {
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		id = strings.TrimPrefix(id, "addb:")
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Strings(unique)
+	return
+}
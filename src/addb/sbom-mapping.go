@@ -0,0 +1,93 @@
+package addb
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"schema"
+)
+
+// Name of files containing SBOM mapping tables. A mapping table can be placed
+// in any directory of ADDB.
+const SBOMMappingFile = "sbom-mapping.yaml"
+
+// Find the ADDB entry for a software component listed in an SBOM. Matching
+// is attempted in the following order -
+//  1. package-URL, including version
+//  2. package-URL without version
+//  3. name and version
+//  4. name only
+func (db *ADDB) MapComponent(purl string, name string, version string) (string, bool) {
+	purl = trimPurlQualifiers(purl)
+	candidates := []func(m SBOMMapping) bool{
+		func(m SBOMMapping) bool { return purl != "" && m.Purl == purl },
+		func(m SBOMMapping) bool { return purl != "" && m.Purl == trimPurlVersion(purl) },
+		func(m SBOMMapping) bool { return name != "" && m.Purl == "" && m.Name == name && m.Version == version },
+		func(m SBOMMapping) bool { return name != "" && m.Purl == "" && m.Name == name && m.Version == "" },
+	}
+	for _, matches := range candidates {
+		for _, mapping := range db.sbomMappings {
+			if matches(mapping) && db.index[mapping.Id] != nil {
+				return mapping.Id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Find the ADDB entry for a programming language (like 'go' or 'python').
+// Mapping tables are checked first, followed by an entry with ID 'lang.<language>'.
+func (db *ADDB) MapLanguage(language string) (string, bool) {
+	language = strings.ToLower(language)
+	for _, mapping := range db.sbomMappings {
+		if mapping.Language == language && db.index[mapping.Id] != nil {
+			return mapping.Id, true
+		}
+	}
+	if db.index["lang."+language] != nil {
+		return "lang." + language, true
+	}
+	return "", false
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (db *ADDB) loadSBOMMappings(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var mappings []SBOMMapping
+	if err := schema.Unmarshal(content, &mappings); err != nil {
+		return errors.New("invalid SBOM mapping table '" + file + "' - " + err.Error())
+	}
+	for _, m := range mappings {
+		if m.Id == "" || (m.Purl == "" && m.Name == "" && m.Language == "") {
+			return errors.New("SBOM mapping table '" + file + "' contains an entry without 'id' or without one of 'purl', 'name' and 'language'")
+		}
+		m.Language = strings.ToLower(m.Language)
+		m.Id = strings.TrimPrefix(m.Id, "addb:")
+		m.Purl = trimPurlQualifiers(m.Purl)
+		db.sbomMappings = append(db.sbomMappings, m)
+	}
+	return nil
+}
+
+// Remove qualifiers ('?...') and subpath ('#...') from a package-URL
+func trimPurlQualifiers(purl string) string {
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		return purl[:i]
+	}
+	return purl
+}
+
+// Remove version ('@...') from a package-URL. '@' in namespaces
+// (like npm scopes) is encoded as '%40', so the last '@' is the version separator.
+func trimPurlVersion(purl string) string {
+	if i := strings.LastIndex(purl, "@"); i >= 0 {
+		return purl[:i]
+	}
+	return purl
+}
@@ -0,0 +1,72 @@
+package addb
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"schema"
+)
+
+// Name of files containing STRIDE mapping tables. A mapping table can be
+// placed in any directory of ADDB.
+const STRIDEMappingFile = "stride-mapping.yaml"
+
+// STRIDE categories, in the order they are listed in reports
+var STRIDECategories = []string{
+	"spoofing",
+	"tampering",
+	"repudiation",
+	"information-disclosure",
+	"denial-of-service",
+	"elevation-of-privilege",
+}
+
+// STRIDE categories of an ADM attack, from mapping tables. Titles are matched
+// ignoring case.
+func (db *ADDB) MapAttack(title string) (categories []string) {
+	for _, mapping := range db.strideMappings {
+		if strings.EqualFold(mapping.Attack, strings.TrimSpace(title)) {
+			categories = append(categories, mapping.STRIDE...)
+		}
+	}
+	return
+}
+
+// Check if 'category' is one of the STRIDE categories
+func IsSTRIDECategory(category string) bool {
+	for _, c := range STRIDECategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (db *ADDB) loadSTRIDEMappings(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var mappings []STRIDEMapping
+	if err := schema.Unmarshal(content, &mappings); err != nil {
+		return errors.New("invalid STRIDE mapping table '" + file + "' - " + err.Error())
+	}
+	for _, m := range mappings {
+		if m.Attack == "" || len(m.STRIDE) == 0 {
+			return errors.New("STRIDE mapping table '" + file + "' contains an entry without 'attack' or 'stride'")
+		}
+		for i, category := range m.STRIDE {
+			m.STRIDE[i] = strings.ToLower(category)
+			if !IsSTRIDECategory(m.STRIDE[i]) {
+				return errors.New("STRIDE mapping table '" + file + "' maps '" + m.Attack + "' to unknown category '" + category + "'. Supported categories - " + strings.Join(STRIDECategories, ", "))
+			}
+		}
+		m.Attack = strings.TrimSpace(m.Attack)
+		db.strideMappings = append(db.strideMappings, m)
+	}
+	return nil
+}
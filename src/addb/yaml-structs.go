@@ -6,28 +6,70 @@ const (
 	Human   ItemType = "human"
 	Program ItemType = "program"
 	System  ItemType = "system"
+	Role    ItemType = "role"
 	Flow    ItemType = "flow"
 )
 
+// 'schema' tags list the types of entries a field applies to. Fields without
+// one apply to all entries.
 type ADDBComponent struct {
 	Id              string   `yaml:"id"`
 	Name            string   `yaml:"name"`
 	Type            ItemType `yaml:"type"`
 	Description     string   `yaml:"description"`
 	DesignDocument  string   `yaml:"design-document"`
-	Base            []string `yaml:"base"`
+	Tags            []string `yaml:"tags"`
+	Owner           string   `yaml:"owner"`
+	Team            string   `yaml:"team"`
+	Contact         string   `yaml:"contact"`
+	Base            []string `yaml:"base" schema:"human,program"`
+	Mitigations     []string `yaml:"mitigations"`
 	Recommendations []string `yaml:"recommendations"`
 	ADM             []string `yaml:"adm"`
 
 	// Only for humans
-	Roles     []string `yaml:"roles"`
-	Interface string   `yaml:"interface"`
+	Interface string `yaml:"interface" schema:"human"`
 
 	// Only for programs/systems
-	CodeRepository string   `yaml:"repo"`
-	Languages      []string `yaml:"languages"`
-	Dependencies   []string `yaml:"dependencies"`
+	Roles          []string `yaml:"roles" schema:"program"`
+	CodeRepository string   `yaml:"repo" schema:"program"`
+	Icon           string   `yaml:"icon" schema:"program"`
+	Languages      []string `yaml:"languages" schema:"program"`
+	Dependencies   []string `yaml:"dependencies" schema:"program"`
 
 	// Only for flows
-	Protocol []string `yaml:"protocol"`
+	Protocol []string `yaml:"protocol" schema:"flow"`
+}
+
+// Entry in an SBOM mapping table ('sbom-mapping.yaml'). Maps a software
+// component, identified by its package-URL or name (and version), or a
+// programming language to an ADDB entry.
+type SBOMMapping struct {
+	Purl     string `yaml:"purl"`
+	Name     string `yaml:"name"`
+	Version  string `yaml:"version"`
+	Language string `yaml:"language"`
+	Id       string `yaml:"id"`
+}
+
+// Entry in a STRIDE mapping table ('stride-mapping.yaml'). Maps an ADM attack,
+// identified by its title, to STRIDE categories.
+type STRIDEMapping struct {
+	Attack string   `yaml:"attack"`
+	STRIDE []string `yaml:"stride"`
+}
+
+// Framework in a control catalog ('controls.yaml'), like ISO 27001 Annex A or
+// NIST 800-53. 'framework' is the short name used in '@control:' tags.
+type ControlFramework struct {
+	Id       string    `yaml:"framework"`
+	Name     string    `yaml:"name"`
+	Profile  string    `yaml:"profile"` // OSCAL profile or catalog the framework's controls are imported from
+	Controls []Control `yaml:"controls"`
+}
+
+// Control of a framework in a control catalog
+type Control struct {
+	Id    string `yaml:"id"`
+	Title string `yaml:"title"`
 }
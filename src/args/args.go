@@ -4,14 +4,22 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"securitymodel/templates"
 	"strconv"
+	"strings"
 )
 
 type Args struct {
 	statCmd			*flag.FlagSet
 	diagCmd   	*flag.FlagSet
 	reportCmd  	*flag.FlagSet
-	//exportCmd  	*flag.FlagSet
+	lockCmd   	*flag.FlagSet
+	sbomCmd   	*flag.FlagSet
+	scanCmd   	*flag.FlagSet
+	schemaCmd 	*flag.FlagSet
+	fmtCmd    	*flag.FlagSet
+	initCmd   	*flag.FlagSet
+	exportCmd  	*flag.FlagSet
 	path      	string
 }
 
@@ -25,18 +33,59 @@ func (a *Args) InitArgs() {
 	a.statCmd.Bool("e", false, "List in-scope entities only.")
 	a.statCmd.Bool("r", false, "List roles only.")
 	a.statCmd.Bool("f", false, "List flows only.")
+	a.statCmd.Bool("s", false, "Show STRIDE coverage of external entities, entities and flows only.")
+	a.statCmd.Bool("t", false, "Show CAPEC, ATT&CK and CWE references of attacks and defenses only.")
+	a.statCmd.String("filter", "", "Only include externals, entities and flows matching `EXPR` (like 'tag:pci and type:program').")
+	a.statCmd.Bool("locked", false, "Fail if ADDB content differs from the model's lock file.")
+	a.statCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
 
 	a.diagCmd = flag.NewFlagSet("diag", flag.ExitOnError)
 	a.diagCmd.Bool("sm", false, "Generate security model diagram only.")
 	a.diagCmd.Bool("adm", false, "Generate ADM decision graph only.")
 	a.diagCmd.String("d", "./", "Output directory for diagrams.")
+	a.diagCmd.Bool("deploy", false, "Generate deployment diagram only.")
+	a.diagCmd.String("filter", "", "Only include externals, entities and flows matching `EXPR` (like 'tag:pci and type:program').")
+	a.diagCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
 
 	a.reportCmd = flag.NewFlagSet("report", flag.ExitOnError)
 	a.reportCmd.String("d", "./", "Output directory for generated report.")
-	/*
+	a.reportCmd.String("filter", "", "Only include externals, entities and flows matching `EXPR` (like 'tag:pci and type:program').")
+	a.reportCmd.String("format", "markdown", "Report format. Supported values - markdown,compliance,csv,sarif.")
+	a.reportCmd.Bool("locked", false, "Fail if ADDB content differs from the model's lock file.")
+	a.reportCmd.String("owner", "", "Only report on entities and flows owned by `OWNER` (owner or team).")
+	a.reportCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
+
+	a.lockCmd = flag.NewFlagSet("lock", flag.ExitOnError)
+	a.lockCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
+
+	a.sbomCmd = flag.NewFlagSet("sbom", flag.ExitOnError)
+	a.sbomCmd.String("i", "", "CycloneDX or SPDX file to import. If not specified, SBOMs referred by entities are checked.")
+	a.sbomCmd.String("e", "", "ID of the entity (program/system) to import dependencies into. Required with '-i'.")
+	a.sbomCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
+
+	a.scanCmd = flag.NewFlagSet("scan", flag.ExitOnError)
+	a.scanCmd.String("e", "", "Scan only the repository of this entity.")
+	a.scanCmd.Bool("w", false, "Add suggested languages and dependencies to the model file.")
+	a.scanCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
+
+	a.schemaCmd = flag.NewFlagSet("schema", flag.ExitOnError)
+
+	a.fmtCmd = flag.NewFlagSet("fmt", flag.ExitOnError)
+	a.fmtCmd.Bool("w", false, "Write formatted content back to the files instead of printing it.")
+	a.fmtCmd.Bool("check", false, "List files that are not formatted and fail if there are any.")
+
+	a.initCmd = flag.NewFlagSet("init", flag.ExitOnError)
+	a.initCmd.String("t", "blank", "Template to start from. Supported values - "+strings.Join(templates.Names(), ",")+".")
+	a.initCmd.String("title", "", "Title of the model.")
+	a.initCmd.String("addb", "~/addb", "ADDB used by the model.")
+	a.initCmd.Bool("i", false, "Prompt for external entities, entities and flows to add.")
+
 	a.exportCmd = flag.NewFlagSet("export", flag.ExitOnError)
-	a.exportCmd.String("f", "json", "Output format. Supported values - json,xml.")
-	a.exportCmd.String("d", "./", "Output directory for exported files.")*/
+	a.exportCmd.String("f", "oscal", "Output format. Supported values - oscal.")
+	a.exportCmd.String("d", "./", "Output directory for exported files.")
+	a.exportCmd.String("filter", "", "Only include externals, entities and flows matching `EXPR` (like 'tag:pci and type:program').")
+	a.exportCmd.String("profile", "", "`URL` of the OSCAL profile (or catalog) the plan imports. Defaults to the 'profile' of frameworks in ADDB's control catalogs.")
+	a.exportCmd.Var(parameterValues{}, "set", "Override the value of a model parameter with `NAME=VALUE`. Can be repeated.")
 }
 
 func (a *Args) PrintHelpToStdout() {
@@ -57,8 +106,26 @@ func (a *Args) PrintHelpToStdout() {
 	fmt.Println("\nreport: Generate security report as markdown file.")
 	a.reportCmd.PrintDefaults()
 
-	/*fmt.Println("\nexport: Export security model and report to other formats.")
-	a.exportCmd.PrintDefaults()*/
+	fmt.Println("\nlock: Record ADDB content used by the model in a '.smspec.lock' file.")
+	a.lockCmd.PrintDefaults()
+
+	fmt.Println("\nsbom: Import SBOM components as dependencies of a program and list components without an ADDB entry.")
+	a.sbomCmd.PrintDefaults()
+
+	fmt.Println("\nscan: Suggest languages and dependencies by scanning programs' local repositories.")
+	a.scanCmd.PrintDefaults()
+
+	fmt.Println("\nschema: Generate JSON schemas for models and ADDB entries in the directory specified as [PATH].")
+	a.schemaCmd.PrintDefaults()
+
+	fmt.Println("\nfmt: Rewrite smspec files (models, fragments and ADDB entries) in canonical layout.")
+	a.fmtCmd.PrintDefaults()
+
+	fmt.Println("\ninit: Create a new model as [PATH] (or 'model.smspec' in directory [PATH]) with a model-level ADM file.")
+	a.initCmd.PrintDefaults()
+
+	fmt.Println("\nexport: Export security model to other formats.")
+	a.exportCmd.PrintDefaults()
 }
 
 func (a Args) ParseArgs(args []string) error {
@@ -89,8 +156,14 @@ func (a Args) ParseArgs(args []string) error {
 		eFlag, _ := strconv.ParseBool(a.statCmd.Lookup("e").Value.String())
 		rFlag, _ := strconv.ParseBool(a.statCmd.Lookup("r").Value.String())
 		fFlag, _ := strconv.ParseBool(a.statCmd.Lookup("f").Value.String())
+		sFlag, _ := strconv.ParseBool(a.statCmd.Lookup("s").Value.String())
+		tFlag, _ := strconv.ParseBool(a.statCmd.Lookup("t").Value.String())
+		lockedFlag, _ := strconv.ParseBool(a.statCmd.Lookup("locked").Value.String())
+		setFlag := a.statCmd.Lookup("set").Value.(parameterValues)
+		
+		filterFlag := a.statCmd.Lookup("filter").Value.String()
 		
-		return statsInvoker(xFlag, eFlag, rFlag, fFlag, a.path)
+		return statsInvoker(xFlag, eFlag, rFlag, fFlag, sFlag, tFlag, filterFlag, lockedFlag, setFlag, a.path)
 
 	case "diag":
 		err := a.diagCmd.Parse(args[1:len(args)-1])
@@ -101,9 +174,13 @@ func (a Args) ParseArgs(args []string) error {
 		}
 		smFlag, _ := strconv.ParseBool(a.diagCmd.Lookup("sm").Value.String())
 		admFlag, _ := strconv.ParseBool(a.diagCmd.Lookup("adm").Value.String())
+		deployFlag, _ := strconv.ParseBool(a.diagCmd.Lookup("deploy").Value.String())
 		dFlag := a.diagCmd.Lookup("d").Value.String()
+		setFlag := a.diagCmd.Lookup("set").Value.(parameterValues)
+
+		filterFlag := a.diagCmd.Lookup("filter").Value.String()
 
-		return diagInvoker(smFlag, admFlag, dFlag, a.path)
+		return diagInvoker(smFlag, admFlag, deployFlag, dFlag, filterFlag, setFlag, a.path)
 
 	case "report":
 		err := a.reportCmd.Parse(args[1:len(args)-1])
@@ -113,8 +190,85 @@ func (a Args) ParseArgs(args []string) error {
 			return err
 		}
 
-		return reportInvoker(a.reportCmd.Lookup("d").Value.String(), a.path)
-/*
+		lockedFlag, _ := strconv.ParseBool(a.reportCmd.Lookup("locked").Value.String())
+		setFlag := a.reportCmd.Lookup("set").Value.(parameterValues)
+
+		return reportInvoker(a.reportCmd.Lookup("d").Value.String(), a.reportCmd.Lookup("format").Value.String(),
+			a.reportCmd.Lookup("filter").Value.String(), a.reportCmd.Lookup("owner").Value.String(), lockedFlag, setFlag, a.path)
+
+	case "lock":
+		err := a.lockCmd.Parse(args[1:len(args)-1])
+		if err != nil {
+			// Control should not reach here. Parse typically does a 'os.Exit()' if something goes wrong.
+			// If you do reach, contact author.
+			return err
+		}
+		setFlag := a.lockCmd.Lookup("set").Value.(parameterValues)
+
+		return lockInvoker(setFlag, a.path)
+
+	case "sbom":
+		err := a.sbomCmd.Parse(args[1:len(args)-1])
+		if err != nil {
+			// Control should not reach here. Parse typically does a 'os.Exit()' if something goes wrong.
+			// If you do reach, contact author.
+			return err
+		}
+		iFlag := a.sbomCmd.Lookup("i").Value.String()
+		eFlag := a.sbomCmd.Lookup("e").Value.String()
+		setFlag := a.sbomCmd.Lookup("set").Value.(parameterValues)
+
+		return sbomInvoker(iFlag, eFlag, setFlag, a.path)
+
+	case "scan":
+		err := a.scanCmd.Parse(args[1:len(args)-1])
+		if err != nil {
+			// Control should not reach here. Parse typically does a 'os.Exit()' if something goes wrong.
+			// If you do reach, contact author.
+			return err
+		}
+		eFlag := a.scanCmd.Lookup("e").Value.String()
+		wFlag, _ := strconv.ParseBool(a.scanCmd.Lookup("w").Value.String())
+		setFlag := a.scanCmd.Lookup("set").Value.(parameterValues)
+
+		return scanInvoker(eFlag, wFlag, setFlag, a.path)
+
+	case "schema":
+		err := a.schemaCmd.Parse(args[1:len(args)-1])
+		if err != nil {
+			// Control should not reach here. Parse typically does a 'os.Exit()' if something goes wrong.
+			// If you do reach, contact author.
+			return err
+		}
+
+		return schemaInvoker(a.path)
+
+	case "fmt":
+		err := a.fmtCmd.Parse(args[1:len(args)-1])
+		if err != nil {
+			// Control should not reach here. Parse typically does a 'os.Exit()' if something goes wrong.
+			// If you do reach, contact author.
+			return err
+		}
+		wFlag, _ := strconv.ParseBool(a.fmtCmd.Lookup("w").Value.String())
+		checkFlag, _ := strconv.ParseBool(a.fmtCmd.Lookup("check").Value.String())
+
+		return fmtInvoker(wFlag, checkFlag, a.path)
+
+	case "init":
+		err := a.initCmd.Parse(args[1:len(args)-1])
+		if err != nil {
+			// Control should not reach here. Parse typically does a 'os.Exit()' if something goes wrong.
+			// If you do reach, contact author.
+			return err
+		}
+		tFlag := a.initCmd.Lookup("t").Value.String()
+		titleFlag := a.initCmd.Lookup("title").Value.String()
+		addbFlag := a.initCmd.Lookup("addb").Value.String()
+		iFlag, _ := strconv.ParseBool(a.initCmd.Lookup("i").Value.String())
+
+		return initInvoker(tFlag, titleFlag, addbFlag, iFlag, a.path)
+
 	case "export":
 		err := a.exportCmd.Parse(args[1:len(args)-1])
 		if err != nil {
@@ -124,9 +278,12 @@ func (a Args) ParseArgs(args []string) error {
 		}
 		fFlag := a.exportCmd.Lookup("f").Value.String()
 		dFlag := a.exportCmd.Lookup("d").Value.String()
+		setFlag := a.exportCmd.Lookup("set").Value.(parameterValues)
+
+		filterFlag := a.exportCmd.Lookup("filter").Value.String()
+		profileFlag := a.exportCmd.Lookup("profile").Value.String()
 
-		return exportInvoker(fFlag, dFlag, a.path)
-*/
+		return exportInvoker(fFlag, dFlag, filterFlag, profileFlag, setFlag, a.path)
 	default:
 		return errors.New("INVALID ARGUMENT - \"" + args[0] + "\"")
 	}
@@ -0,0 +1,161 @@
+package args
+
+import (
+	"addb"
+	"fmt"
+	"securitymodel/objmodel"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type techniqueStatsCommand struct {
+	model objmodel.SecurityModel
+	db    *addb.ADDB
+}
+
+// CAPEC attack pattern, ATT&CK technique or CWE weakness referred to by ADM
+// tags of attacks and defenses
+type catalogReference struct {
+	id        string
+	kind      string
+	entry     *addb.CatalogEntry // nil if ADDB has no catalog entry for 'id'
+	attacks   []string
+	defenses  []string
+	mitigated int // number of 'attacks' that are mitigated
+}
+
+// Order in which kinds of references are listed
+var catalogKinds = []string{addb.CAPEC, addb.ATTACK, addb.CWE}
+
+////////////////////////////////////////
+// 'execute()' implementation
+
+func (t techniqueStatsCommand) execute() error {
+	for _, ref := range findCatalogReferences(t.model, t.db) {
+		line := "\tTechnique: " + ref.id
+		if ref.entry != nil {
+			line += " (" + ref.entry.Name + ")"
+		}
+		line += " - ATTACKS:" + strconv.Itoa(len(ref.attacks)) + ", MITIGATED:" + strconv.Itoa(ref.mitigated)
+		if len(ref.defenses) > 0 {
+			line += ", DEFENSES:" + strconv.Itoa(len(ref.defenses))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// Functions to generate report content
+
+func generateReferencesSection(model objmodel.SecurityModel, db *addb.ADDB) (markdownLines []string) {
+	refs := findCatalogReferences(model, db)
+	if len(refs) == 0 {
+		return
+	}
+	markdownLines = append(markdownLines, "| Reference | Name | Attacks (mitigated) | Defenses |")
+	markdownLines = append(markdownLines, "|---|---|---|---|")
+	for _, ref := range refs {
+		name, url := "", addb.CatalogURL(ref.id)
+		if ref.entry != nil {
+			name, url = ref.entry.Name, ref.entry.URL
+		}
+		attacks := strings.Join(ref.attacks, ", ")
+		if len(ref.attacks) > 0 {
+			attacks += " (" + strconv.Itoa(ref.mitigated) + "/" + strconv.Itoa(len(ref.attacks)) + ")"
+		}
+		markdownLines = append(markdownLines, "| ["+ref.id+"]("+url+") | "+name+" | "+attacks+" | "+strings.Join(ref.defenses, ", ")+" |")
+	}
+	return
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Catalog entries referred to by '@capec:', '@attack:' and '@cwe:' tags of
+// attacks and defenses in a model, sorted by kind and ID. References that are
+// malformed, not in ADDB's catalog or deprecated are reported as warnings.
+func findCatalogReferences(model objmodel.SecurityModel, db *addb.ADDB) (refs []*catalogReference) {
+	var catalog *addb.Catalog
+	if db != nil {
+		var err error
+		if catalog, err = db.Catalog(); err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		} else {
+			PrintWarnings(catalog.Warnings)
+		}
+	}
+
+	found := make(map[string]*catalogReference)
+	// Entries referred to by tags of an item. Tags are repeated for each
+	// location the item is listed under, so each entry is returned once.
+	add := func(tags []string, item string, title string) (refs []*catalogReference) {
+		seen := make(map[string]bool)
+		for _, tag := range tags {
+			id, kind, ok, err := addb.ParseCatalogTag(tag)
+			if !ok || seen[tag] || (id != "" && seen[id]) {
+				continue
+			}
+			seen[tag] = true
+			if err != nil {
+				fmt.Println("WARNING: " + item + " '" + title + "' has a " + err.Error())
+				continue
+			}
+			ref := found[id]
+			if ref == nil {
+				ref = &catalogReference{id: id, kind: kind}
+				found[id] = ref
+				if catalog != nil {
+					ref.entry, _ = catalog.Lookup(id)
+					if ref.entry == nil && catalog.Has(kind) {
+						fmt.Println("WARNING: '" + id + "' (tag of " + item + " '" + title + "') is not in ADDB's " + kind + " catalog")
+					} else if ref.entry != nil && ref.entry.Deprecated {
+						fmt.Println("WARNING: '" + id + "' (tag of " + item + " '" + title + "') is deprecated")
+					}
+				}
+			}
+			seen[id] = true
+			refs = append(refs, ref)
+		}
+		return
+	}
+
+	attacks := loadAttacks(model.GetADM())
+	for _, title := range sortedKeys(attacks.tags) {
+		for _, ref := range add(attacks.tags[title], "attack", title) {
+			ref.attacks = append(ref.attacks, title)
+			if !attacks.unmitigated[title] {
+				ref.mitigated++
+			}
+		}
+	}
+	for _, title := range sortedKeys(attacks.defenses) {
+		for _, ref := range add(attacks.defenses[title], "defense", title) {
+			ref.defenses = append(ref.defenses, title)
+		}
+	}
+
+	for _, ref := range found {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].kind != refs[j].kind {
+			return indexOf(refs[i].kind, catalogKinds) < indexOf(refs[j].kind, catalogKinds)
+		}
+		if refs[i].kind != addb.ATTACK && len(refs[i].id) != len(refs[j].id) { // numeric IDs
+			return len(refs[i].id) < len(refs[j].id)
+		}
+		return refs[i].id < refs[j].id
+	})
+	return
+}
+
+func indexOf(item string, list []string) int {
+	for i, x := range list {
+		if x == item {
+			return i
+		}
+	}
+	return -1
+}
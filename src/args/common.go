@@ -6,7 +6,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"securitymodel/loaders"
+	"sort"
 )
 
 // Common 'command' interface
@@ -35,25 +36,47 @@ func (l *filelist) WalkDir(path string, d fs.DirEntry, err error) error {
 ////////////////////////////////////////
 // Common functions used across the package
 
-func getContent(path string) (map[string]string, error) {
-	fileAndContent := make(map[string]string)
+// A security model file along with its location. Relative paths in a
+// model (ADM files, includes, etc.) are resolved against its directory.
+type modelFile struct {
+	path    string
+	dir     string
+	content string
+}
 
+// Read all models in 'path', sorted by their path. Files included by other
+// models are fragments, not models, and are skipped.
+func getModels(path string) ([]modelFile, error) {
 	files, err := getFiles(path)
 	if err != nil {
 		return nil, err
 	}
-	if len(files) > 0 {
-		fmt.Println("Found", len(files), "file(s)")
-	}
+
+	var models []modelFile
+	fragments := make(map[string]bool)
 	for _, file := range files {
-		newContent, err := getFileContent(file)
+		content, err := getFileContent(file)
 		if err != nil {
 			return nil, err
 		}
-		fileAndContent[getFileName(file)] = newContent
+		models = append(models, modelFile{path: file, dir: filepath.Dir(file), content: content})
+		for _, fragment := range loaders.IncludedFiles(content, filepath.Dir(file)) {
+			fragments[filepath.Clean(fragment)] = true
+		}
+	}
+
+	var filtered []modelFile
+	for _, m := range models {
+		if !fragments[filepath.Clean(m.path)] {
+			filtered = append(filtered, m)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].path < filtered[j].path })
+	if len(filtered) > 0 {
+		fmt.Println("Found", len(filtered), "file(s)")
 	}
 
-	return fileAndContent, nil
+	return filtered, nil
 }
 
 func getFiles(path string) ([]string, error) {
@@ -83,27 +106,13 @@ func getFileContent(path string) (string, error) {
 	return content, nil
 }
 
-func getFileName(path string) string {
-	parts := strings.Split(path, "/")
-	return parts[len(parts) - 1]
-}
-
 func checkAndCreateDirectory(directory string) string {
 	if directory[len(directory) - 1] != '/' { // append a "/" if directory string doesn't contain it.
 		directory = directory + "/"
 	}
-	var path string
-	for _, dir := range strings.Split(directory, "/") {
-		if dir != "" {
-			path += dir
-			if _, err := os.Stat(path); err != nil {
-				err = os.Mkdir(path, 0700) // create directory with RW rights to owner only.
-				if err != nil {
-					panic(err)
-				}
-			}
-			path += "/"
-		}
+	err := os.MkdirAll(directory, 0700) // create directory with RW rights to owner only.
+	if err != nil {
+		panic(err)
 	}
 
 	return directory
@@ -113,4 +122,10 @@ func PrintErrors(errs []error) {
 	for _, err := range errs {
 		fmt.Println("ERROR: " + err.Error())
 	}
+}
+
+func PrintWarnings(warnings []error) {
+	for _, w := range warnings {
+		fmt.Println("WARNING: " + w.Error())
+	}
 }
\ No newline at end of file
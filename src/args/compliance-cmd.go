@@ -0,0 +1,263 @@
+package args
+
+import (
+	"addb"
+	"fmt"
+	"os"
+	"securitymodel/objmodel"
+	"sort"
+	"strings"
+)
+
+type generateComplianceCommand struct {
+	model      objmodel.SecurityModel
+	db         *addb.ADDB // source of control catalogs
+	outputpath string
+}
+
+// Control of a compliance framework along with defenses and mitigations that
+// implement it
+type tracedControl struct {
+	framework       string
+	id              string
+	title           string
+	implementations []*controlImplementation
+}
+
+// ADM defense or smspec mitigation referring to a control
+type controlImplementation struct {
+	title      string
+	mitigation bool
+	locations  []string // qualified-names of security-model items the defense/mitigation is listed under
+}
+
+////////////////////////////////////////
+// 'execute()' implementation
+
+func (g generateComplianceCommand) execute() error {
+	markdownReport := strings.Join(generateComplianceReport(g.model, g.db), "\n")
+	outpath := checkAndCreateDirectory(g.outputpath)
+	outpath = checkAndCreateDirectory(outpath + "report")
+	return os.WriteFile(outpath+complianceFileName(g.model), []byte(markdownReport), 0777)
+}
+
+////////////////////////////////////////
+// Functions to generate report content
+
+func generateComplianceReport(model objmodel.SecurityModel, db *addb.ADDB) (markdownLines []string) {
+	markdownLines = append(markdownLines, "# Compliance Report: "+model.Title)
+	markdownLines = appendLineSpacer(markdownLines)
+	markdownLines = append(markdownLines, "This report traces controls of compliance frameworks to ADM defenses and smspec mitigations "+
+		"that refer to them using `@control:<framework>:<control ID>` tags, and to entities/flows that implement them. "+
+		"Controls are listed in the order of control catalogs in ADDB.")
+	markdownLines = appendLineSpacer(markdownLines)
+
+	controls := traceControls(model, db)
+	if len(controls) == 0 {
+		markdownLines = append(markdownLines, "No control catalogs were found in ADDB and no defenses/mitigations refer to controls.")
+		return
+	}
+
+	names := make(map[string]string)
+	if db != nil {
+		for _, f := range db.Frameworks() {
+			names[f.Id] = f.Name
+		}
+	}
+	for i := 0; i < len(controls); {
+		framework := controls[i].framework
+		var rows, gaps []string
+		for ; i < len(controls) && controls[i].framework == framework; i++ {
+			c := controls[i]
+			if len(c.implementations) == 0 {
+				rows = append(rows, "| "+c.id+" | "+c.title+" | **none** | |")
+				gaps = append(gaps, "* "+strings.TrimSuffix(c.id+" - "+c.title, " - "))
+				continue
+			}
+			for _, impl := range c.implementations {
+				rows = append(rows, "| "+c.id+" | "+c.title+" | "+impl.describe()+" | "+implementationLocations(impl.locations)+" |")
+			}
+		}
+
+		heading := "`" + framework + "`"
+		if names[framework] != "" {
+			heading = names[framework] + " (" + heading + ")"
+		}
+		markdownLines = append(markdownLines, "## "+heading)
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "| Control | Title | Defense/Mitigation | Implemented by |")
+		markdownLines = append(markdownLines, "|---|---|---|---|")
+		markdownLines = append(markdownLines, rows...)
+		markdownLines = appendLineSpacer(markdownLines)
+		if len(gaps) > 0 {
+			markdownLines = append(markdownLines, "### Controls without defenses")
+			markdownLines = appendLineSpacer(markdownLines)
+			markdownLines = append(markdownLines, gaps...)
+			markdownLines = appendLineSpacer(markdownLines)
+		}
+	}
+	return
+}
+
+func (impl controlImplementation) describe() string {
+	if impl.mitigation {
+		return impl.title + " (mitigation)"
+	}
+	return impl.title + " (defense)"
+}
+
+// Readable locations of a defense/mitigation, without duplicates
+func implementationLocations(qualifiedNames []string) string {
+	var locations []string
+	seen := make(map[string]bool)
+	for _, qualifiedName := range qualifiedNames {
+		location := "model"
+		if qualifiedName != "sm" {
+			location = riskLocation(qualifiedName)
+		}
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, "`"+location+"`")
+		}
+	}
+	return strings.Join(locations, ", ")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Controls in ADDB's control catalogs and controls referred to by defenses and
+// mitigations, grouped by framework. Within a framework, controls in catalogs
+// (in catalog order) are followed by controls that are not in any catalog
+// (sorted by ID). Each control lists the defenses and mitigations that refer
+// to it.
+func traceControls(model objmodel.SecurityModel, db *addb.ADDB) (controls []*tracedControl) {
+	found := make(map[string]*tracedControl) // by lower-case '<framework>:<control ID>'
+	if db != nil {
+		for _, f := range db.Frameworks() {
+			for _, c := range f.Controls {
+				control := &tracedControl{framework: f.Id, id: c.Id, title: c.Title}
+				found[f.Id+":"+strings.ToLower(c.Id)] = control
+				controls = append(controls, control)
+			}
+		}
+	}
+
+	var uncataloged []*tracedControl
+	implementations := make(map[string]*controlImplementation) // by title, per kind
+	add := func(tags []string, title string, mitigation bool, qualifiedName string) {
+		item := "defense"
+		if mitigation {
+			item = "mitigation"
+		}
+		for _, tag := range tags {
+			framework, id, ok, err := addb.ParseControlTag(tag)
+			if !ok {
+				continue
+			}
+			if err != nil {
+				fmt.Println("WARNING: " + item + " '" + title + "' has a " + err.Error())
+				continue
+			}
+			control := found[framework+":"+strings.ToLower(id)]
+			if control == nil {
+				if db != nil && len(db.Frameworks()) > 0 {
+					fmt.Println("WARNING: '" + tag + "' (tag of " + item + " '" + title + "') refers to a control that is not in ADDB's control catalogs")
+				}
+				control = &tracedControl{framework: framework, id: id}
+				found[framework+":"+strings.ToLower(id)] = control
+				uncataloged = append(uncataloged, control)
+			}
+			key := item + ":" + framework + ":" + strings.ToLower(id) + ":" + title
+			impl := implementations[key]
+			if impl == nil {
+				impl = &controlImplementation{title: title, mitigation: mitigation}
+				implementations[key] = impl
+				control.implementations = append(control.implementations, impl)
+			}
+			impl.locations = append(impl.locations, qualifiedName)
+		}
+	}
+
+	attacks := loadAttacks(model.GetADM())
+	for _, title := range sortedKeys(attacks.implemented) {
+		for _, qualifiedName := range sortedKeys(attacks.implemented[title]) {
+			add(attacks.implemented[title][qualifiedName], title, false, qualifiedName)
+		}
+	}
+	for _, id := range sortedKeys(model.Entities) {
+		if _, ok := model.Entities[id].(*objmodel.Role); ok { // Roles will be processed as part of entity that uses it.
+			continue
+		}
+		addMitigations(model.Entities[id], "sm.entities."+id, add)
+	}
+	for _, id := range sortedKeys(model.Flows) {
+		addMitigations(model.Flows[id], "sm.flows."+id, add)
+	}
+
+	sort.Slice(uncataloged, func(i, j int) bool {
+		if uncataloged[i].framework != uncataloged[j].framework {
+			return uncataloged[i].framework < uncataloged[j].framework
+		}
+		return uncataloged[i].id < uncataloged[j].id
+	})
+	controls = append(controls, uncataloged...)
+
+	// Group controls by framework, keeping the order of catalogs. Frameworks
+	// without a catalog are listed last.
+	order := make(map[string]int)
+	if db != nil {
+		for i, f := range db.Frameworks() {
+			order[f.Id] = i + 1
+		}
+	}
+	sort.SliceStable(controls, func(i, j int) bool {
+		oi, oj := order[controls[i].framework], order[controls[j].framework]
+		if oi != oj {
+			return oj == 0 || (oi != 0 && oi < oj)
+		}
+		return oi == 0 && controls[i].framework < controls[j].framework
+	})
+	for _, control := range controls {
+		sortImplementations(control.implementations)
+	}
+	return
+}
+
+func addMitigations(spec objmodel.EntitySpec, qualifiedName string, add func([]string, string, bool, string)) {
+	mitigations := spec.GetMitigations()
+	for _, source := range sortedKeys(mitigations) {
+		for _, mitigation := range mitigations[source] {
+			_, tags := addb.MitigationControls(mitigation)
+			add(tags, mitigationText(mitigation), true, qualifiedName)
+		}
+	}
+}
+
+// Text of a mitigation as shown in reports, without its control tags.
+// Mitigations that consist of tags only are shown as the controls they refer
+// to.
+func mitigationText(mitigation string) string {
+	text, tags := addb.MitigationControls(mitigation)
+	if text != "" || len(tags) == 0 {
+		return text
+	}
+	for i := range tags {
+		tags[i] = tags[i][len(addb.ControlTag):]
+	}
+	return strings.Join(tags, ", ")
+}
+
+// Defenses before mitigations, each sorted by title
+func sortImplementations(implementations []*controlImplementation) {
+	sort.SliceStable(implementations, func(i, j int) bool {
+		if implementations[i].mitigation != implementations[j].mitigation {
+			return !implementations[i].mitigation
+		}
+		return implementations[i].title < implementations[j].title
+	})
+}
+
+func complianceFileName(model objmodel.SecurityModel) string {
+	return modelFileID(model) + ".compliance.md"
+}
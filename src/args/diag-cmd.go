@@ -1,6 +1,7 @@
 package args
 
 import (
+	"addb"
 	"fmt"
 	"libadm/graph"
 	"libadm/graphviz"
@@ -22,6 +23,11 @@ type generateSmCommand struct {
 	outputpath string
 }
 
+type generateDeploymentCommand struct {
+	model      objmodel.SecurityModel
+	outputpath string
+}
+
 ////////////////////////////////////////
 // 'execute()' implementation for each command
 
@@ -60,7 +66,7 @@ func (g generateAdmCommand) execute() error {
 		g.outputpath += "/"
 	}
 	checkAndCreateDirectory(g.outputpath)
-	err = os.WriteFile(g.outputpath+diagram.GenerateID(g.model.Title)+".adm.dot", []byte(output), 0777)
+	err = os.WriteFile(g.outputpath+modelFileID(g.model)+".adm.dot", []byte(output), 0777)
 	if err != nil {
 		return err
 	}
@@ -80,7 +86,7 @@ func (g generateSmCommand) execute() error {
 		g.outputpath += "/"
 	}
 	checkAndCreateDirectory(g.outputpath)
-	err = os.WriteFile(g.outputpath+diagram.GenerateID(g.model.Title)+".sm.dot", []byte(output), 0777)
+	err = os.WriteFile(g.outputpath+modelFileID(g.model)+".sm.dot", []byte(output), 0777)
 	if err != nil {
 		return err
 	}
@@ -88,9 +94,32 @@ func (g generateSmCommand) execute() error {
 	return nil
 }
 
+// Generate deployment diagram, showing nodes and the entities deployed on them.
+// Models without a 'deployment' section don't have one.
+func (g generateDeploymentCommand) execute() error {
+	if len(g.model.Deployment) == 0 {
+		return nil
+	}
+	lines, err := diagram.GenerateDeploymentDiagram(g.model)
+	if err != nil {
+		return err
+	}
+
+	output := strings.Join(lines, "\n")
+	if g.outputpath[len(g.outputpath)-1] != '/' { // append a '/' if path doesn't have it
+		g.outputpath += "/"
+	}
+	checkAndCreateDirectory(g.outputpath)
+	return os.WriteFile(g.outputpath+deploymentFileName(g.model), []byte(output), 0777)
+}
+
 ////////////////////////////////////////
 // Helper functions
 
+func deploymentFileName(model objmodel.SecurityModel) string {
+	return modelFileID(model) + ".deployment.dot"
+}
+
 // Get a list of model objects from a list of adm file paths
 func getADMModels(allADM []string) (models []*model.Model) {
 	for _, admFile := range allADM {
@@ -103,19 +132,19 @@ func getADMModels(allADM []string) (models []*model.Model) {
 	return
 }
 
-// Load a single ADM file into a model object
+// Load a single ADM file (or inline ADM) into a model object
 func getADM(file string) *model.Model {
-	contents, err := getFileContent(file)
+	contents, err := addb.ReadADM(file)
 	if err != nil {
 		fmt.Println(err.Error())
 		return nil
 	}
 	if len(contents) == 0 { //no contents
-		fmt.Println("No ADM content found in " + file)
+		fmt.Println("No ADM content found in " + addb.ADMSource(file))
 		return nil
 	}
 
-	gherkinModel, err := admloaders.LoadGherkinContent(contents)
+	gherkinModel, err := admloaders.LoadGherkinContent(string(contents))
 	if err != nil {
 		fmt.Println(err)
 		return nil
@@ -0,0 +1,262 @@
+package args
+
+import (
+	"addb"
+	"encoding/json"
+	"errors"
+	"os"
+	"securitymodel/objmodel"
+	"securitymodel/oscal"
+	"securitymodel/risk"
+	"strings"
+	"time"
+)
+
+type exportOSCALCommand struct {
+	model      objmodel.SecurityModel
+	db         *addb.ADDB // source of control catalogs
+	profile    string     // href of the imported profile, if not taken from control catalogs
+	outputpath string
+}
+
+// FIPS 199 impact of data, by its classification
+var fips199Impacts = map[string]string{
+	"public":       "fips-199-low",
+	"internal":     "fips-199-low",
+	"confidential": "fips-199-moderate",
+	"restricted":   "fips-199-high",
+}
+
+////////////////////////////////////////
+// 'execute()' implementation
+
+func (e exportOSCALCommand) execute() error {
+	document, err := generateSSP(e.model, e.db, e.profile, time.Now())
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	outpath := checkAndCreateDirectory(e.outputpath)
+	return os.WriteFile(outpath+sspFileName(e.model), append(content, '\n'), 0777)
+}
+
+////////////////////////////////////////
+// Functions to generate OSCAL content
+
+// OSCAL System Security Plan for a security model -
+//   - programs (entities and external entities) are components, while humans
+//     are users. The model itself is the 'this-system' component. Models
+//     without humans get a 'System owner' user, since OSCAL requires one.
+//   - flows are 'interconnection' components, with their protocols.
+//   - defenses in ADM and mitigations in the model that refer to controls (see
+//     '@control:' tags) are implemented requirements of the components they
+//     are listed under. OSCAL requires at least one implemented requirement,
+//     so models without such defenses/mitigations cannot be exported.
+//   - the imported profile is 'profile' or, if it is empty, the profile of
+//     the frameworks these controls belong to (see control catalogs in ADDB).
+func generateSSP(model objmodel.SecurityModel, db *addb.ADDB, profile string, modified time.Time) (oscal.Document, error) {
+	namespace := "adsm:" + model.Title
+	system := oscal.UUID(namespace, "sm")
+	ssp := oscal.SystemSecurityPlan{
+		UUID: oscal.UUID(namespace, "ssp"),
+		Metadata: oscal.Metadata{
+			Title:        "System Security Plan: " + model.Title,
+			LastModified: modified.UTC().Format(time.RFC3339),
+			Version:      "1.0",
+			OSCALVersion: oscal.Version,
+		},
+		SystemCharacteristics: oscal.SystemCharacteristics{
+			SystemIds:             []oscal.SystemId{{IdentifierType: "https://ietf.org/rfc/rfc4122", Id: system}},
+			SystemName:            model.Title,
+			Description:           "Exported from security model '" + model.Title + "'.",
+			SystemInformation:     oscal.SystemInformation{InformationTypes: informationTypes(model, namespace)},
+			Status:                oscal.Status{State: "operational"},
+			AuthorizationBoundary: oscal.AuthorizationBoundary{Description: "Entities and flows of security model '" + model.Title + "'. External entities are outside the boundary."},
+		},
+		ControlImplementation: oscal.ControlImplementation{
+			Description: "Controls implemented by ADM defenses and mitigations in security model '" + model.Title + "'.",
+		},
+	}
+
+	// Components and users. 'components' maps qualified-names of security-model
+	// items to UUIDs of their components.
+	impl := &ssp.SystemImplementation
+	impl.Components = append(impl.Components, oscal.Component{UUID: system, Type: "this-system", Title: model.Title,
+		Description: "Security model '" + model.Title + "'.", Status: oscal.Status{State: "operational"}})
+	components := map[string]string{"sm": system}
+	addEntity := func(spec objmodel.CoreSpec, qualifiedName string, scope string) {
+		id := oscal.UUID(namespace, qualifiedName)
+		props := []oscal.Property{{Name: "id", Ns: oscal.Namespace, Value: spec.GetID()}, {Name: "scope", Ns: oscal.Namespace, Value: scope}}
+		switch spec.(type) {
+		case *objmodel.Program:
+			impl.Components = append(impl.Components, oscal.Component{UUID: id, Type: "software", Title: spec.GetName(),
+				Description: spec.GetDescription(), Props: props, Status: oscal.Status{State: "operational"}})
+			components[qualifiedName] = id
+		case *objmodel.Human:
+			impl.Users = append(impl.Users, oscal.User{UUID: id, Title: spec.GetName(), Description: spec.GetDescription(), Props: props})
+		}
+	}
+	for _, id := range sortedKeys(model.Externals) {
+		addEntity(model.Externals[id], "sm.externals."+id, "external")
+	}
+	for _, id := range sortedKeys(model.Entities) {
+		addEntity(model.Entities[id], "sm.entities."+id, "in-scope")
+	}
+	if len(impl.Users) == 0 {
+		impl.Users = append(impl.Users, oscal.User{UUID: oscal.UUID(namespace, "user.system-owner"), Title: "System owner",
+			Description: "Owner of '" + model.Title + "'. The security model has no humans."})
+	}
+	party := func(spec objmodel.CoreSpec) string { // link to the sender/receiver of a flow
+		if spec == nil {
+			return ""
+		}
+		if _, external := model.Externals[spec.GetID()]; external {
+			return "#" + oscal.UUID(namespace, "sm.externals."+spec.GetID())
+		}
+		if _, entity := model.Entities[spec.GetID()]; entity {
+			return "#" + oscal.UUID(namespace, "sm.entities."+spec.GetID())
+		}
+		return "" // left out by a filter
+	}
+	for _, id := range sortedKeys(model.Flows) {
+		flow := model.Flows[id]
+		c := oscal.Component{UUID: oscal.UUID(namespace, "sm.flows."+id), Type: "interconnection", Title: flow.GetName(),
+			Description: flow.GetDescription(), Props: []oscal.Property{{Name: "id", Ns: oscal.Namespace, Value: id}},
+			Status: oscal.Status{State: "operational"}}
+		if href := party(flow.GetSender()); href != "" {
+			c.Links = append(c.Links, oscal.Link{Href: href, Rel: "sender"})
+		}
+		if href := party(flow.GetReceiver()); href != "" {
+			c.Links = append(c.Links, oscal.Link{Href: href, Rel: "receiver"})
+		}
+		protocols := flow.GetProtocol()
+		for _, protocolId := range sortedKeys(protocols) {
+			c.Protocols = append(c.Protocols, oscal.Protocol{UUID: oscal.UUID(namespace, "sm.flows."+id+".protocol."+protocolId),
+				Name: protocolId, Title: protocols[protocolId].GetName()})
+		}
+		impl.Components = append(impl.Components, c)
+		components["sm.flows."+id] = c.UUID
+	}
+
+	// Implemented requirements
+	requirements := []oscal.ImplementedRequirement{}
+	frameworks := make(map[string]bool) // of implemented controls
+	for _, control := range traceControls(model, db) {
+		if len(control.implementations) == 0 {
+			continue
+		}
+		frameworks[control.framework] = true
+		key := "control." + control.framework + "." + strings.ToLower(control.id)
+		requirement := oscal.ImplementedRequirement{UUID: oscal.UUID(namespace, key), ControlId: oscal.ControlId(control.id),
+			Props: []oscal.Property{{Name: "framework", Ns: oscal.Namespace, Value: control.framework}}}
+		descriptions := make(map[string][]string) // by component UUID
+		var order []string
+		for _, implementation := range control.implementations {
+			kind := "Defense"
+			if implementation.mitigation {
+				kind = "Mitigation"
+			}
+			for _, qualifiedName := range implementation.locations {
+				component := componentOf(qualifiedName, components)
+				line := kind + ": " + implementation.title
+				if descriptions[component] == nil {
+					order = append(order, component)
+				}
+				if !contains(line, descriptions[component]) {
+					descriptions[component] = append(descriptions[component], line)
+				}
+			}
+		}
+		for _, component := range order {
+			requirement.ByComponents = append(requirement.ByComponents, oscal.ByComponent{ComponentUUID: component,
+				UUID: oscal.UUID(namespace, key+"."+component), Description: strings.Join(descriptions[component], "\n")})
+		}
+		requirements = append(requirements, requirement)
+	}
+	if len(requirements) == 0 {
+		return oscal.Document{}, errors.New("cannot export security model '" + model.Title + "' to OSCAL - no defenses or mitigations refer to controls (see '" + addb.ControlTag + "' tags)")
+	}
+	ssp.ControlImplementation.ImplementedRequirements = requirements
+
+	if profile == "" {
+		var err error
+		if profile, err = frameworkProfile(db, sortedKeys(frameworks)); err != nil {
+			return oscal.Document{}, errors.New("cannot export security model '" + model.Title + "' to OSCAL - " + err.Error())
+		}
+	}
+	ssp.ImportProfile = oscal.ImportProfile{Href: profile}
+
+	return oscal.Document{SSP: ssp}, nil
+}
+
+// Profile of frameworks, taken from control catalogs in ADDB. All frameworks
+// must have the same profile.
+func frameworkProfile(db *addb.ADDB, frameworks []string) (profile string, err error) {
+	profiles := make(map[string]string) // by framework
+	if db != nil {
+		for _, f := range db.Frameworks() {
+			if f.Profile != "" {
+				profiles[f.Id] = f.Profile
+			}
+		}
+	}
+	for _, framework := range frameworks {
+		switch {
+		case profiles[framework] == "":
+			return "", errors.New("framework '" + framework + "' has no 'profile' in ADDB's control catalogs. Add one or pass '-profile'")
+		case profile != "" && profiles[framework] != profile:
+			return "", errors.New("controls come from frameworks with different profiles - " + strings.Join(frameworks, ", ") + ". Pass '-profile' with a profile that includes all of them")
+		}
+		profile = profiles[framework]
+	}
+	return
+}
+
+// Information types, one for each data classification used by entities and
+// flows. Classifications set the FIPS 199 impact of confidentiality,
+// integrity and availability.
+func informationTypes(model objmodel.SecurityModel, namespace string) (types []oscal.InformationType) {
+	used := make(map[string]bool)
+	for _, entity := range model.Entities {
+		used[strings.ToLower(entity.GetRiskAttributes().DataClassification)] = true
+	}
+	for _, flow := range model.Flows {
+		used[strings.ToLower(flow.GetRiskAttributes().DataClassification)] = true
+	}
+	informationType := func(key string, title string, description string, impact string) oscal.InformationType {
+		return oscal.InformationType{UUID: oscal.UUID(namespace, key), Title: title, Description: description,
+			ConfidentialityImpact: oscal.Impact{Base: impact},
+			IntegrityImpact:       oscal.Impact{Base: impact},
+			AvailabilityImpact:    oscal.Impact{Base: impact}}
+	}
+	for _, classification := range risk.DataClassifications {
+		if used[classification] {
+			types = append(types, informationType("information."+classification, capitalize(classification)+" data",
+				"Data classified as '"+classification+"' in the security model.", fips199Impacts[classification]))
+		}
+	}
+	if len(types) == 0 {
+		types = append(types, informationType("information", "System data",
+			"Data handled by '"+model.Title+"'. Entities and flows don't have a data-classification.", "fips-199-moderate"))
+	}
+	return
+}
+
+// UUID of the component a security-model item belongs to. Items of humans and
+// the model itself belong to the 'this-system' component.
+func componentOf(qualifiedName string, components map[string]string) string {
+	prefix := "sm"
+	for candidate := range components {
+		if len(candidate) > len(prefix) && listedUnder(candidate, []string{qualifiedName}) {
+			prefix = candidate
+		}
+	}
+	return components[prefix]
+}
+
+func sspFileName(model objmodel.SecurityModel) string {
+	return modelFileID(model) + ".ssp.json"
+}
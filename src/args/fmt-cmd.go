@@ -0,0 +1,60 @@
+package args
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"securitymodel/yamlmodel"
+	"strconv"
+)
+
+type formatCommand struct {
+	files []string
+	write bool // rewrite files instead of printing them
+	check bool // only report files that are not formatted
+}
+
+////////////////////////////////////////
+// 'execute()' implementation for each command
+
+// Rewrite smspec files (models, fragments and ADDB entries) in canonical
+// layout. Formatted content is printed, unless it is written back to the
+// files ('-w') or files are only checked ('-check').
+func (f formatCommand) execute() error {
+	unformatted := 0
+	for _, file := range f.files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		formatted, err := yamlmodel.Format(content)
+		if err != nil {
+			return errors.New("cannot format '" + file + "' - " + err.Error())
+		}
+		if bytes.Equal(content, formatted) {
+			if !f.write && !f.check {
+				fmt.Print(string(formatted))
+			}
+			continue
+		}
+
+		unformatted++
+		switch {
+		case f.check:
+			fmt.Println(file + " is not formatted")
+		case f.write:
+			if err := os.WriteFile(file, formatted, 0644); err != nil {
+				return err
+			}
+			fmt.Println("Formatted " + file)
+		default:
+			fmt.Print(string(formatted))
+		}
+	}
+
+	if f.check && unformatted > 0 {
+		return errors.New(strconv.Itoa(unformatted) + " file(s) are not formatted. Use 'adsm fmt -w' to format them")
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ replace libadm => ../../../adm/src/libadm
 
 replace addb => ../addb
 
+replace schema => ../schema
+
 require (
 	github.com/goccy/go-graphviz v0.1.0
 	libadm v0.0.0-00010101000000-000000000000
@@ -24,4 +26,5 @@ require (
 	golang.org/x/image v0.5.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	addb v0.0.0-00010101000000-000000000000 // indirect
+	schema v0.0.0-00010101000000-000000000000 // indirect
 )
@@ -0,0 +1,164 @@
+package args
+
+import (
+	"addb"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"securitymodel/templates"
+	"securitymodel/yamlmodel"
+	"strings"
+)
+
+type initCommand struct {
+	modelPath   string
+	template    string
+	title       string
+	addbPath    string
+	interactive bool      // prompt for externals, entities and flows
+	input       io.Reader // answers to prompts
+}
+
+////////////////////////////////////////
+// 'execute()' implementation for each command
+
+// Create a new model from a template, along with its model-level ADM file.
+// ADDB references that are not in the ADDB are left out.
+func (i initCommand) execute() error {
+	if _, err := os.Stat(i.modelPath); err == nil {
+		return errors.New("'" + i.modelPath + "' already exists")
+	}
+
+	var p *prompter
+	if i.interactive {
+		p = &prompter{scanner: bufio.NewScanner(i.input)}
+		if i.title == "" {
+			i.title = p.ask("Title", "")
+		}
+	}
+	if i.title == "" {
+		i.title = "Security model"
+	}
+	m, err := templates.New(i.template, i.title, i.addbPath)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		p.addItems(m)
+	}
+
+	var db addb.ADDB
+	if err := db.Init(i.addbPath); err != nil {
+		fmt.Println("WARNING: cannot check ADDB references - " + err.Error())
+	} else {
+		var missing []string
+		for _, ref := range templates.ADDBReferences(m) {
+			if _, err := db.GetComponent(ref); err != nil {
+				fmt.Println("WARNING: '" + ref + "' is not in ADDB. Removed it from the model.")
+				missing = append(missing, ref)
+			}
+		}
+		templates.RemoveReferences(m, missing)
+	}
+
+	content, err := yamlmodel.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(i.modelPath), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(i.modelPath, content, 0644); err != nil {
+		return err
+	}
+	fmt.Println("Created " + i.modelPath)
+
+	admPath := filepath.Join(filepath.Dir(i.modelPath), templates.ModelADMFile)
+	if _, err := os.Stat(admPath); err == nil {
+		return nil // keep existing ADM
+	}
+	if err := os.MkdirAll(filepath.Dir(admPath), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(admPath, []byte(templates.ModelADM(i.title)), 0644); err != nil {
+		return err
+	}
+	fmt.Println("Created " + admPath)
+	return nil
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Read answers, one per line. Once input ends, defaults are used.
+type prompter struct {
+	scanner *bufio.Scanner
+}
+
+func (p *prompter) ask(question string, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Print(question + " [" + defaultValue + "]: ")
+	} else {
+		fmt.Print(question + ": ")
+	}
+	answer := ""
+	if p.scanner.Scan() {
+		answer = strings.TrimSpace(p.scanner.Text())
+	}
+	if answer == "" {
+		return defaultValue
+	}
+	return answer
+}
+
+// Ask until one of 'options' is entered
+func (p *prompter) choose(question string, options []string, defaultValue string) string {
+	for {
+		answer := p.ask(question+" ("+strings.Join(options, "/")+")", defaultValue)
+		if contains(answer, options) {
+			return answer
+		}
+		fmt.Println("'" + answer + "' is not one of - " + strings.Join(options, ", "))
+	}
+}
+
+// Prompt for externals, entities and flows to add to 'm', until an empty ID
+// is entered for each.
+func (p *prompter) addItems(m *yamlmodel.SecurityModel) {
+	for {
+		id := p.ask("ID of external entity to add (leave empty to continue)", "")
+		if id == "" {
+			break
+		}
+		e := yamlmodel.Entity{Id: id, Type: yamlmodel.ItemType(p.choose("Type", []string{"human", "program"}, "human"))}
+		e.Name = p.ask("Name", id)
+		e.Description = p.ask("Description", e.Name)
+		if e.Type == yamlmodel.Human {
+			e.Interface = p.ask("ID of the program used to interact with the system", "")
+		}
+		m.Externals = append(m.Externals, &e)
+	}
+	for {
+		id := p.ask("ID of entity to add (leave empty to continue)", "")
+		if id == "" {
+			break
+		}
+		e := yamlmodel.Entity{Id: id, Type: yamlmodel.ItemType(p.choose("Type", []string{"human", "program", "role"}, "program"))}
+		e.Name = p.ask("Name", id)
+		e.Description, e.ADM = p.ask("Description", e.Name), []string{}
+		m.Entities = append(m.Entities, &e)
+	}
+	for {
+		id := p.ask("ID of flow to add (leave empty to finish)", "")
+		if id == "" {
+			break
+		}
+		f := yamlmodel.Flow{Id: id, Name: p.ask("Name", id)}
+		f.Description = p.ask("Description", f.Name)
+		f.Sender, f.Receiver, f.ADM = p.ask("ID of the sender", ""), p.ask("ID of the receiver", ""), []string{}
+		m.Flows = append(m.Flows, &f)
+	}
+}
@@ -5,110 +5,391 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"securitymodel/filter"
 	"securitymodel/loaders"
+	"securitymodel/objmodel"
+	"sort"
+	"strings"
 )
 
-func statsInvoker(x bool, e bool, r bool, f bool, path string) error {
+func statsInvoker(x bool, e bool, r bool, f bool, s bool, t bool, filterText string, locked bool, params map[string]string, path string) error {
 	err := checkPath(path)
 	if err != nil {
 		return errors.New("error when verifying path - '" + path + "'")
 	}
+	itemFilter, err := parseFilter(filterText)
+	if err != nil {
+		return err
+	}
 
-	// Special case: If all flags are 'false' (i.e., none were specified) turn all of them to 'true'
-	if !(x || e || r || f) {
+	// Special case: If all flags are 'false' (i.e., none were specified) list all items.
+	// STRIDE coverage ('-s') and references ('-t') are shown only when asked for.
+	if !(x || e || r || f || s || t) {
 		x = true
 		e = true
 		r = true
 		f = true
 	}
 
-	models, err := getContent(path)
+	// '-x', '-e', '-r' and '-f' select the kind of items listed
+	var kinds []string
+	for i, flag := range []bool{x, e, r, f} {
+		if flag {
+			kinds = append(kinds, "("+statListFilters[i]+")")
+		}
+	}
+	listFilterText := strings.Join(kinds, " or ")
+	if filterText != "" && len(kinds) > 0 {
+		listFilterText = "(" + filterText + ") and (" + listFilterText + ")"
+	}
+	listFilter, err := parseFilter(listFilterText)
+	if err != nil {
+		return err
+	}
+
+	models, err := getModels(path)
 	if err != nil {
 		return err
 	}
-	for _, modelText := range models {
+	var summary portfolio
+	for _, m := range models {
 		var l loaders.Loader
-		model, errs := l.LoadSecurityModel(modelText, filepath.Dir(path))
-		PrintErrors(errs)                    // send errors to STDOUT
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
+		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		summary.add(m.path, model, errs)
+		if model == nil {
+			continue
+		}
+		err = verifyLockCommand{model: *model, loader: &l, modelPath: m.path, locked: locked}.execute()
+		if err != nil {
+			return err
+		}
 		fmt.Println("MODEL: " + model.Title) // Print the title once (not for each flag)
-		for _, adm := range model.GetADM()["sm"] {
+		selected := selectItems(*model, itemFilter)
+		for _, adm := range selected.GetADM()["sm"] {
 			printADMStatLine(adm)
 		}
-		if x {
-			externalStatsCommand{model: *model}.execute()
-		}
-		if e {
-			entityStatsCommand{model: *model}.execute()
+		if len(kinds) > 0 {
+			listed := selectItems(*model, listFilter)
+			externalStatsCommand{model: listed}.execute()
+			entityStatsCommand{model: listed}.execute()
+			roleStatsCommand{model: listed}.execute()
+			flowStatsCommand{model: listed}.execute()
 		}
-		if r {
-			roleStatsCommand{model: *model}.execute()
+		if s {
+			strideStatsCommand{model: selected, db: l.ADDB()}.execute()
 		}
-		if f {
-			flowStatsCommand{model: *model}.execute()
+		if t {
+			techniqueStatsCommand{model: selected, db: l.ADDB()}.execute()
 		}
 	}
+	summary.printSummary()
 
 	return nil
 }
 
-func diagInvoker(sm bool, adm bool, outPath string, path string) error {
+func diagInvoker(sm bool, adm bool, deploy bool, outPath string, filterText string, params map[string]string, path string) error {
 	err := checkPath(path)
 	if err != nil {
 		return errors.New("error when verifying path - '" + path + "'")
 	}
-	models, err := getContent(path)
+
+	// Special case: If all flags are 'false' (i.e., none were specified) generate all diagrams
+	if !(sm || adm || deploy) {
+		sm = true
+		adm = true
+		deploy = true
+	}
+	itemFilter, err := parseFilter(filterText)
 	if err != nil {
 		return err
 	}
-	for _, modelText := range models {
+	models, err := getModels(path)
+	if err != nil {
+		return err
+	}
+	var summary portfolio
+	for _, m := range models {
 		var l loaders.Loader
-		model, errs := l.LoadSecurityModel(modelText, filepath.Dir(path))
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
 		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		summary.add(m.path, model, errs)
+		if model == nil {
+			continue
+		}
 
-		if sm {
-			generateSmCommand{model: *model, outputpath: outPath}.execute()
+		selected := selectItems(*model, itemFilter)
+		if sm { // flows are drawn along with their senders and receivers
+			generateSmCommand{model: selected.WithFlowEndpoints(*model), outputpath: outPath}.execute()
 		}
 		if adm {
-			generateAdmCommand{model: *model, outputpath: outPath}.execute()
+			generateAdmCommand{model: selected, outputpath: outPath}.execute()
+		}
+		if deploy {
+			generateDeploymentCommand{model: selected, outputpath: outPath}.execute()
 		}
 	}
+	summary.printSummary()
 
 	return nil
 }
 
-func reportInvoker(outPath string, path string) error {
+func reportInvoker(outPath string, format string, filterText string, owner string, locked bool, params map[string]string, path string) error {
 	err := checkPath(path)
 	if err != nil {
 		return errors.New("error when verifying path - '" + path + "'")
 	}
-	models, err := getContent(path)
+	if !contains(format, []string{"markdown", "compliance", "csv", "sarif"}) {
+		return errors.New("unsupported report format '" + format + "'. Supported formats - markdown, compliance, csv, sarif")
+	}
+	itemFilter, err := parseFilter(filterText)
+	if err != nil {
+		return err
+	}
+	models, err := getModels(path)
 	if err != nil {
 		return err
 	}
-	for _, modelText := range models {
+	var summary portfolio
+	for _, m := range models {
 		var l loaders.Loader
-		model, errs := l.LoadSecurityModel(modelText, filepath.Dir(path))
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
 		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		entry := summary.add(m.path, model, errs)
+		if model == nil {
+			continue
+		}
+		err = verifyLockCommand{model: *model, loader: &l, modelPath: m.path, locked: locked}.execute()
+		if err != nil {
+			return err
+		}
+
+		scoped := selectItems(*model, itemFilter)
+		if owner != "" { // externals and roles are kept, since entities owned by the team may use them
+			scoped = scoped.Select(func(item objmodel.CoreSpec, external bool) bool {
+				_, role := item.(*objmodel.Role)
+				entity, ok := item.(objmodel.EntitySpec)
+				return external || role || (ok && entity.GetOwnership().OwnedBy(owner))
+			})
+		}
 
-		generateReportCommand{model: *model, outputpath: outPath}.execute()
+		switch format {
+		case "compliance":
+			err = generateComplianceCommand{model: scoped, db: l.ADDB(), outputpath: outPath}.execute()
+			entry.report = complianceFileName(scoped)
+		case "csv":
+			err = generateRisksCSVCommand{model: scoped, outputpath: outPath}.execute()
+			entry.report = risksCSVFileName(scoped)
+		case "sarif":
+			err = generateSARIFCommand{model: scoped, modelPath: m.path, outputpath: outPath}.execute()
+			entry.report = sarifFileName(scoped)
+		default:
+			generateReportCommand{model: scoped, db: l.ADDB(), outputpath: outPath}.execute()
+			entry.report = reportFileName(scoped)
+		}
+		if err != nil {
+			return err
+		}
+		entry.risks = findUnmitigatedAttacks(scoped)
+		entry.addbReferences = l.ADDBReferences()
+		entry.shared = model.SharedEntities
+	}
+	summary.printSummary()
+	err = generatePortfolioReportCommand{portfolio: summary, outputpath: outPath}.execute()
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
 
-/*
-func exportInvoker(e bool, r bool, f bool, outPath string, path string) error {
+func lockInvoker(params map[string]string, path string) error {
 	err := checkPath(path)
 	if err != nil {
 		return errors.New("error when verifying path - '" + path + "'")
 	}
+	models, err := getModels(path)
+	if err != nil {
+		return err
+	}
+	for _, m := range models {
+		var l loaders.Loader
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
+		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		if model == nil {
+			continue
+		}
+
+		err = generateLockCommand{model: *model, loader: &l, modelPath: m.path}.execute()
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
-}*/
+}
+
+func sbomInvoker(sbomPath string, entityId string, params map[string]string, path string) error {
+	err := checkPath(path)
+	if err != nil {
+		return errors.New("error when verifying path - '" + path + "'")
+	}
+	if sbomPath != "" && entityId == "" {
+		return errors.New("entity to import SBOM into must be specified with '-e'")
+	}
+	models, err := getModels(path)
+	if err != nil {
+		return err
+	}
+	for _, m := range models {
+		var l loaders.Loader
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
+		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		if model == nil {
+			continue
+		}
+
+		if sbomPath != "" {
+			err = importSBOMCommand{model: *model, loader: &l, modelPath: m.path, sbomPath: sbomPath, entityId: entityId}.execute()
+		} else {
+			err = unmappedSBOMCommand{loader: &l}.execute()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanInvoker(entityId string, write bool, params map[string]string, path string) error {
+	err := checkPath(path)
+	if err != nil {
+		return errors.New("error when verifying path - '" + path + "'")
+	}
+	models, err := getModels(path)
+	if err != nil {
+		return err
+	}
+	for _, m := range models {
+		var l loaders.Loader
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
+		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		if model == nil {
+			continue
+		}
+
+		err = scanCommand{model: *model, loader: &l, modelPath: m.path, entityId: entityId, write: write}.execute()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func schemaInvoker(path string) error {
+	return generateSchemaCommand{outputpath: path}.execute()
+}
+
+func fmtInvoker(write bool, check bool, path string) error {
+	err := checkPath(path)
+	if err != nil {
+		return errors.New("error when verifying path - '" + path + "'")
+	}
+	files, err := getFiles(path)
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	return formatCommand{files: files, write: write, check: check}.execute()
+}
+
+func initInvoker(template string, title string, addbPath string, interactive bool, path string) error {
+	if strings.HasPrefix(path, "-") { // like 'adsm init -h', which would otherwise create a directory named '-h'
+		return errors.New("invalid path - '" + path + "'. Path of the new model (or its directory) must be the last argument")
+	}
+	modelPath := path
+	if info, err := os.Stat(path); (err == nil && info.IsDir()) || filepath.Ext(path) != ".smspec" {
+		modelPath = filepath.Join(path, "model.smspec")
+	}
+
+	return initCommand{modelPath: modelPath, template: template, title: title, addbPath: addbPath, interactive: interactive, input: os.Stdin}.execute()
+}
+
+func exportInvoker(format string, outPath string, filterText string, profile string, params map[string]string, path string) error {
+	err := checkPath(path)
+	if err != nil {
+		return errors.New("error when verifying path - '" + path + "'")
+	}
+	if format != "oscal" {
+		return errors.New("unsupported export format '" + format + "'. Supported formats - oscal")
+	}
+	itemFilter, err := parseFilter(filterText)
+	if err != nil {
+		return err
+	}
+	models, err := getModels(path)
+	if err != nil {
+		return err
+	}
+	var summary portfolio
+	for _, m := range models {
+		var l loaders.Loader
+		l.SetParameters(params)
+		model, errs := l.LoadSecurityModel(m.content, m.dir)
+		PrintErrors(errs) // send errors to STDOUT
+		PrintWarnings(l.Warnings())
+		summary.add(m.path, model, errs)
+		if model == nil {
+			continue
+		}
+
+		err = exportOSCALCommand{model: selectItems(*model, itemFilter), db: l.ADDB(), profile: profile, outputpath: outPath}.execute()
+		if err != nil {
+			return err
+		}
+	}
+	summary.printSummary()
+
+	return nil
+}
 
 ////////////////////////////////////////
 // Helper functions
 
+// Filter expressions equivalent to the '-x', '-e', '-r' and '-f' flags of 'stat'
+var statListFilters = []string{"scope:external", "scope:internal and (type:human or type:program)", "type:role", "type:flow"}
+
+// Parse the '-filter' flag of a sub-command. Empty filters select all items.
+func parseFilter(text string) (filter.Expression, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return filter.Parse(text)
+}
+
+// Externals, entities and flows of a model that match a filter
+func selectItems(model objmodel.SecurityModel, e filter.Expression) objmodel.SecurityModel {
+	if e == nil {
+		return model
+	}
+	return filter.Apply(model, e)
+}
+
 func checkPath(path string) error {
 	_, err := os.Stat(path)
 	return err
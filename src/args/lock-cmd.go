@@ -0,0 +1,81 @@
+package args
+
+import (
+	"addb"
+	"errors"
+	"fmt"
+	"os"
+	"securitymodel/loaders"
+	"securitymodel/objmodel"
+)
+
+type generateLockCommand struct {
+	model     objmodel.SecurityModel
+	loader    *loaders.Loader
+	modelPath string
+}
+
+type verifyLockCommand struct {
+	model     objmodel.SecurityModel
+	loader    *loaders.Loader
+	modelPath string
+	locked    bool // fail instead of warning when ADDB content doesn't match the lock
+}
+
+////////////////////////////////////////
+// 'execute()' implementation for each command
+
+// Record all ADDB entries (and their ADM files) used by the model in '<model>.lock'
+func (g generateLockCommand) execute() error {
+	db := g.loader.ADDB()
+	if db == nil || db.Location == "" {
+		return errors.New("cannot lock '" + g.modelPath + "' without a valid ADDB")
+	}
+	lock, err := db.Lock(g.model.AddbPath, g.loader.ADDBReferences())
+	if err != nil {
+		return err
+	}
+	err = lock.WriteFile(lockFilePath(g.modelPath))
+	if err != nil {
+		return err
+	}
+	fmt.Println("Locked", len(lock.Entries), "ADDB entries in "+lockFilePath(g.modelPath))
+	return nil
+}
+
+// Compare ADDB content used by the model with its lock file.
+func (v verifyLockCommand) execute() error {
+	lockPath := lockFilePath(v.modelPath)
+	lock, err := addb.ReadLockFile(lockPath)
+	if os.IsNotExist(err) {
+		if v.locked {
+			return errors.New("lock file '" + lockPath + "' not found. Use 'adsm lock' to create it")
+		}
+		return nil // models without a lock file are not verified
+	} else if err != nil {
+		return err
+	}
+
+	db := v.loader.ADDB()
+	if db == nil {
+		db = &addb.ADDB{}
+	}
+	drift := lock.Verify(db, v.model.AddbPath, v.loader.ADDBReferences())
+	if len(drift) == 0 {
+		return nil
+	}
+	for _, d := range drift {
+		fmt.Println("WARNING: " + d.Error())
+	}
+	if v.locked {
+		return errors.New("ADDB content used by '" + v.modelPath + "' differs from '" + lockPath + "'")
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// Helper functions
+
+func lockFilePath(modelPath string) string {
+	return modelPath + ".lock"
+}
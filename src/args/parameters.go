@@ -0,0 +1,29 @@
+package args
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Values of model parameters passed with '-set NAME=VALUE'. The flag can be
+// repeated to set more than one parameter.
+type parameterValues map[string]string
+
+func (p parameterValues) String() string {
+	var values []string
+	for name, value := range p {
+		values = append(values, name+"="+value)
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func (p parameterValues) Set(value string) error {
+	name, v, found := strings.Cut(value, "=")
+	if !found || strings.TrimSpace(name) == "" {
+		return errors.New("expected NAME=VALUE, got '" + value + "'")
+	}
+	p[strings.TrimSpace(name)] = v
+	return nil
+}
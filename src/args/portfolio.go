@@ -0,0 +1,272 @@
+package args
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"securitymodel/diagram"
+	"securitymodel/objmodel"
+	"securitymodel/yamlmodel"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Summary of all models processed by a command. Printed at the end when a
+// directory contains more than one model.
+type portfolio struct {
+	entries []*portfolioEntry
+}
+
+type portfolioEntry struct {
+	path      string
+	title     string
+	fileID    string // base name of files generated for the model
+	externals int
+	entities  int
+	flows     int
+	errors    int
+
+	// Only for reports
+	report         string              // report file, relative to the report directory
+	risks          map[string][]string // unmitigated attacks, mapped to their locations
+	addbReferences []string
+	shared         map[string]yamlmodel.EntitySource
+}
+
+func (p *portfolio) add(path string, model *objmodel.SecurityModel, errs []error) *portfolioEntry {
+	entry := portfolioEntry{path: path, errors: len(errs)}
+	if model != nil {
+		model.FileID = p.uniqueFileID(*model)
+		entry.fileID = model.FileID
+		entry.title = model.Title
+		entry.externals = len(model.Externals)
+		entry.entities = len(model.Entities)
+		entry.flows = len(model.Flows)
+	}
+	p.entries = append(p.entries, &entry)
+	return &entry
+}
+
+func (p *portfolio) printSummary() {
+	if len(p.entries) < 2 {
+		return
+	}
+	withErrors := 0
+	for _, e := range p.entries {
+		if e.errors > 0 {
+			withErrors++
+		}
+	}
+	fmt.Println("PORTFOLIO:", len(p.entries), "model(s),", withErrors, "with errors")
+	for _, e := range p.entries {
+		fmt.Println("\t" + e.title + " (" + e.path + "): " +
+			strconv.Itoa(e.externals) + " external(s), " +
+			strconv.Itoa(e.entities) + " entities, " +
+			strconv.Itoa(e.flows) + " flow(s), " +
+			strconv.Itoa(e.errors) + " error(s)")
+	}
+}
+
+// Risks of entities shared between models are counted only for the
+// model defining them, if that model is part of the portfolio.
+func (p *portfolio) attributeSharedRisks() {
+	models := make(map[string]bool)
+	for _, e := range p.entries {
+		models[absolutePath(e.path)] = true
+	}
+	for _, e := range p.entries {
+		for attack, locations := range e.risks {
+			var own []string
+			for _, location := range locations {
+				if source, shared := sharedEntitySource(location, e.shared); shared && models[absolutePath(source.Path)] {
+					continue
+				}
+				own = append(own, location)
+			}
+			if len(own) > 0 {
+				e.risks[attack] = own
+			} else {
+				delete(e.risks, attack)
+			}
+		}
+	}
+}
+
+// Number of (attack, location) pairs that are not mitigated.
+func (e *portfolioEntry) riskCount() (count int) {
+	for _, locations := range e.risks {
+		count += len(locations)
+	}
+	return
+}
+
+////////////////////////////////////////
+// Portfolio report
+
+type generatePortfolioReportCommand struct {
+	portfolio  portfolio
+	outputpath string
+}
+
+// Number of ADDB entries listed in the portfolio report.
+const frequentADDBEntriesLimit = 10
+
+// Write an index of all model reports to 'report/index.md'. Only generated
+// when more than one model is processed.
+func (g generatePortfolioReportCommand) execute() error {
+	if len(g.portfolio.entries) < 2 {
+		return nil
+	}
+	g.portfolio.attributeSharedRisks()
+	markdownReport := strings.Join(generatePortfolioReport(g.portfolio), "\n")
+	outpath := checkAndCreateDirectory(g.outputpath)
+	outpath = checkAndCreateDirectory(outpath + "report")
+	return os.WriteFile(outpath+"index.md", []byte(markdownReport), 0777)
+}
+
+func generatePortfolioReport(p portfolio) (markdownLines []string) {
+	markdownLines = append(markdownLines, "# Security Portfolio")
+	markdownLines = appendLineSpacer(markdownLines)
+	markdownLines = append(markdownLines, "This report summarizes "+strconv.Itoa(len(p.entries))+" security models. Detailed reports are linked from the table below.")
+	markdownLines = appendLineSpacer(markdownLines)
+
+	// Models
+	markdownLines = append(markdownLines, "## Models")
+	markdownLines = appendLineSpacer(markdownLines)
+	markdownLines = append(markdownLines, "| Model | File | Externals | Entities | Flows | Unmitigated risks | Errors |")
+	markdownLines = append(markdownLines, "|---|---|---:|---:|---:|---:|---:|")
+	var externals, entities, flows, risks, errors int
+	for _, e := range p.entries {
+		title := e.title
+		if e.report != "" {
+			title = "[" + e.title + "](" + e.report + ")"
+		}
+		markdownLines = append(markdownLines, "| "+title+" | `"+e.path+"` | "+
+			strconv.Itoa(e.externals)+" | "+strconv.Itoa(e.entities)+" | "+strconv.Itoa(e.flows)+" | "+
+			strconv.Itoa(e.riskCount())+" | "+strconv.Itoa(e.errors)+" |")
+		externals += e.externals
+		entities += e.entities
+		flows += e.flows
+		risks += e.riskCount()
+		errors += e.errors
+	}
+	markdownLines = append(markdownLines, "| **Total** | | "+
+		strconv.Itoa(externals)+" | "+strconv.Itoa(entities)+" | "+strconv.Itoa(flows)+" | "+
+		strconv.Itoa(risks)+" | "+strconv.Itoa(errors)+" |")
+	markdownLines = appendLineSpacer(markdownLines)
+
+	// ADDB entries
+	addbUsage := make(map[string][]string) // ADDB ID to titles of models using it
+	for _, e := range p.entries {
+		for _, id := range uniqueADDBIds(e.addbReferences) {
+			addbUsage[id] = append(addbUsage[id], e.title)
+		}
+	}
+	if len(addbUsage) > 0 {
+		markdownLines = append(markdownLines, "## Frequently used ADDB entries")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "| ADDB entry | Models |")
+		markdownLines = append(markdownLines, "|---|---:|")
+		ids := sortByUsage(addbUsage)
+		if len(ids) > frequentADDBEntriesLimit {
+			ids = ids[:frequentADDBEntriesLimit]
+		}
+		for _, id := range ids {
+			markdownLines = append(markdownLines, "| `"+id+"` | "+strconv.Itoa(len(addbUsage[id]))+" |")
+		}
+		markdownLines = appendLineSpacer(markdownLines)
+	}
+
+	// Attacks that are unmitigated in more than one model
+	attackUsage := make(map[string][]string) // attack title to titles of models where it is unmitigated
+	for _, e := range p.entries {
+		for attack := range e.risks {
+			attackUsage[attack] = append(attackUsage[attack], e.title)
+		}
+	}
+	var commonAttacks []string
+	for _, attack := range sortByUsage(attackUsage) {
+		if len(attackUsage[attack]) > 1 {
+			commonAttacks = append(commonAttacks, attack)
+		}
+	}
+	if len(commonAttacks) > 0 {
+		markdownLines = append(markdownLines, "## Risks common to several models")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "These attacks are not mitigated in more than one model. Addressing them in shared components or ADDB entries benefits all of them.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "| Attack | Models |")
+		markdownLines = append(markdownLines, "|---|---|")
+		for _, attack := range commonAttacks {
+			models := attackUsage[attack]
+			sort.Strings(models)
+			markdownLines = append(markdownLines, "| "+attack+" | "+strings.Join(models, ", ")+" |")
+		}
+		markdownLines = appendLineSpacer(markdownLines)
+	}
+
+	return
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// ID of the model's title, with a numeric suffix if an earlier model in the
+// portfolio has the same title. Keeps their generated files apart.
+func (p *portfolio) uniqueFileID(model objmodel.SecurityModel) string {
+	base := diagram.GenerateID(model.Title)
+	used := make(map[string]bool)
+	for _, e := range p.entries {
+		used[e.fileID] = true
+	}
+	id := base
+	for n := 2; used[id]; n++ {
+		id = base + "_" + strconv.Itoa(n)
+	}
+	return id
+}
+
+// Base name of files generated for a model
+func modelFileID(model objmodel.SecurityModel) string {
+	if model.FileID != "" {
+		return model.FileID
+	}
+	return diagram.GenerateID(model.Title)
+}
+
+func absolutePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// Keys sorted by number of users (descending), then by name.
+func sortByUsage(usage map[string][]string) []string {
+	var keys []string
+	for key := range usage {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(usage[keys[i]]) != len(usage[keys[j]]) {
+			return len(usage[keys[i]]) > len(usage[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// Sorted ADDB IDs without duplicates and the 'addb:' prefix.
+func uniqueADDBIds(list []string) (unique []string) {
+	seen := make(map[string]bool)
+	for _, item := range list {
+		item = strings.TrimPrefix(item, "addb:")
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	sort.Strings(unique)
+	return
+}
@@ -1,19 +1,21 @@
 package args
 
 import (
+	"addb"
 	"fmt"
 
 	"libadm/graph"
 	admloaders "libadm/loaders"
 	admmodel "libadm/model"
 	"os"
-	"securitymodel/diagram"
 	"securitymodel/objmodel"
+	"securitymodel/yamlmodel"
 	"strings"
 )
 
 type generateReportCommand struct {
 	model      objmodel.SecurityModel
+	db         *addb.ADDB // used to map attacks to STRIDE categories and catalog entries
 	outputpath string
 }
 
@@ -22,10 +24,10 @@ type generateReportCommand struct {
 
 func (g generateReportCommand) execute() error {
 	// Generate report
-	markdownReport := strings.Join(generateReport(g.model), "\n")
+	markdownReport := strings.Join(generateReport(g.model, g.db), "\n")
 	outpath := checkAndCreateDirectory(g.outputpath)
 	outpath = checkAndCreateDirectory(outpath + "report")
-	err := os.WriteFile(outpath+diagram.GenerateID(g.model.Title)+".sm.md", []byte(markdownReport), 0777)
+	err := os.WriteFile(outpath+reportFileName(g.model), []byte(markdownReport), 0777)
 	if err != nil {
 		return err
 	}
@@ -36,13 +38,16 @@ func (g generateReportCommand) execute() error {
 	// export ADM (linked to in report)
 	generateAdmCommand{model: g.model, outputpath: outpath + "resources"}.execute()
 
+	// export deployment diagram (used in report), if the model has a deployment view
+	generateDeploymentCommand{model: g.model, outputpath: outpath + "resources"}.execute()
+
 	return nil
 }
 
 ////////////////////////////////////////
 // Functions to generate report content
 
-func generateReport(model objmodel.SecurityModel) (markdownLines []string) {
+func generateReport(model objmodel.SecurityModel, db *addb.ADDB) (markdownLines []string) {
 	markdownLines = append(markdownLines, "# Security Report: "+model.Title)
 	markdownLines = appendLineSpacer(markdownLines)
 	markdownLines = append(markdownLines, "This report contains")
@@ -50,6 +55,8 @@ func generateReport(model objmodel.SecurityModel) (markdownLines []string) {
 	markdownLines = append(markdownLines, "* Existing mitigations implemented in specific entities/flows in this security model.")
 	markdownLines = append(markdownLines, "* Security recommendations for specific entities/flows in this security model.")
 	markdownLines = append(markdownLines, "* A list of un-mitigated risks for specific entities/flows.")
+	markdownLines = append(markdownLines, "* STRIDE coverage of external entities, entities and flows.")
+	markdownLines = append(markdownLines, "* CAPEC, ATT&CK and CWE references of attacks and defenses.")
 	markdownLines = appendLineSpacer(markdownLines)
 
 	// Security model
@@ -57,34 +64,67 @@ func generateReport(model objmodel.SecurityModel) (markdownLines []string) {
 	markdownLines = appendLineSpacer(markdownLines)
 	markdownLines = append(markdownLines,
 		"The ADSM Graph is available as a [graphviz file]("+
-			"resources/"+diagram.GenerateID(model.Title)+".sm.dot). "+
+			"resources/"+modelFileID(model)+".sm.dot). "+
 			"Please use a graphviz viewer or use [graphviz CLI tool](https://graphviz.org/download/) "+
 			"to export it to an image format of your choice. "+
-			"In case of CLI tool use `dot -Tpng resources/"+diagram.GenerateID(model.Title)+".sm.dot` "+
+			"In case of CLI tool use `dot -Tpng resources/"+modelFileID(model)+".sm.dot` "+
 			"to generate a PNG image of the graph. "+
 			"Detailed user documentation for CLI tool is available [here](https://graphviz.org/doc/info/command.html).")
 	markdownLines = appendLineSpacer(markdownLines)
 	markdownLines = append(markdownLines,
 		"The Attack-Defense Graph for this model is available as a [graphviz file]("+
-			"resources/"+diagram.GenerateID(model.Title)+".adm.dot). "+
+			"resources/"+modelFileID(model)+".adm.dot). "+
 			"Please use a graphviz viewer or use [graphviz CLI tool](https://graphviz.org/download/) "+
 			"to export it to an image format of your choice. "+
-			"In case of CLI tool use `dot -Tpng resources/"+diagram.GenerateID(model.Title)+".adm.dot` "+
+			"In case of CLI tool use `dot -Tpng resources/"+modelFileID(model)+".adm.dot` "+
 			"to generate a PNG image of the graph. "+
 			"Detailed user documentation for CLI tool is available [here](https://graphviz.org/doc/info/command.html).")
 	markdownLines = appendLineSpacer(markdownLines)
+	if len(model.Deployment) > 0 {
+		markdownLines = append(markdownLines,
+			"The deployment diagram, showing nodes and the entities deployed on them, is available as a [graphviz file]("+
+				"resources/"+deploymentFileName(model)+"). "+
+				"In case of CLI tool use `dot -Tpng resources/"+deploymentFileName(model)+"` to generate a PNG image of the diagram.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, generateDeploymentSection(model)...)
+		markdownLines = appendLineSpacer(markdownLines)
+	}
 
 	// List risks
 	risks := generateRisksSection(model)
 	if len(risks) > 0 {
 		markdownLines = append(markdownLines, "## Risks")
 		markdownLines = appendLineSpacer(markdownLines)
-		markdownLines = append(markdownLines, "This section lists all ADM attacks that have not been mitigated.")
+		markdownLines = append(markdownLines, "This section lists all ADM attacks that have not been mitigated, sorted by risk score (likelihood × impact).")
 		markdownLines = appendLineSpacer(markdownLines)
 		markdownLines = append(markdownLines, risks...)
 		markdownLines = appendLineSpacer(markdownLines)
 	}
 
+	// STRIDE coverage
+	stride := generateSTRIDESection(model, db)
+	if len(stride) > 0 {
+		markdownLines = append(markdownLines, "## STRIDE Coverage")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "This section lists the number of mitigated and considered ADM attacks in each STRIDE category. "+
+			"Attacks are assigned to categories using `@stride:` tags in ADM and STRIDE mapping tables in ADDB.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, stride...)
+		markdownLines = appendLineSpacer(markdownLines)
+	}
+
+	// Threat references
+	references := generateReferencesSection(model, db)
+	if len(references) > 0 {
+		markdownLines = append(markdownLines, "## Threat References")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "This section lists CAPEC attack patterns, ATT&CK techniques and CWE weaknesses referred to by "+
+			"`@capec:`, `@attack:` and `@cwe:` tags of ADM attacks and defenses, along with the number of mitigated attacks.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, references...)
+		markdownLines = appendLineSpacer(markdownLines)
+	}
+
 	// List mitigations
 	mitigations := generateMitigationsSection(model)
 	if len(mitigations) > 0 {
@@ -110,6 +150,32 @@ func generateReport(model objmodel.SecurityModel) (markdownLines []string) {
 	return
 }
 
+// Nodes of the deployment view, nested under the nodes they belong to, along
+// with the entities deployed on them. ADM of a node applies to these entities.
+func generateDeploymentSection(model objmodel.SecurityModel) (markdownLines []string) {
+	markdownLines = append(markdownLines, "### Deployment")
+	markdownLines = appendLineSpacer(markdownLines)
+	var list func(nodes []*objmodel.Node, indent string)
+	list = func(nodes []*objmodel.Node, indent string) {
+		for _, node := range nodes {
+			line := indent + "* " + node.GetName() + " (" + string(node.GetType()) + ")"
+			var names []string
+			for _, id := range node.GetEntities() {
+				if entity, found := model.Entities[id]; found {
+					names = append(names, entity.GetName())
+				}
+			}
+			if len(names) > 0 {
+				line += " - " + strings.Join(names, ", ")
+			}
+			markdownLines = append(markdownLines, line)
+			list(node.GetNodes(), indent+"  ")
+		}
+	}
+	list(model.Deployment, "")
+	return
+}
+
 func generateMitigationsSection(model objmodel.SecurityModel) (markdownLines []string) {
 	// entities
 	var entitiesSectionContent []string
@@ -125,9 +191,9 @@ func generateMitigationsSection(model objmodel.SecurityModel) (markdownLines []s
 			for mitiSource, mitis := range mitigations {
 				for _, miti := range mitis {
 					if mitiSource == entity.GetName() { // Skip showing the source if it is the root entity.
-						entitiesSectionContent = append(entitiesSectionContent, "* "+miti)
+						entitiesSectionContent = append(entitiesSectionContent, "* "+mitigationText(miti))
 					} else {
-						entitiesSectionContent = append(entitiesSectionContent, "* (`"+mitiSource+"`) "+miti)
+						entitiesSectionContent = append(entitiesSectionContent, "* (`"+mitiSource+"`) "+mitigationText(miti))
 					}
 				}
 			}
@@ -149,9 +215,9 @@ func generateMitigationsSection(model objmodel.SecurityModel) (markdownLines []s
 			for mitiSource, mitis := range mitigations {
 				for _, miti := range mitis {
 					if mitiSource == flow.GetName() { // Skip showing the source if it is the root entity.
-						flowsSectionContent = append(flowsSectionContent, "* "+miti)
+						flowsSectionContent = append(flowsSectionContent, "* "+mitigationText(miti))
 					} else {
-						flowsSectionContent = append(flowsSectionContent, "* (`"+mitiSource+"`) "+miti)
+						flowsSectionContent = append(flowsSectionContent, "* (`"+mitiSource+"`) "+mitigationText(miti))
 					}
 				}
 			}
@@ -219,19 +285,122 @@ func generateRecommendationsSection(model objmodel.SecurityModel) (markdownLines
 }
 
 func generateRisksSection(model objmodel.SecurityModel) (markdownLines []string) {
+	risks := rateRisks(model)
+	var sharedRisks []string
+	for _, r := range risks {
+		if source, shared := sharedEntitySource(r.qualifiedName, model.SharedEntities); shared {
+			sharedRisks = append(sharedRisks, "* "+r.attack+" (under `"+riskLocation(r.qualifiedName)+"`, defined in `"+source.Title+"` - `"+source.Path+"`) - "+r.rating())
+			continue
+		}
+		markdownLines = append(markdownLines, "* "+r.attack+" (under `"+riskLocation(r.qualifiedName)+"`) - "+r.rating())
+	}
+	if len(sharedRisks) > 0 {
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "### Shared entities")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "Risks of entities defined in other security models. They are also listed in reports of those models.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, sharedRisks...)
+	}
+	if len(risks) > 0 {
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "### Heat map")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "Number of risks by likelihood and impact, along with their severity.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, generateHeatMap(risks)...)
+	}
+	if owners, groups := groupRisksByOwner(risks); len(owners) > 1 || (len(owners) == 1 && owners[0] != "") {
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "### Risks by owner")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "Risks grouped by the owner (or team) of the entity/flow they are listed under.")
+		for _, owner := range owners {
+			markdownLines = appendLineSpacer(markdownLines)
+			markdownLines = append(markdownLines, "#### "+ownerHeading(owner, groups[owner][0].ownership))
+			markdownLines = appendLineSpacer(markdownLines)
+			for _, r := range groups[owner] {
+				markdownLines = append(markdownLines, "* "+r.attack+" (under `"+riskLocation(r.qualifiedName)+"`) - "+r.rating())
+			}
+		}
+	}
+	return
+}
+
+// Heading for risks of an owner, along with their team and contact
+func ownerHeading(owner string, ownership objmodel.Ownership) string {
+	if owner == "" {
+		return "No owner"
+	}
+	var details []string
+	if ownership.Team != "" && ownership.Team != owner {
+		details = append(details, "team: "+ownership.Team)
+	}
+	if ownership.Contact != "" {
+		details = append(details, "contact: "+ownership.Contact)
+	}
+	if len(details) == 0 {
+		return owner
+	}
+	return owner + " (" + strings.Join(details, ", ") + ")"
+}
+
+// Model defining the entity a qualified-name belongs to, if the entity is shared from another model.
+func sharedEntitySource(qualifiedName string, shared map[string]yamlmodel.EntitySource) (yamlmodel.EntitySource, bool) {
+	if !strings.HasPrefix(qualifiedName, "sm.entities.") {
+		return yamlmodel.EntitySource{}, false
+	}
+	id := strings.SplitN(strings.TrimPrefix(qualifiedName, "sm.entities."), ".", 2)[0]
+	source, found := shared[id]
+	return source, found
+}
+
+// Maps titles of unmitigated attacks to qualified-names of security-model items they are listed under.
+func findUnmitigatedAttacks(model objmodel.SecurityModel) map[string][]string {
+	attacks := loadAttacks(model.GetADM())
+
+	unmitigated := make(map[string][]string)
+	for risk := range attacks.unmitigated {
+		if attacks.locations[risk] == nil {
+			// CAUTION: This line should never be reached. If it does, contact author.
+			fmt.Println("ERROR: Cannot find attack - '" + risk + "' among all attacks listed for this security model.")
+		}
+		unmitigated[risk] = attacks.locations[risk]
+	}
+	return unmitigated
+}
+
+// ADM attacks listed under items of a security model
+type admAttacks struct {
+	locations   map[string][]string // maps attack titles to the qualified-name of security-model items
+	tags        map[string][]string // maps attack titles to their ADM tags
+	unmitigated map[string]bool
+	defenses    map[string][]string            // maps defense titles to their ADM tags
+	implemented map[string]map[string][]string // maps defense titles to the qualified-name of security-model items, and to their ADM tags there
+}
+
+// Load ADM listed under each security-model item (by qualified-name) into a
+// single attack-defense graph.
+func loadAttacks(admByItem map[string][]string) admAttacks {
 	var graph graph.Graph
 	graph.Init()
 
-	attackMap := make(map[string][]string) // maps attack titles to the qualified-name of security-model item
-	for qualifiedName, admList := range model.GetADM() {
+	attacks := admAttacks{
+		locations:   make(map[string][]string),
+		tags:        make(map[string][]string),
+		unmitigated: make(map[string]bool),
+		defenses:    make(map[string][]string),
+		implemented: make(map[string]map[string][]string),
+	}
+	for qualifiedName, admList := range admByItem {
 		for _, admFile := range admList {
-			contents, err := os.ReadFile(admFile)
+			contents, err := addb.ReadADM(admFile)
 			if err != nil {
 				fmt.Println(err.Error())
 				continue
 			}
 			if len(contents) == 0 { //no contents
-				fmt.Println("No ADM content found in " + admFile)
+				fmt.Println("No ADM content found in " + addb.ADMSource(admFile))
 				continue
 			}
 
@@ -247,8 +416,18 @@ func generateRisksSection(model objmodel.SecurityModel) (markdownLines []string)
 				fmt.Println(err)
 				continue
 			}
+			tags := addb.AttackTags(string(contents))
 			for attackTitle := range m.Attacks {
-				attackMap[attackTitle] = append(attackMap[attackTitle], qualifiedName)
+				attacks.locations[attackTitle] = append(attacks.locations[attackTitle], qualifiedName)
+				attacks.tags[attackTitle] = append(attacks.tags[attackTitle], tags[attackTitle]...)
+			}
+			tags = addb.DefenseTags(string(contents))
+			for defenseTitle := range m.Defenses {
+				attacks.defenses[defenseTitle] = append(attacks.defenses[defenseTitle], tags[defenseTitle]...)
+				if attacks.implemented[defenseTitle] == nil {
+					attacks.implemented[defenseTitle] = make(map[string][]string)
+				}
+				attacks.implemented[defenseTitle][qualifiedName] = append(attacks.implemented[defenseTitle][qualifiedName], tags[defenseTitle]...)
 			}
 
 			err = graph.AddModel(&m)
@@ -258,23 +437,26 @@ func generateRisksSection(model objmodel.SecurityModel) (markdownLines []string)
 			}
 		}
 	}
+
 	for risk := range graph.UnmitigatedAttacks {
-		if attackMap[risk] == nil {
-			// CAUTION: This line should never be reached. If it does, contact author.
-			fmt.Println("ERROR: Cannot find attack - '" + risk + "' among all attacks listed for this security model.")
-		}
-		for _, qualifiedName := range attackMap[risk] {
-			qualifiedName := strings.ReplaceAll(qualifiedName, "sm.", "")
-			location := strings.ReplaceAll(qualifiedName, ".", " → ")
-			markdownLines = append(markdownLines, "* "+risk+" (under `"+location+"`)")
-		}
+		attacks.unmitigated[risk] = true
 	}
-	return
+	return attacks
+}
+
+// Readable location of a security-model item from its qualified-name.
+func riskLocation(qualifiedName string) string {
+	qualifiedName = strings.ReplaceAll(qualifiedName, "sm.", "")
+	return strings.ReplaceAll(qualifiedName, ".", " → ")
 }
 
 ////////////////////////////////////////
 // Helper Functions
 
+func reportFileName(model objmodel.SecurityModel) string {
+	return modelFileID(model) + ".sm.md"
+}
+
 func appendLineSpacer(document []string) []string {
 	return append(document, "")
 }
@@ -0,0 +1,129 @@
+package args
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"securitymodel/diagram"
+	"securitymodel/objmodel"
+	"securitymodel/risk"
+	"securitymodel/sarif"
+	"strconv"
+)
+
+type generateRisksCSVCommand struct {
+	model      objmodel.SecurityModel
+	outputpath string
+}
+
+type generateSARIFCommand struct {
+	model      objmodel.SecurityModel
+	modelPath  string // smspec file results point to
+	outputpath string
+}
+
+// SARIF level of risks, by their severity
+var sarifLevels = map[risk.Level]string{
+	risk.Low:      "note",
+	risk.Medium:   "warning",
+	risk.High:     "error",
+	risk.Critical: "error",
+}
+
+////////////////////////////////////////
+// 'execute()' implementation
+
+func (g generateRisksCSVCommand) execute() error {
+	outpath := checkAndCreateDirectory(g.outputpath)
+	outpath = checkAndCreateDirectory(outpath + "report")
+	file, err := os.Create(outpath + risksCSVFileName(g.model))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.WriteAll(generateRisksCSV(g.model))
+	return writer.Error()
+}
+
+func (g generateSARIFCommand) execute() error {
+	content, err := json.MarshalIndent(generateSARIF(g.model, g.modelPath), "", "  ")
+	if err != nil {
+		return err
+	}
+	outpath := checkAndCreateDirectory(g.outputpath)
+	outpath = checkAndCreateDirectory(outpath + "report")
+	return os.WriteFile(outpath+sarifFileName(g.model), append(content, '\n'), 0777)
+}
+
+////////////////////////////////////////
+// Functions to generate content
+
+// Unmitigated risks as CSV records, grouped by owner (see 'groupRisksByOwner')
+// and sorted by risk score within each group. First record is the header.
+func generateRisksCSV(model objmodel.SecurityModel) (records [][]string) {
+	records = append(records, []string{"owner", "team", "contact", "attack", "location", "likelihood", "impact", "score", "severity"})
+	owners, groups := groupRisksByOwner(rateRisks(model))
+	for _, owner := range owners {
+		for _, r := range groups[owner] {
+			records = append(records, []string{r.ownership.Owner, r.ownership.Team, r.ownership.Contact, r.attack,
+				riskLocation(r.qualifiedName), r.likelihood.String(), r.impact.String(), strconv.Itoa(r.score()),
+				risk.Severity(r.score()).String()})
+		}
+	}
+	return
+}
+
+// SARIF log with one rule per unmitigated attack and one result per location
+// it is listed under. Results are grouped by owner (see 'groupRisksByOwner').
+// Levels are set by the severity of risks.
+func generateSARIF(model objmodel.SecurityModel, modelPath string) sarif.Log {
+	run := sarif.Run{
+		Tool:    sarif.Tool{Driver: sarif.Driver{Name: "adsm", InformationUri: "https://github.com/vinayprograms/adsm", Rules: []sarif.Rule{}}},
+		Results: []sarif.Result{},
+	}
+	rules := make(map[string]bool)
+	owners, groups := groupRisksByOwner(rateRisks(model))
+	for _, owner := range owners {
+		for _, r := range groups[owner] {
+			ruleId := diagram.GenerateID(r.attack)
+			if !rules[ruleId] {
+				rules[ruleId] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarif.Rule{Id: ruleId, Name: r.attack,
+					ShortDescription: sarif.Message{Text: "Unmitigated attack - " + r.attack}})
+			}
+			severity := risk.Severity(r.score())
+			result := sarif.Result{
+				RuleId:  ruleId,
+				Level:   sarifLevels[severity],
+				Message: sarif.Message{Text: "'" + r.attack + "' is not mitigated under '" + riskLocation(r.qualifiedName) + "'."},
+				Locations: []sarif.Location{{
+					PhysicalLocation: &sarif.PhysicalLocation{ArtifactLocation: sarif.ArtifactLocation{Uri: filepath.ToSlash(modelPath)}},
+					LogicalLocations: []sarif.LogicalLocation{{FullyQualifiedName: r.qualifiedName}},
+				}},
+				Properties: map[string]interface{}{
+					"likelihood": r.likelihood.String(),
+					"impact":     r.impact.String(),
+					"score":      r.score(),
+					"severity":   severity.String(),
+				},
+			}
+			for name, value := range map[string]string{"owner": r.ownership.Owner, "team": r.ownership.Team, "contact": r.ownership.Contact} {
+				if value != "" {
+					result.Properties[name] = value
+				}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+	return sarif.Log{Schema: sarif.Schema, Version: sarif.Version, Runs: []sarif.Run{run}}
+}
+
+func risksCSVFileName(model objmodel.SecurityModel) string {
+	return modelFileID(model) + ".risks.csv"
+}
+
+func sarifFileName(model objmodel.SecurityModel) string {
+	return modelFileID(model) + ".sarif"
+}
@@ -0,0 +1,184 @@
+package args
+
+import (
+	"fmt"
+	"securitymodel/objmodel"
+	"securitymodel/risk"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Unmitigated attack listed under a security-model item, rated by its
+// likelihood and impact.
+type ratedRisk struct {
+	attack        string
+	qualifiedName string
+	likelihood    risk.Level
+	impact        risk.Level
+	ownership     objmodel.Ownership // of the entity/flow the attack is listed under
+}
+
+func (r ratedRisk) score() int {
+	return risk.Score(r.likelihood, r.impact)
+}
+
+// Unmitigated attacks of a model, one per location, sorted by risk score
+// (highest first). For each attack, likelihood and impact are taken from the
+// first of -
+//  1. the model's risk overlay, for the entity/flow the attack is listed under
+//  2. the model's risk overlay, for all entities/flows
+//  3. '@likelihood:' and '@impact:' tags of the attack in ADM
+//  4. the entity/flow the attack is listed under. Impact is derived from its
+//     criticality or data-classification.
+//  5. 'medium', if none of the above is set
+func rateRisks(model objmodel.SecurityModel) (risks []ratedRisk) {
+	var overlay []risk.Estimate
+	if model.RiskOverlay != "" {
+		var err error
+		if overlay, err = risk.LoadOverlay(model.RiskOverlay); err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	}
+
+	attacks := loadAttacks(model.GetADM())
+	for attack := range attacks.unmitigated {
+		tagLikelihood, tagImpact, err := risk.FromTags(attacks.tags[attack])
+		if err != nil {
+			fmt.Println("WARNING: attack '" + attack + "' has an " + err.Error())
+		}
+		for _, qualifiedName := range attacks.locations[attack] {
+			r := ratedRisk{attack: attack, qualifiedName: qualifiedName}
+			id, element := modelElement(model, qualifiedName)
+			var elementLikelihood, elementImpact risk.Level
+			if element != nil {
+				attributes := element.GetRiskAttributes()
+				elementLikelihood, _ = risk.ParseLevel(attributes.Likelihood)
+				elementImpact = risk.ElementImpact(attributes.Criticality, attributes.DataClassification)
+				r.ownership = element.GetOwnership()
+			}
+			var overlayLikelihood, overlayImpact [2]risk.Level // estimates for this location and for all locations
+			for _, e := range overlay {
+				if !strings.EqualFold(e.Attack, attack) || (e.Location != "" && e.Location != id) {
+					continue
+				}
+				i := 1
+				if e.Location != "" {
+					i = 0
+				}
+				overlayLikelihood[i], _ = risk.ParseLevel(e.Likelihood)
+				overlayImpact[i], _ = risk.ParseLevel(e.Impact)
+			}
+			r.likelihood = firstLevel(overlayLikelihood[0], overlayLikelihood[1], tagLikelihood, elementLikelihood, risk.Default)
+			r.impact = firstLevel(overlayImpact[0], overlayImpact[1], tagImpact, elementImpact, risk.Default)
+			risks = append(risks, r)
+		}
+	}
+
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].score() != risks[j].score() {
+			return risks[i].score() > risks[j].score()
+		}
+		if risks[i].attack != risks[j].attack {
+			return risks[i].attack < risks[j].attack
+		}
+		return risks[i].qualifiedName < risks[j].qualifiedName
+	})
+	return
+}
+
+// Table counting risks by likelihood (rows, highest first) and impact (columns)
+func generateHeatMap(risks []ratedRisk) (markdownLines []string) {
+	counts := make(map[[2]risk.Level]int)
+	for _, r := range risks {
+		counts[[2]risk.Level{r.likelihood, r.impact}]++
+	}
+	header := "| Likelihood / Impact |"
+	separator := "|---|"
+	for _, impact := range risk.Levels {
+		header += " " + capitalize(impact) + " |"
+		separator += "---|"
+	}
+	markdownLines = append(markdownLines, header, separator)
+	for likelihood := risk.Critical; likelihood >= risk.Low; likelihood-- {
+		row := "| " + capitalize(likelihood.String()) + " |"
+		for impact := risk.Low; impact <= risk.Critical; impact++ {
+			count := counts[[2]risk.Level{likelihood, impact}]
+			if count == 0 {
+				row += " |"
+				continue
+			}
+			row += " " + strconv.Itoa(count) + " (" + risk.Severity(risk.Score(likelihood, impact)).String() + ") |"
+		}
+		markdownLines = append(markdownLines, row)
+	}
+	return
+}
+
+// Risks grouped by the owner (or team, if there is no owner) of the
+// entity/flow they are listed under. Groups are sorted by name, with risks
+// without an owner last. Risks keep their order within a group.
+func groupRisksByOwner(risks []ratedRisk) (owners []string, groups map[string][]ratedRisk) {
+	groups = make(map[string][]ratedRisk)
+	for _, r := range risks {
+		owner := r.owner()
+		if _, found := groups[owner]; !found {
+			owners = append(owners, owner)
+		}
+		groups[owner] = append(groups[owner], r)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i] == "" || owners[j] == "" {
+			return owners[j] == ""
+		}
+		return owners[i] < owners[j]
+	})
+	return
+}
+
+// Owner (or team, if there is no owner) of a risk
+func (r ratedRisk) owner() string {
+	if r.ownership.Owner != "" {
+		return r.ownership.Owner
+	}
+	return r.ownership.Team
+}
+
+// Readable rating of a risk
+func (r ratedRisk) rating() string {
+	return "risk score **" + strconv.Itoa(r.score()) + "** (likelihood: " + r.likelihood.String() + ", impact: " + r.impact.String() + ")"
+}
+
+// ID and specification of the entity or flow a qualified-name belongs to.
+// Model-level ADM doesn't belong to any.
+func modelElement(model objmodel.SecurityModel, qualifiedName string) (id string, element objmodel.EntitySpec) {
+	prefix := ""
+	check := func(candidate string, candidateId string, spec objmodel.EntitySpec) {
+		if len(candidate) > len(prefix) && listedUnder(candidate, []string{qualifiedName}) { // IDs can contain '.'
+			prefix, id, element = candidate, candidateId, spec
+		}
+	}
+	for entityId, entity := range model.Entities {
+		check("sm.entities."+entityId, entityId, entity)
+	}
+	for flowId, flow := range model.Flows {
+		check("sm.flows."+flowId, flowId, flow)
+	}
+	return
+}
+
+func firstLevel(levels ...risk.Level) risk.Level {
+	for _, level := range levels {
+		if level != risk.Unknown {
+			return level
+		}
+	}
+	return risk.Unknown
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,98 @@
+package args
+
+import (
+	"errors"
+	"fmt"
+	"securitymodel/loaders"
+	"securitymodel/objmodel"
+	"securitymodel/sbom"
+	"sort"
+)
+
+type importSBOMCommand struct {
+	model     objmodel.SecurityModel
+	loader    *loaders.Loader
+	modelPath string
+	sbomPath  string
+	entityId  string
+}
+
+type unmappedSBOMCommand struct {
+	loader *loaders.Loader
+}
+
+////////////////////////////////////////
+// 'execute()' implementation for each command
+
+// Map components in an SBOM to ADDB entries and add the ones missing from the
+// entity's dependencies to the model file. Components that have no ADDB entry
+// are listed.
+func (i importSBOMCommand) execute() error {
+	entity, ok := i.model.Entities[i.entityId]
+	if !ok {
+		return errors.New("entity '" + i.entityId + "' not found in model '" + i.model.Title + "'")
+	}
+	program, ok := entity.(*objmodel.Program)
+	if !ok {
+		return errors.New("dependencies can only be imported into programs and systems. '" + i.entityId + "' is not one")
+	}
+	db := i.loader.ADDB()
+	if db == nil || db.Location == "" {
+		return errors.New("cannot map SBOM components without a valid ADDB")
+	}
+
+	components, err := sbom.Load(i.sbomPath)
+	if err != nil {
+		return err
+	}
+	ids, unmapped := sbom.MapComponents(components, db)
+
+	fmt.Println("ENTITY: " + program.GetName())
+	fmt.Println("\tMapped", len(components)-len(unmapped), "of", len(components), "SBOM component(s)")
+	var newIds []string
+	for _, id := range ids {
+		if !hasReference(program.GetDependencies(), id) {
+			newIds = append(newIds, id)
+		}
+	}
+	printSuggestions("dependencies", i.entityId, newIds)
+	printUnmappedComponents(unmapped)
+
+	if len(newIds) > 0 {
+		file := entityFile(i.model, i.modelPath, i.entityId)
+		err = appendToModel(file, i.entityId, nil, newIds)
+		if err != nil {
+			return err
+		}
+		fmt.Println("\tUpdated " + file)
+	}
+	return nil
+}
+
+// List components in SBOMs referred by entities ('sbom' field) that have no ADDB entry.
+func (u unmappedSBOMCommand) execute() error {
+	unmapped := u.loader.UnmappedSBOMComponents()
+	var ids []string
+	for id := range unmapped {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Println("ENTITY: " + id)
+		printUnmappedComponents(unmapped[id])
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// Helper functions
+
+func printUnmappedComponents(components []sbom.Component) {
+	if len(components) == 0 {
+		return
+	}
+	fmt.Println("\tNo ADDB entry for:")
+	for _, c := range components {
+		fmt.Println("\t  - " + c.String())
+	}
+}
@@ -0,0 +1,175 @@
+package args
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"securitymodel/loaders"
+	"securitymodel/objmodel"
+	"securitymodel/sbom"
+	"securitymodel/scan"
+	"securitymodel/yamlmodel"
+	"sort"
+	"strings"
+)
+
+type scanCommand struct {
+	model     objmodel.SecurityModel
+	loader    *loaders.Loader
+	modelPath string
+	entityId  string // scan only this entity
+	write     bool   // write suggestions back into the model
+}
+
+////////////////////////////////////////
+// 'execute()' implementation for each command
+
+// Scan local code repositories of programs and suggest 'languages' and
+// 'dependencies' entries that are missing in the model.
+func (s scanCommand) execute() error {
+	db := s.loader.ADDB()
+	if db == nil || db.Location == "" {
+		return errors.New("cannot map languages and dependencies without a valid ADDB")
+	}
+	if s.entityId != "" {
+		if _, ok := s.model.Entities[s.entityId]; !ok {
+			return errors.New("entity '" + s.entityId + "' not found in model '" + s.model.Title + "'")
+		}
+	}
+
+	var ids []string
+	for id := range s.model.Entities {
+		if s.entityId == "" || id == s.entityId {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		program, ok := s.model.Entities[id].(*objmodel.Program)
+		if !ok {
+			continue
+		}
+		repoPath, isLocal := localRepository(program.GetCodeRepository(), filepath.Dir(s.modelPath))
+		if !isLocal {
+			if s.entityId != "" {
+				return errors.New("'repo' of '" + id + "' is not a local path")
+			}
+			continue
+		}
+		result, err := scan.Scan(repoPath)
+		if err != nil {
+			return err
+		}
+
+		var languages, unknownLanguages []string
+		for _, language := range result.Languages {
+			if addbId, found := db.MapLanguage(language); !found {
+				unknownLanguages = append(unknownLanguages, language)
+			} else if !hasReference(program.GetLanguages(), "addb:"+addbId) {
+				languages = append(languages, "addb:"+addbId)
+			}
+		}
+		var dependencies []string
+		mapped, unmapped := sbom.MapComponents(result.Components, db)
+		for _, dep := range mapped {
+			if !hasReference(program.GetDependencies(), dep) {
+				dependencies = append(dependencies, dep)
+			}
+		}
+
+		fmt.Println("ENTITY: " + program.GetName() + " (" + repoPath + ")")
+		printSuggestions("languages", id, languages)
+		printSuggestions("dependencies", id, dependencies)
+		if len(unknownLanguages) > 0 {
+			fmt.Println("\tNo ADDB entry for languages: " + strings.Join(unknownLanguages, ", "))
+		}
+		printUnmappedComponents(unmapped)
+
+		if s.write && len(languages)+len(dependencies) > 0 {
+			file := entityFile(s.model, s.modelPath, id)
+			err = appendToModel(file, id, languages, dependencies)
+			if err != nil {
+				return err
+			}
+			fmt.Println("\tUpdated " + file)
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Path to a program's repository if it is on the local filesystem. Relative
+// paths are resolved against the model's directory.
+func localRepository(repo string, modelDir string) (string, bool) {
+	if repo == "" {
+		return "", false
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return "", false
+	}
+	path := repo
+	if u.Scheme == "file" {
+		path = u.Path
+	} else if u.Scheme != "" && len(u.Scheme) > 1 { // single letter schemes are windows drive names
+		return "", false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(modelDir, path)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return path, true
+}
+
+// Check if 'id' ('addb:<id>') is in 'refs'. Depending on the field, 'refs' is
+// keyed by the reference used in the model or the entry's own ID.
+func hasReference(refs map[string]objmodel.ProgramEntitySpec, id string) bool {
+	_, withPrefix := refs[id]
+	_, withoutPrefix := refs[strings.TrimPrefix(id, "addb:")]
+	return withPrefix || withoutPrefix
+}
+
+func printSuggestions(field string, entityId string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	fmt.Println("\tAdd to '" + field + "' of '" + entityId + "':")
+	for _, id := range ids {
+		fmt.Println("\t  - " + id)
+	}
+}
+
+// File an entity is defined in: the fragment it was included from, if any, or the model file.
+func entityFile(model objmodel.SecurityModel, modelPath string, entityId string) string {
+	if fragment, found := model.Fragments[entityId]; found {
+		return fragment
+	}
+	return modelPath
+}
+
+func appendToModel(modelPath string, entityId string, languages []string, dependencies []string) error {
+	content, err := os.ReadFile(modelPath)
+	if err != nil {
+		return err
+	}
+	if len(languages) > 0 {
+		content, err = yamlmodel.AppendToEntityList(content, entityId, "languages", languages)
+		if err != nil {
+			return err
+		}
+	}
+	if len(dependencies) > 0 {
+		content, err = yamlmodel.AppendToEntityList(content, entityId, "dependencies", dependencies)
+		if err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(modelPath, content, 0644)
+}
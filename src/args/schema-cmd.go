@@ -0,0 +1,37 @@
+package args
+
+import (
+	"fmt"
+	"os"
+	"securitymodel/schemagen"
+)
+
+type generateSchemaCommand struct {
+	outputpath string
+}
+
+////////////////////////////////////////
+// 'execute()' implementation for each command
+
+// Write JSON schemas for security models and ADDB entries, generated from
+// the structures used to read them.
+func (g generateSchemaCommand) execute() error {
+	directory := checkAndCreateDirectory(g.outputpath)
+	for _, schema := range []struct {
+		file     string
+		generate func() ([]byte, error)
+	}{
+		{schemagen.ModelSchemaFile, schemagen.ModelSchema},
+		{schemagen.ComponentSchemaFile, schemagen.ComponentSchema},
+	} {
+		content, err := schema.generate()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(directory+schema.file, content, 0644); err != nil {
+			return err
+		}
+		fmt.Println("Generated " + directory + schema.file)
+	}
+	return nil
+}
@@ -1,8 +1,8 @@
 package args
 
 import (
+	"addb"
 	"fmt"
-	"os"
 	"securitymodel/objmodel"
 
 	admloaders "libadm/loaders"
@@ -43,7 +43,11 @@ func (e entityStatsCommand) execute() error {
 		if !(ok1 || ok2) {
 			continue
 		}
-		fmt.Println("\tEntity: " + ent.GetName())
+		if source, shared := e.model.SharedEntities[ent.GetID()]; shared {
+			fmt.Println("\tEntity: " + ent.GetName() + " (defined in '" + source.Title + "' - " + source.Path + ")")
+		} else {
+			fmt.Println("\tEntity: " + ent.GetName())
+		}
 		fmt.Println("\t        " + ent.GetDescription())
 		for _, adm := range ent.GetADM() {
 			for _, admFilePath := range adm {
@@ -93,7 +97,7 @@ func (f flowStatsCommand) execute() error {
 }
 
 func printADMStatLine(file string) (line string) {
-	content, err := os.ReadFile(file)
+	content, err := addb.ReadADM(file)
 	if err == nil {
 		gherkinModel, err1 := admloaders.LoadGherkinContent(string(content))
 		if err1 != nil {
@@ -102,7 +106,7 @@ func printADMStatLine(file string) (line string) {
 		var m admmodel.Model
 		err2 := m.Init(gherkinModel.Feature)
 		if err2 == nil {
-			line += "ADM: " + file
+			line += "ADM: " + addb.ADMSource(file)
 			if len(m.Assumptions) > 0 {
 				line += ", ASSUMPTIONS:" + fmt.Sprint(len(m.Assumptions))
 			}
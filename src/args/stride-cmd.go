@@ -0,0 +1,277 @@
+package args
+
+import (
+	"addb"
+	"fmt"
+	"securitymodel/objmodel"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type strideStatsCommand struct {
+	model objmodel.SecurityModel
+	db    *addb.ADDB
+}
+
+// Prefix of ADM tags that assign STRIDE categories to attacks, like '@stride:tampering'
+const strideTagPrefix = "@stride:"
+
+// Readable names of STRIDE categories
+var strideTitles = map[string]string{
+	"spoofing":               "Spoofing",
+	"tampering":              "Tampering",
+	"repudiation":            "Repudiation",
+	"information-disclosure": "Information Disclosure",
+	"denial-of-service":      "Denial of Service",
+	"elevation-of-privilege": "Elevation of Privilege",
+}
+
+// STRIDE categories that normally apply to each type of element (STRIDE-per-element).
+// Humans interact with the system like external entities.
+var strideApplicable = map[string][]string{
+	"external": {"spoofing", "repudiation"},
+	"human":    {"spoofing", "repudiation"},
+	"program":  addb.STRIDECategories,
+	"flow":     {"tampering", "information-disclosure", "denial-of-service"},
+}
+
+// Attacks considered and mitigated in a STRIDE category
+type strideCount struct {
+	considered int
+	mitigated  int
+}
+
+// STRIDE coverage of an external entity, an entity or a flow
+type strideCoverage struct {
+	name         string
+	kind         string                  // 'external', 'human', 'program' or 'flow'
+	counts       map[string]*strideCount // by STRIDE category
+	unclassified strideCount             // attacks without a STRIDE category
+}
+
+////////////////////////////////////////
+// 'execute()' implementation
+
+func (s strideStatsCommand) execute() error {
+	coverage := findSTRIDECoverage(s.model, s.db)
+	if len(coverage) == 0 {
+		return nil
+	}
+	fmt.Println("\tSTRIDE coverage (mitigated/considered attacks, '!' - no attacks in a category that normally applies):")
+
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ELEMENT\tTYPE\tS\tT\tR\tI\tD\tE\tUNCLASSIFIED")
+	for _, c := range coverage {
+		line := c.name + "\t" + c.kind + "\t"
+		for _, category := range addb.STRIDECategories {
+			line += c.cell(category, "!") + "\t"
+		}
+		fmt.Fprintln(w, line+c.unclassified.String())
+	}
+	w.Flush()
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		fmt.Println("\t\t" + strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// Functions to generate report content
+
+func generateSTRIDESection(model objmodel.SecurityModel, db *addb.ADDB) (markdownLines []string) {
+	coverage := findSTRIDECoverage(model, db)
+	if len(coverage) == 0 {
+		return
+	}
+
+	header := "| Element | Type |"
+	separator := "|---|---|"
+	for _, category := range addb.STRIDECategories {
+		header += " " + strideTitles[category] + " |"
+		separator += "---|"
+	}
+	markdownLines = append(markdownLines, header+" Unclassified |", separator+"---|")
+	var gaps []string
+	for _, c := range coverage {
+		row := "| " + c.name + " | " + c.kind + " |"
+		for _, category := range addb.STRIDECategories {
+			row += " " + c.cell(category, "**none**") + " |"
+		}
+		markdownLines = append(markdownLines, row+" "+c.unclassified.String()+" |")
+		for _, category := range c.gaps() {
+			gaps = append(gaps, "* No *"+strideTitles[category]+"* attacks considered for "+c.kind+" `"+c.name+"`.")
+		}
+	}
+
+	if len(gaps) > 0 {
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "### Gaps")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, "STRIDE categories that normally apply to these elements, but have no attacks in ADM.")
+		markdownLines = appendLineSpacer(markdownLines)
+		markdownLines = append(markdownLines, gaps...)
+	}
+	return
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// STRIDE coverage of externals, entities and flows of a model, in that order.
+// Attacks are assigned to categories using '@stride:' tags in ADM and STRIDE
+// mapping tables in ADDB.
+func findSTRIDECoverage(model objmodel.SecurityModel, db *addb.ADDB) (coverage []strideCoverage) {
+	admByItem := model.GetADM()
+	kinds := make(map[string]string) // maps qualified-name prefix of each element to its type
+	names := make(map[string]string) // maps qualified-name prefix of each element to its name
+	var elements [3][]string         // qualified-name prefixes of externals, entities and flows
+	for _, id := range sortedKeys(model.Externals) {
+		prefix := "sm.externals." + id
+		kinds[prefix], names[prefix] = "external", model.Externals[id].GetName()
+		elements[0] = append(elements[0], prefix)
+		if e, ok := model.Externals[id].(objmodel.EntitySpec); ok {
+			for qualifiedName, adm := range e.GetADM() {
+				admByItem["sm.externals."+qualifiedName] = adm
+			}
+		}
+	}
+	for _, id := range sortedKeys(model.Entities) {
+		prefix := "sm.entities." + id
+		switch model.Entities[id].(type) {
+		case *objmodel.Human:
+			kinds[prefix] = "human"
+		case *objmodel.Program:
+			kinds[prefix] = "program"
+		default: // roles are covered as part of programs using them
+			continue
+		}
+		names[prefix] = model.Entities[id].GetName()
+		elements[1] = append(elements[1], prefix)
+	}
+	for _, id := range sortedKeys(model.Flows) {
+		prefix := "sm.flows." + id
+		kinds[prefix], names[prefix] = "flow", model.Flows[id].GetName()
+		elements[2] = append(elements[2], prefix)
+	}
+
+	attacks := loadAttacks(admByItem)
+	categories := make(map[string][]string) // STRIDE categories of each attack
+	for _, title := range sortedKeys(attacks.locations) {
+		categories[title] = strideCategories(title, attacks.tags[title], db)
+	}
+	for _, group := range elements {
+		for _, prefix := range group {
+			c := strideCoverage{name: names[prefix], kind: kinds[prefix], counts: make(map[string]*strideCount)}
+			for _, category := range addb.STRIDECategories {
+				c.counts[category] = &strideCount{}
+			}
+			for _, title := range sortedKeys(attacks.locations) {
+				if !listedUnder(prefix, attacks.locations[title]) {
+					continue
+				}
+				mitigated := 0
+				if !attacks.unmitigated[title] {
+					mitigated = 1
+				}
+				for _, category := range categories[title] {
+					c.counts[category].considered++
+					c.counts[category].mitigated += mitigated
+				}
+				if len(categories[title]) == 0 {
+					c.unclassified.considered++
+					c.unclassified.mitigated += mitigated
+				}
+			}
+			coverage = append(coverage, c)
+		}
+	}
+	return
+}
+
+// STRIDE categories of an attack, from its ADM tags and ADDB mapping tables
+func strideCategories(title string, tags []string, db *addb.ADDB) (categories []string) {
+	seen := make(map[string]bool)
+	add := func(category string) {
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if !strings.HasPrefix(tag, strideTagPrefix) {
+			continue
+		}
+		category := strings.TrimPrefix(tag, strideTagPrefix)
+		if !addb.IsSTRIDECategory(category) {
+			fmt.Println("WARNING: unknown STRIDE category '" + category + "' in tags of attack '" + title + "'")
+			continue
+		}
+		add(category)
+	}
+	if db != nil {
+		for _, category := range db.MapAttack(title) {
+			add(category)
+		}
+	}
+	return
+}
+
+// Check if any of the qualified-names belongs to the element with qualified-name 'prefix'
+func listedUnder(prefix string, qualifiedNames []string) bool {
+	for _, qualifiedName := range qualifiedNames {
+		if qualifiedName == prefix || strings.HasPrefix(qualifiedName, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Cell of the coverage matrix for 'category'. Categories that normally apply,
+// but have no attacks are shown as 'gap'.
+func (c strideCoverage) cell(category string, gap string) string {
+	if c.counts[category].considered > 0 {
+		return c.counts[category].String()
+	}
+	if contains(category, strideApplicable[c.kind]) {
+		return gap
+	}
+	return "-"
+}
+
+// STRIDE categories that normally apply to the element, but have no attacks
+func (c strideCoverage) gaps() (categories []string) {
+	for _, category := range strideApplicable[c.kind] {
+		if c.counts[category].considered == 0 {
+			categories = append(categories, category)
+		}
+	}
+	return
+}
+
+func (s strideCount) String() string {
+	if s.considered == 0 {
+		return "-"
+	}
+	return strconv.Itoa(s.mitigated) + "/" + strconv.Itoa(s.considered)
+}
+
+func contains(item string, list []string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) (keys []string) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
@@ -8,6 +8,8 @@ replace securitymodel => ../securitymodel
 
 replace addb => ../addb
 
+replace schema => ../schema
+
 replace libadm => ../../../adm/src/libadm
 
 require args v0.0.0-00010101000000-000000000000
@@ -22,6 +24,7 @@ require (
 	golang.org/x/image v0.5.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	addb v0.0.0-00010101000000-000000000000 // indirect
+	schema v0.0.0-00010101000000-000000000000 // indirect
 	libadm v0.0.0-00010101000000-000000000000 // indirect
 	securitymodel v0.0.0-00010101000000-000000000000 // indirect
 )
@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Message reported by yaml.v3 for fields not present in the target type
+var fieldNotFound = regexp.MustCompile(`^(line \d+: )field (\S+) not found in type (\S+)$`)
+
+// Like 'yaml.Unmarshal', but fields that are not present in 'out' are
+// reported as errors instead of being silently ignored.
+func Unmarshal(content []byte, out interface{}) error {
+	err := Decode(yaml.NewDecoder(bytes.NewReader(content)), out)
+	if err == io.EOF { // empty document
+		return nil
+	}
+	return err
+}
+
+// Decode the next YAML document from 'd' into 'out', reporting fields that
+// are not present in 'out' along with the closest field that is. As with
+// 'yaml.TypeError', rest of the document is still decoded.
+func Decode(d *yaml.Decoder, out interface{}) error {
+	d.KnownFields(true)
+	err := d.Decode(out)
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return err
+	}
+
+	fields := yamlFields(reflect.TypeOf(out), make(map[string][]string))
+	for i, message := range typeErr.Errors {
+		if m := fieldNotFound.FindStringSubmatch(message); m != nil {
+			typeErr.Errors[i] = m[1] + "unknown field '" + m[2] + "'" + suggestion(m[2], fields[m[3]])
+		}
+	}
+	return typeErr
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// Fields, by their YAML names, of all structs that make up type 't'. Structs
+// are identified by their qualified name (like 'yamlmodel.Entity').
+func yamlFields(t reflect.Type, fields map[string][]string) map[string][]string {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return yamlFields(t.Elem(), fields)
+	case reflect.Struct:
+		if _, done := fields[t.String()]; done {
+			return fields
+		}
+		fields[t.String()] = []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			fields[t.String()] = append(fields[t.String()], name)
+			yamlFields(f.Type, fields)
+		}
+	}
+	return fields
+}
+
+// ", did you mean 'x'?" if one of the candidates is close enough to 'name'
+// to be a typo.
+func suggestion(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		if bestDistance < 0 || d < bestDistance || (d == bestDistance && c < best) {
+			best, bestDistance = c, d
+		}
+	}
+	limit := len(name) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	if best == "" || bestDistance > limit {
+		return ""
+	}
+	return ", did you mean '" + best + "'?"
+}
+
+// Levenshtein distance between two strings
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minimum(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
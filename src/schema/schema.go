@@ -0,0 +1,207 @@
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Copies of schemas in 'schemas/' at the root of the repository. Run 'go
+// generate' after changing them.
+//go:generate cp ../../schemas/model-schema.json ../../schemas/component-schema.json .
+
+//go:embed model-schema.json
+var modelSchema []byte
+
+//go:embed component-schema.json
+var componentSchema []byte
+
+const (
+	modelSchemaFile     = "model-schema.json"
+	componentSchemaFile = "component-schema.json"
+	otherSchemaFile     = "schema.json" // name of schemas passed to 'Validate'
+)
+
+// Schema documents by their file name, used to resolve '$ref's across files.
+var documents = map[string]map[string]interface{}{
+	modelSchemaFile:     mustParse(modelSchema),
+	componentSchemaFile: mustParse(componentSchema),
+}
+
+// Kinds of items (like 'human' or 'flow') each field can be used in.
+var applicableTo = indexFields()
+
+// Validate a security model against 'model-schema.json'.
+func ValidateModel(content []byte) []error {
+	return validateDocuments(content, modelSchemaFile, true)
+}
+
+// Validate a fragment included by a security model. Fragments follow the
+// model's schema, but none of the model's fields are mandatory.
+func ValidateFragment(content []byte) []error {
+	return validateDocuments(content, modelSchemaFile, false)
+}
+
+// Validate ADDB entries (one per YAML document) against 'component-schema.json'.
+func ValidateComponents(content []byte) []error {
+	return validateDocuments(content, componentSchemaFile, true)
+}
+
+// Validate a single ADDB entry, decoded as a YAML document, against
+// 'component-schema.json'.
+func ValidateComponent(doc *yaml.Node) []error {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	return validateDocument(doc, documents[componentSchemaFile], componentSchemaFile)
+}
+
+// Validate YAML (or JSON) content against another JSON schema, like the
+// schema of a format models are exported to. Keywords not listed in
+// 'validator' are ignored, and '$ref's can only refer to the schema itself.
+func Validate(content []byte, schemaContent []byte) []error {
+	var root map[string]interface{}
+	if err := json.Unmarshal(schemaContent, &root); err != nil {
+		return []error{errors.New("invalid schema - " + err.Error())}
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return []error{err}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	v := validator{documents: map[string]map[string]interface{}{otherSchemaFile: root}}
+	return v.validate(doc.Content[0], root, context{doc: otherSchemaFile, label: "document", subject: "document"})
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func validateDocuments(content []byte, file string, required bool) []error {
+	var errs []error
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if err != io.EOF {
+				errs = append(errs, err)
+			}
+			break
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := documents[file]
+		if !required {
+			root = withoutRequired(root)
+		}
+		errs = append(errs, validateDocument(&doc, root, file)...)
+	}
+	return errs
+}
+
+func validateDocument(doc *yaml.Node, root map[string]interface{}, file string) []error {
+	v := validator{documents: documents}
+	return v.validate(doc.Content[0], root, context{doc: file, label: "model", subject: "model"})
+}
+
+func mustParse(content []byte) map[string]interface{} {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		panic("invalid embedded schema - " + err.Error())
+	}
+	return doc
+}
+
+// Shallow copy of a schema without its 'required' list
+func withoutRequired(s map[string]interface{}) map[string]interface{} {
+	copy := make(map[string]interface{})
+	for k, v := range s {
+		if k != "required" {
+			copy[k] = v
+		}
+	}
+	return copy
+}
+
+// Map each field to the kinds of items (see 'itemKind') it is declared in.
+func indexFields() map[string][]string {
+	fields := make(map[string][]string)
+	var walk func(s map[string]interface{}, path []string)
+	walk = func(s map[string]interface{}, path []string) {
+		if props, ok := s["properties"].(map[string]interface{}); ok {
+			kind := itemKind(path)
+			for field := range props {
+				if !contains(kind, fields[field]) {
+					fields[field] = append(fields[field], kind)
+				}
+			}
+		}
+		for key, value := range s {
+			if key == "properties" {
+				continue
+			}
+			if child, ok := value.(map[string]interface{}); ok {
+				walk(child, append(append([]string{}, path...), key))
+			}
+		}
+	}
+	for _, doc := range documents {
+		walk(doc, nil)
+	}
+	for _, kinds := range fields {
+		sort.Strings(kinds)
+	}
+	return fields
+}
+
+// Kind of item described by the schema at 'path' (the path of a '$ref').
+// External entities are treated like their in-scope counterparts.
+func itemKind(path []string) string {
+	if len(path) == 0 {
+		return "model"
+	}
+	return path[len(path)-1]
+}
+
+// Resolve a '$ref' relative to schema file 'doc', one of 'docs'. Returns the
+// referred schema along with the file containing it.
+func resolveRef(ref string, doc string, docs map[string]map[string]interface{}) (map[string]interface{}, string, []string, error) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	if file != "" {
+		doc = file
+	}
+	s, ok := docs[doc]
+	if !ok {
+		return nil, "", nil, errors.New("unknown schema '" + doc + "'")
+	}
+	var path []string
+	for _, part := range strings.Split(strings.Trim(pointer, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		child, ok := s[part].(map[string]interface{})
+		if !ok {
+			return nil, "", nil, errors.New("cannot resolve '" + ref + "' in schema '" + doc + "'")
+		}
+		s = child
+		path = append(path, part)
+	}
+	return s, doc, path, nil
+}
+
+func contains(item string, list []string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,394 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validates YAML nodes against the subset of JSON schema used by schemas in
+// this repository - 'type', 'properties', 'required', 'additionalProperties',
+// 'items', 'minItems', 'uniqueItems', 'enum', 'pattern', 'oneOf', 'anyOf',
+// 'allOf' and '$ref'.
+type validator struct {
+	documents map[string]map[string]interface{} // schema documents '$ref's are resolved in, by file name
+}
+
+type context struct {
+	doc     string // schema file containing the schema being applied
+	label   string // kind of item being validated, like 'program'
+	subject string // item being validated, like "program 'backend'"
+	field   string // field of 'subject' being validated
+}
+
+func (v validator) validate(n *yaml.Node, s map[string]interface{}, ctx context) []error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, doc, path, err := resolveRef(ref, ctx.doc, v.documents)
+		if err != nil {
+			return []error{err}
+		}
+		ctx.doc = doc
+		if target["type"] == "object" && n.Kind == yaml.MappingNode {
+			ctx.label = itemKind(path)
+			if contains("externals", path) {
+				ctx.label = "external " + ctx.label
+			}
+			ctx.subject = describe(ctx.label, n)
+			ctx.field = ""
+		}
+		return v.validate(n, target, ctx)
+	}
+	if branches, ok := s["oneOf"].([]interface{}); ok {
+		return v.oneOf(n, branches, ctx)
+	}
+	if branches, ok := s["anyOf"].([]interface{}); ok {
+		return v.oneOf(n, branches, ctx)
+	}
+	var errs []error
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, b := range all {
+			branch, _ := b.(map[string]interface{})
+			errs = append(errs, v.validate(n, branch, ctx)...)
+		}
+	}
+
+	if types := stringList(s["type"]); len(types) > 0 && !hasType(n, types) {
+		return append(errs, v.errorf(n, ctx, "%smust be %s", fieldPrefix(ctx), typeNames(types)))
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		errs = append(errs, v.validateMapping(n, s, ctx)...)
+	case yaml.SequenceNode:
+		errs = append(errs, v.validateSequence(n, s, ctx)...)
+	case yaml.ScalarNode:
+		errs = append(errs, v.validateScalar(n, s, ctx)...)
+	}
+	return errs
+}
+
+func (v validator) validateMapping(n *yaml.Node, s map[string]interface{}, ctx context) []error {
+	var errs []error
+
+	properties, _ := s["properties"].(map[string]interface{})
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if property, ok := properties[key.Value].(map[string]interface{}); ok {
+			fieldCtx := ctx
+			fieldCtx.field = key.Value
+			errs = append(errs, v.validate(value, property, fieldCtx)...)
+			continue
+		}
+		switch extra := s["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				errs = append(errs, v.unknownField(key, properties, ctx))
+			}
+		case map[string]interface{}:
+			fieldCtx := ctx
+			fieldCtx.field = key.Value
+			errs = append(errs, v.validate(value, extra, fieldCtx)...)
+		}
+	}
+
+	for _, field := range stringList(s["required"]) {
+		if mappingValue(n, field) == nil {
+			errs = append(errs, v.errorf(n, ctx, "missing '%s'%s", field, inField(ctx)))
+		}
+	}
+	return errs
+}
+
+func (v validator) validateSequence(n *yaml.Node, s map[string]interface{}, ctx context) []error {
+	var errs []error
+
+	if min, ok := s["minItems"].(float64); ok && len(n.Content) < int(min) {
+		errs = append(errs, v.errorf(n, ctx, "%smust have at least %v item(s)", fieldPrefix(ctx), min))
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		for _, item := range n.Content {
+			errs = append(errs, v.validate(item, items, ctx)...)
+		}
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		seen := make(map[string]bool)
+		for _, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+			if seen[item.Value] {
+				errs = append(errs, v.errorf(item, ctx, "'%s' is listed more than once%s", item.Value, inField(ctx)))
+			}
+			seen[item.Value] = true
+		}
+	}
+	return errs
+}
+
+func (v validator) validateScalar(n *yaml.Node, s map[string]interface{}, ctx context) []error {
+	if n.Tag == "!!null" {
+		return nil
+	}
+	if enum := stringList(s["enum"]); len(enum) > 0 && !contains(n.Value, enum) {
+		return []error{v.errorf(n, ctx, "%smust be %s", fieldPrefix(ctx), quotedList(enum, "or"))}
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return []error{errors.New("invalid pattern in schema - " + pattern)}
+		}
+		if !re.MatchString(n.Value) {
+			return []error{v.errorf(n, ctx, "'%s'%s does not match '%s'", n.Value, inField(ctx), pattern)}
+		}
+	}
+	return nil
+}
+
+// Validate against alternatives listed under 'oneOf'/'anyOf'. If none of them
+// match, errors are reported for the alternative meant by the item's 'type'
+// (or the one that comes closest).
+func (v validator) oneOf(n *yaml.Node, branches []interface{}, ctx context) []error {
+	var results [][]error
+	for _, b := range branches {
+		branch, _ := b.(map[string]interface{})
+		errs := v.validate(n, branch, ctx)
+		if len(errs) == 0 {
+			return nil
+		}
+		results = append(results, errs)
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	if t := mappingValue(n, "type"); t != nil && t.Kind == yaml.ScalarNode {
+		var types []string
+		for i, b := range branches {
+			branch, _ := b.(map[string]interface{})
+			enum := v.typeEnum(branch, ctx.doc)
+			if contains(t.Value, enum) {
+				return results[i]
+			}
+			types = append(types, enum...)
+		}
+		if len(types) > 0 {
+			ctx.subject = describe("entry", n)
+			return []error{v.errorf(t, ctx, "unknown type '%s'%s", t.Value, suggestion(t.Value, types))}
+		}
+	}
+
+	// Prefer alternatives of the same type as the node (a mapping over a string, etc.)
+	var closest []error
+	for _, typeMatched := range []bool{true, false} {
+		for i, b := range branches {
+			branch, _ := b.(map[string]interface{})
+			if types := v.types(branch, ctx.doc); typeMatched && len(types) > 0 && !hasType(n, types) {
+				continue
+			}
+			if closest == nil || len(results[i]) < len(closest) {
+				closest = results[i]
+			}
+		}
+		if closest != nil {
+			break
+		}
+	}
+	return closest
+}
+
+// Types allowed by a schema, after following '$ref's
+func (v validator) types(s map[string]interface{}, doc string) []string {
+	s, _ = v.resolve(s, doc)
+	return stringList(s["type"])
+}
+
+// Values allowed for the 'type' field by an (object) schema
+func (v validator) typeEnum(s map[string]interface{}, doc string) []string {
+	s, doc = v.resolve(s, doc)
+	properties, _ := s["properties"].(map[string]interface{})
+	t, _ := properties["type"].(map[string]interface{})
+	t, _ = v.resolve(t, doc)
+	return stringList(t["enum"])
+}
+
+// Follow '$ref's till a schema that isn't a reference is found
+func (v validator) resolve(s map[string]interface{}, doc string) (map[string]interface{}, string) {
+	for {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s, doc
+		}
+		target, targetDoc, _, err := resolveRef(ref, doc, v.documents)
+		if err != nil {
+			return nil, doc
+		}
+		s, doc = target, targetDoc
+	}
+}
+
+// Error for a field that isn't part of the schema. Fields meant for other
+// kinds of items (like 'interface' in a program) are reported as such.
+func (v validator) unknownField(key *yaml.Node, properties map[string]interface{}, ctx context) error {
+	kind := strings.TrimPrefix(ctx.label, "external ")
+	kinds := applicableTo[key.Value]
+	if len(kinds) == 0 {
+		var fields []string
+		for field := range properties {
+			fields = append(fields, field)
+		}
+		return v.errorf(key, ctx, "unknown field '%s'%s%s", key.Value, inField(ctx), suggestion(key.Value, fields))
+	}
+	if contains(kind, kinds) {
+		return v.errorf(key, ctx, "'%s' cannot be used in %s", key.Value, withArticle(ctx.label))
+	}
+	var items []string
+	for _, k := range kinds {
+		items = append(items, withArticle(k))
+	}
+	return v.errorf(key, ctx, "'%s' can only be used in %s", key.Value, list(items, "or"))
+}
+
+func (v validator) errorf(n *yaml.Node, ctx context, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s - %s", n.Line, ctx.subject, fmt.Sprintf(format, args...))
+}
+
+// Name of the field being validated, if any, to start an error message with.
+func fieldPrefix(ctx context) string {
+	if ctx.field == "" {
+		return ""
+	}
+	return "'" + ctx.field + "' "
+}
+
+// Name of the field being validated, if any, to end an error message with.
+func inField(ctx context) string {
+	if ctx.field == "" {
+		return ""
+	}
+	return " in '" + ctx.field + "'"
+}
+
+////////////////////////////////////////
+// Helper functions
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Kind of item followed by its ID (if it has one)
+func describe(label string, n *yaml.Node) string {
+	if id := mappingValue(n, "id"); id != nil && id.Kind == yaml.ScalarNode && id.Value != "" {
+		return label + " '" + id.Value + "'"
+	}
+	return label
+}
+
+// Check if a node is one of the JSON types. Any non-empty scalar is accepted
+// as a string since that's how it is read into a string field.
+func hasType(n *yaml.Node, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if n.Kind == yaml.MappingNode {
+				return true
+			}
+		case "array":
+			if n.Kind == yaml.SequenceNode {
+				return true
+			}
+		case "null":
+			if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+				return true
+			}
+		case "string":
+			if n.Kind == yaml.ScalarNode && n.Tag != "!!null" {
+				return true
+			}
+		case "boolean":
+			if n.Kind == yaml.ScalarNode && n.Tag == "!!bool" {
+				return true
+			}
+		case "integer":
+			if n.Kind == yaml.ScalarNode && n.Tag == "!!int" {
+				return true
+			}
+		case "number":
+			if n.Kind == yaml.ScalarNode && (n.Tag == "!!int" || n.Tag == "!!float") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeNames(types []string) string {
+	names := map[string]string{
+		"object":  "a mapping",
+		"array":   "a list",
+		"null":    "empty",
+		"string":  "a string",
+		"boolean": "true or false",
+		"integer": "an integer",
+		"number":  "a number",
+	}
+	var list []string
+	for _, t := range types {
+		list = append(list, names[t])
+	}
+	return strings.Join(list, " or ")
+}
+
+// Values of a schema keyword that is either a string or a list of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func withArticle(word string) string {
+	if strings.ContainsAny(word[:1], "aeiou") {
+		return "an " + word
+	}
+	return "a " + word
+}
+
+func quotedList(items []string, conjunction string) string {
+	var quoted []string
+	for _, item := range items {
+		quoted = append(quoted, "'"+item+"'")
+	}
+	return list(quoted, conjunction)
+}
+
+// 'a', 'a or b', 'a, b or c'
+func list(items []string, conjunction string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + conjunction + " " + items[len(items)-1]
+}
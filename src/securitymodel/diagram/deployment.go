@@ -0,0 +1,96 @@
+package diagram
+
+import (
+	"fmt"
+	"securitymodel/objmodel"
+)
+
+// Graphviz code for the deployment view of a model. Each node is a cluster
+// (nested in the node it belongs to) containing the entities deployed on it.
+// Entities that are not deployed, external entities and flows are drawn as in
+// the security model diagram.
+func GenerateDeploymentDiagram(model objmodel.SecurityModel) ([]string, error) {
+	var lines []string
+	lines = append(lines, generateHeader()...)
+	lines = append(lines, generateDeploymentBody(model)...)
+	lines = append(lines, generateFooter()...)
+	return lines, nil
+}
+
+////////////////////////////////////////
+// Internal functions that build parts of the diagram
+
+func generateDeploymentBody(model objmodel.SecurityModel) (body []string) {
+	// Add externals
+	body = appendLine(body, 1, "//externals")
+	var externIDs []string
+	for id, ext := range model.Externals {
+		if id == "" || ext == nil {
+			continue
+		}
+		externIDs = append(externIDs, GenerateID(id))
+		body = appendLine(body, 1, GenerateExternalEntityCode(GenerateID(id), ext))
+	}
+	body = appendLineSpacer(body)
+
+	// Add nodes, along with entities deployed on them
+	body = appendLine(body, 1, "//deployment")
+	deployed := make(map[string]bool)
+	for _, node := range model.Deployment {
+		body = append(body, generateNodeCode(node, model, deployed, 1)...)
+	}
+	body = appendLineSpacer(body)
+
+	// Add entities that are not deployed on any node
+	body = appendLine(body, 1, "//entities")
+	for id, entity := range model.Entities {
+		if id == "" || entity == nil || deployed[id] {
+			continue
+		}
+		if _, ok := entity.(*objmodel.Role); ok {
+			continue // Role data will be consolidated into the entity that uses it.
+		}
+		body = appendLine(body, 1, GenerateEntityCode(id, entity))
+	}
+	body = appendLineSpacer(body)
+
+	// Add flows
+	body = appendLine(body, 1, "//flows")
+	for _, flow := range model.Flows {
+		if flow == nil {
+			continue
+		}
+		body = appendLine(body, 1, GenerateFlowCode(flow, externIDs))
+	}
+
+	return
+}
+
+// Cluster for a node, along with entities deployed on it and nested nodes.
+// Entities that are drawn are recorded in 'deployed'.
+func generateNodeCode(node *objmodel.Node, model objmodel.SecurityModel, deployed map[string]bool, tabs int) (lines []string) {
+	riskyNodeProperties := " style=\"rounded, dashed\" fontname=\"Arial\" fontcolor=\"black\" fillcolor=\"transparent\" color=\"red\" penwidth=\"2\"];"
+	safeNodeProperties := " style=\"rounded, dashed\" fontname=\"Arial\" fontcolor=\"black\" fillcolor=\"transparent\" color=\"gray\"];"
+
+	a, d, hasRisks := getStats(node.GetADM())
+	label := "<<b>" + htmlwrap(node.GetName()) + "</b><br/>" + string(node.GetType()) + " | A: " + fmt.Sprint(a) + " | D: " + fmt.Sprint(d) + ">"
+	lines = appendLine(lines, tabs, "subgraph cluster_node_"+GenerateID(node.GetID())+"{")
+	if hasRisks {
+		lines = appendLine(lines, tabs+1, "graph[label="+label+riskyNodeProperties)
+	} else {
+		lines = appendLine(lines, tabs+1, "graph[label="+label+safeNodeProperties)
+	}
+	for _, id := range node.GetEntities() {
+		entity, found := model.Entities[id]
+		if !found || entity == nil { // left out of the model, or by a filter
+			continue
+		}
+		deployed[id] = true
+		lines = appendLine(lines, tabs+1, GenerateEntityCode(id, entity))
+	}
+	for _, nested := range node.GetNodes() {
+		lines = append(lines, generateNodeCode(nested, model, deployed, tabs+1)...)
+	}
+	lines = appendLine(lines, tabs, "}")
+	return
+}
@@ -1,11 +1,11 @@
 package diagram
 
 import (
+	"addb"
 	"fmt"
 	"libadm/graph"
 	admloaders "libadm/loaders"
 	"libadm/model"
-	"os"
 	"securitymodel/objmodel"
 )
 
@@ -164,13 +164,13 @@ func getStats(allADM map[string][]string) (attacks int, defenses int, hasUnmitig
 
 	for _, admList := range allADM {
 		for _, admFile := range admList {
-			contents, err := os.ReadFile(admFile)
+			contents, err := addb.ReadADM(admFile)
 			if err != nil {
 				fmt.Println(err.Error())
 				continue
 			}
 			if len(contents) == 0 { //no contents
-				fmt.Println("No ADM content found in " + admFile)
+				fmt.Println("No ADM content found in " + addb.ADMSource(admFile))
 				continue
 			}
 
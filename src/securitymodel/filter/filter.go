@@ -0,0 +1,236 @@
+// Filter expressions used to select externals, entities and flows of a
+// security model. An expression is made of terms like 'tag:pci' or
+// 'id:backend*', joined by 'and', 'or' and 'not' and grouped with parentheses.
+// 'not' takes precedence over 'and', which takes precedence over 'or'.
+//
+// Values of terms are matched ignoring case and can use '*' and '?' wildcards,
+// which match any characters (including '/').
+// Supported keys are -
+//   - id, name - ID and name of the item
+//   - type - 'human', 'program', 'role' or 'flow'
+//   - scope - 'external' for external entities, 'internal' for other items
+//   - tag - any of the item's tags
+//   - owner, team - owner or team of the item (externals have neither)
+package filter
+
+import (
+	"errors"
+	"regexp"
+	"securitymodel/objmodel"
+	"strings"
+)
+
+// Keys supported in terms
+var Keys = []string{"id", "name", "type", "scope", "tag", "owner", "team"}
+
+// Parsed filter expression
+type Expression interface {
+	// Check if an item matches the expression. 'external' is set for external
+	// entities.
+	Matches(item objmodel.CoreSpec, external bool) bool
+}
+
+// Parse a filter expression, like 'tag:pci and not type:flow'
+func Parse(text string) (Expression, error) {
+	p := parser{tokens: tokenize(text)}
+	if len(p.tokens) == 0 {
+		return nil, errors.New("empty filter expression")
+	}
+	e, err := p.or()
+	if err == nil && p.position < len(p.tokens) {
+		err = errors.New("unexpected '" + p.tokens[p.position] + "'")
+	}
+	if err != nil {
+		return nil, errors.New("invalid filter '" + text + "' - " + err.Error())
+	}
+	return e, nil
+}
+
+// Copy of 'model' with only the externals, entities and flows matching 'e'
+func Apply(model objmodel.SecurityModel, e Expression) objmodel.SecurityModel {
+	return model.Select(e.Matches)
+}
+
+////////////////////////////////////////
+// Expressions
+
+type term struct {
+	key     string
+	pattern *regexp.Regexp // of the lower-case value
+}
+
+type and struct{ left, right Expression }
+type or struct{ left, right Expression }
+type not struct{ operand Expression }
+
+func (t term) Matches(item objmodel.CoreSpec, external bool) bool {
+	for _, value := range values(t.key, item, external) {
+		if t.pattern.MatchString(strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a and) Matches(item objmodel.CoreSpec, external bool) bool {
+	return a.left.Matches(item, external) && a.right.Matches(item, external)
+}
+
+func (o or) Matches(item objmodel.CoreSpec, external bool) bool {
+	return o.left.Matches(item, external) || o.right.Matches(item, external)
+}
+
+func (n not) Matches(item objmodel.CoreSpec, external bool) bool {
+	return !n.operand.Matches(item, external)
+}
+
+// Values of an item for a key
+func values(key string, item objmodel.CoreSpec, external bool) []string {
+	switch key {
+	case "id":
+		return []string{item.GetID()}
+	case "name":
+		return []string{item.GetName()}
+	case "type":
+		switch item.(type) {
+		case *objmodel.Human:
+			return []string{"human"}
+		case *objmodel.Program:
+			return []string{"program"}
+		case *objmodel.Role:
+			return []string{"role"}
+		case objmodel.FlowSpec:
+			return []string{"flow"}
+		}
+	case "scope":
+		if external {
+			return []string{"external"}
+		}
+		return []string{"internal"}
+	case "tag":
+		return item.GetTags()
+	case "owner", "team":
+		entity, ok := item.(objmodel.EntitySpec)
+		if external || !ok {
+			return nil
+		}
+		if key == "owner" {
+			return []string{entity.GetOwnership().Owner}
+		}
+		return []string{entity.GetOwnership().Team}
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// Parser
+
+type parser struct {
+	tokens   []string
+	position int
+}
+
+// Split an expression into parentheses, words and quoted values
+func tokenize(text string) (tokens []string) {
+	var current strings.Builder
+	quoted := false
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, c := range text {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			current.WriteRune(c)
+		case c == '(' || c == ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+	return
+}
+
+func (p *parser) next() string {
+	if p.position >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.position]
+}
+
+func (p *parser) or() (Expression, error) {
+	left, err := p.and()
+	for err == nil && strings.EqualFold(p.next(), "or") {
+		p.position++
+		var right Expression
+		right, err = p.and()
+		left = or{left, right}
+	}
+	return left, err
+}
+
+func (p *parser) and() (Expression, error) {
+	left, err := p.unary()
+	for err == nil && strings.EqualFold(p.next(), "and") {
+		p.position++
+		var right Expression
+		right, err = p.unary()
+		left = and{left, right}
+	}
+	return left, err
+}
+
+func (p *parser) unary() (Expression, error) {
+	token := p.next()
+	p.position++
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of expression")
+	case strings.EqualFold(token, "not"):
+		operand, err := p.unary()
+		return not{operand}, err
+	case token == "(":
+		e, err := p.or()
+		if err == nil && p.next() != ")" {
+			err = errors.New("missing ')'")
+		}
+		p.position++
+		return e, err
+	}
+	key, value, found := strings.Cut(token, ":")
+	key = strings.ToLower(key)
+	if !found || value == "" {
+		return nil, errors.New("'" + token + "' is not a term like 'tag:pci'")
+	}
+	if !contains(key, Keys) {
+		return nil, errors.New("unknown key '" + key + "'. Supported keys - " + strings.Join(Keys, ", "))
+	}
+	return term{key: key, pattern: wildcardPattern(strings.ToLower(value))}, nil
+}
+
+// Regular expression for a value with '*' and '?' wildcards. Unlike
+// 'path.Match', wildcards match '/' too, so that 'name:ci/*' matches
+// 'ci/deploy'.
+func wildcardPattern(value string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(value)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("(?s)^" + expr + "$")
+}
+
+func contains(item string, list []string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
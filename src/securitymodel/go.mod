@@ -4,6 +4,8 @@ go 1.19
 
 replace addb => ../addb
 
+replace schema => ../schema
+
 replace libadm => ../../../adm/src/libadm
 
 replace securitymodel/addb => ./addb
@@ -16,7 +18,10 @@ replace securitymodel/objmodel => ./objmodel
 
 require gopkg.in/yaml.v3 v3.0.1 // direct
 
-require addb v0.0.0-00010101000000-000000000000
+require (
+	addb v0.0.0-00010101000000-000000000000
+	schema v0.0.0-00010101000000-000000000000
+)
 
 require (
 	github.com/cucumber/gherkin-go/v19 v19.0.3 // indirect
@@ -1,8 +1,13 @@
 package loaders
 
+import "securitymodel/sbom"
+
 type Builder struct {
 	yamlIndex map[string]interface{}
 	objectIndex map[string]interface{}
+	addbReferences []string	// IDs of all ADDB entries indexed by this builder
+	unmappedComponents map[string][]sbom.Component	// SBOM components without an ADDB entry, by entity ID
+	resolving []string	// IDs of objects being built, in the order they were referred
 }
 
 func (t *Builder) init() {
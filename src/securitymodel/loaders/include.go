@@ -0,0 +1,223 @@
+package loaders
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"schema"
+	"securitymodel/yamlmodel"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge externals, entities, flows and model-level ADM from fragments listed
+// under 'include' (and fragments they include) into 'm'. Include paths are
+// relative to the directory of the including file and can contain glob
+// patterns. 'modelDir' is the directory of the model file itself. Model's
+// parameters are applied to fragments before they are merged. Fragments
+// included more than once (like two fragments including a common one) are
+// merged once. The file each merged item came from is recorded in
+// 'm.Fragments'.
+func mergeIncludes(m *yamlmodel.SecurityModel, modelDir string, params parameters) []error {
+	i := includer{root: m, modelDir: modelDir, params: params, origin: make(map[string]string), merged: make(map[string]bool)}
+	i.recordOrigins(m, "")
+	errs := i.include(m.Includes, modelDir, nil)
+	for id, file := range i.origin {
+		if file != "" {
+			if m.Fragments == nil {
+				m.Fragments = make(map[string]string)
+			}
+			m.Fragments[id] = file
+		}
+	}
+	return errs
+}
+
+// Paths of all fragments included, directly or via other fragments, by a
+// model. Fragments that cannot be read are skipped.
+func IncludedFiles(yamlText string, modelDir string) []string {
+	var m yamlmodel.SecurityModel
+	if err := yaml.Unmarshal([]byte(yamlText), &m); err != nil {
+		return nil
+	}
+
+	var files []string
+	pending := m.Includes
+	dirs := make([]string, len(pending))
+	for i := range dirs {
+		dirs[i] = modelDir
+	}
+	for len(pending) > 0 {
+		pattern, dir := pending[0], dirs[0]
+		pending, dirs = pending[1:], dirs[1:]
+		matches, _ := expandInclude(pattern, dir)
+		for _, file := range matches {
+			if contains(file, files) {
+				continue
+			}
+			files = append(files, file)
+			if fragment, _, err := readFragment(file); err == nil {
+				for _, include := range fragment.Includes {
+					pending = append(pending, include)
+					dirs = append(dirs, filepath.Dir(file))
+				}
+			}
+		}
+	}
+	return files
+}
+
+type includer struct {
+	root     *yamlmodel.SecurityModel
+	modelDir string
+	params   parameters
+	origin   map[string]string // ID to the file it was defined in ("" for the model file)
+	merged   map[string]bool   // fragments merged so far
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// 'stack' contains fragments being included, to detect fragments including each other.
+func (i *includer) include(patterns []string, dir string, stack []string) (errs []error) {
+	for _, pattern := range patterns {
+		files, err := expandInclude(pattern, dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range files {
+			if contains(file, stack) {
+				errs = append(errs, errors.New("include cycle - "+strings.Join(append(stack, file), " -> ")))
+				continue
+			}
+			if i.merged[file] {
+				continue
+			}
+			i.merged[file] = true
+			fragment, problems, err := readFragment(file)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			errs = append(errs, problems...)
+			errs = append(errs, i.params.apply(fragment, file)...)
+			errs = append(errs, i.merge(fragment, file)...)
+			errs = append(errs, i.include(fragment.Includes, filepath.Dir(file), append(stack, file))...)
+		}
+	}
+	return
+}
+
+func (i *includer) merge(fragment *yamlmodel.SecurityModel, file string) (errs []error) {
+	if fragment.AddbUri.String() != "" {
+		errs = append(errs, errors.New("'addb' can only be specified in the main model, found in '"+file+"'"))
+	}
+	if len(fragment.Parameters) > 0 {
+		errs = append(errs, errors.New("'parameters' can only be specified in the main model, found in '"+file+"'"))
+	}
+	errs = append(errs, i.recordOrigins(fragment, file)...)
+
+	// Items are located relative to their fragment's directory
+	fragmentDir := filepath.Dir(file)
+	for _, e := range fragment.Externals {
+		if e != nil {
+			e.AdmDir = fragmentDir
+			i.root.Externals = append(i.root.Externals, e)
+		}
+	}
+	for _, e := range fragment.Entities {
+		if e != nil {
+			e.AdmDir = fragmentDir
+			i.root.Entities = append(i.root.Entities, e)
+		}
+	}
+	for _, f := range fragment.Flows {
+		if f != nil {
+			f.AdmDir = fragmentDir
+			i.root.Flows = append(i.root.Flows, f)
+		}
+	}
+	for _, n := range fragment.Deployment {
+		if n != nil {
+			n.AdmDir = fragmentDir // nested nodes are located relative to their parent
+			i.root.Deployment = append(i.root.Deployment, n)
+		}
+	}
+	// Model-level ADM paths are always resolved against the model's directory
+	for _, adm := range fragment.ModelADM {
+		path := filepath.Join(fragmentDir, adm)
+		if rel, err := filepath.Rel(i.modelDir, path); err == nil {
+			path = rel
+		}
+		i.root.ModelADM = append(i.root.ModelADM, path)
+	}
+	return
+}
+
+// Remember which file each item's ID came from. IDs defined in more than one file are reported.
+func (i *includer) recordOrigins(m *yamlmodel.SecurityModel, file string) (errs []error) {
+	var ids []string
+	for _, e := range append(append([]*yamlmodel.Entity{}, m.Externals...), m.Entities...) {
+		if e != nil && e.Id != "" {
+			ids = append(ids, e.Id)
+		}
+	}
+	for _, f := range m.Flows {
+		if f != nil && f.Id != "" {
+			ids = append(ids, f.Id)
+		}
+	}
+	for _, id := range ids {
+		if existing, found := i.origin[id]; found && existing != file {
+			errs = append(errs, errors.New("'"+id+"' is defined in both "+describeFile(existing)+" and "+describeFile(file)))
+			continue
+		}
+		i.origin[id] = file
+	}
+	return
+}
+
+func describeFile(file string) string {
+	if file == "" {
+		return "the main model"
+	}
+	return "'" + file + "'"
+}
+
+func expandInclude(pattern string, dir string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, errors.New("cannot include '" + pattern + "' - " + err.Error())
+		}
+		return []string{pattern}, nil
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, errors.New("invalid include pattern '" + pattern + "' - " + err.Error())
+	}
+	return files, nil
+}
+
+// Read a fragment (or another model). Problems found by validating it
+// against the model schema are returned along with the fragment.
+func readFragment(file string) (*yamlmodel.SecurityModel, []error, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fragment yamlmodel.SecurityModel
+	problems, err := decodeModel(content, &fragment, schema.ValidateFragment)
+	if err != nil {
+		return nil, nil, errors.New("cannot read '" + file + "' - " + err.Error())
+	}
+	for i, problem := range problems {
+		problems[i] = errors.New(file + ": " + problem.Error())
+	}
+	return &fragment, problems, nil
+}
@@ -3,7 +3,9 @@ package loaders
 import (
 	"addb"
 	"errors"
+	"path/filepath"
 	"securitymodel/objmodel"
+	"securitymodel/sbom"
 	"securitymodel/yamlmodel"
 	"strings"
 )
@@ -75,25 +77,26 @@ func (b *Builder) indexModelParts(m *yamlmodel.SecurityModel, admDir string, add
 
 	// Index parts of each entity. This is required by resolver later
 	// to find yaml objects that are referred in other places in the doc.
+	// Items from included fragments use their fragment's directory.
 	for _, obj := range m.Externals {
 		if obj == nil || obj.Id == "" {
 			continue
 		}
-		errs := b.indexEntityParts(obj.Id, admDir, addb, obj)
+		errs := b.indexEntityParts(obj.Id, itemDir(obj.AdmDir, admDir), addb, obj)
 		errors = append(errors, errs...)
 	}
 	for _, obj := range m.Entities {
 		if obj == nil || obj.Id == "" {
 			continue
 		}
-		errs := b.indexEntityParts(obj.Id, admDir, addb, obj)
+		errs := b.indexEntityParts(obj.Id, itemDir(obj.AdmDir, admDir), addb, obj)
 		errors = append(errors, errs...)
 	}
 	for _, obj := range m.Flows {
 		if obj == nil || obj.Id == "" {
 			continue
 		}
-		errs := b.indexFlowParts(obj.Id, admDir, addb, obj)
+		errs := b.indexFlowParts(obj.Id, itemDir(obj.AdmDir, admDir), addb, obj)
 		errors = append(errors, errs...)
 	}
 
@@ -140,6 +143,12 @@ func (b *Builder) indexEntityParts(id string, basePath string, addb *addb.ADDB,
 			}
 		}
 	}
+	if entity.SBOM != "" {
+		err := b.importSBOM(id, basePath, addb, entity)
+		if err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
 	if len(entity.Dependencies) > 0 {
 		for _, dep := range entity.Dependencies {
 			if dep != "" {
@@ -158,6 +167,38 @@ func (b *Builder) indexEntityParts(id string, basePath string, addb *addb.ADDB,
 	}
 }
 
+// Add ADDB entries for components listed in the entity's SBOM to its
+// dependencies. Components without an ADDB entry are recorded, so they
+// can be reported.
+func (b *Builder) importSBOM(id string, basePath string, addb *addb.ADDB, entity *yamlmodel.Entity) error {
+	if entity.Type != yamlmodel.Program && entity.Type != yamlmodel.System {
+		return errors.New("'sbom' is only applicable to programs and systems. '" + id + "' is a " + string(entity.Type))
+	}
+
+	path := entity.SBOM
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(basePath, path)
+	}
+	components, err := sbom.Load(path)
+	if err != nil {
+		return err
+	}
+
+	ids, unmapped := sbom.MapComponents(components, addb)
+	for _, dep := range ids {
+		if !contains(dep, entity.Dependencies) {
+			entity.Dependencies = append(entity.Dependencies, dep)
+		}
+	}
+	if len(unmapped) > 0 {
+		if b.unmappedComponents == nil {
+			b.unmappedComponents = make(map[string][]sbom.Component)
+		}
+		b.unmappedComponents[id] = unmapped
+	}
+	return nil
+}
+
 func (b *Builder) indexFlowParts(id string, basePath string, addb *addb.ADDB, flow *yamlmodel.Flow) []error {
 	var allErrors []error
 
@@ -199,9 +240,10 @@ func (b *Builder) readandIndexYamlFromADDB(id string, addb *addb.ADDB) (interfac
 	if err != nil {
 		return nil, nil
 	}
+	b.addbReferences = append(b.addbReferences, id)
 
 	switch strings.ToLower(string(component.Type)) {
-	case "human", "program", "system":
+	case "human", "program", "system", "role":
 		entity, err := b.translateADDBComponentToEntity(component)
 		if err != nil {
 			return nil, nil
@@ -237,18 +279,22 @@ func (t *Builder) translateADDBComponentToEntity(component *addb.ADDBComponent)
 		entity.Type = yamlmodel.Program
 	case addb.System:
 		entity.Type = yamlmodel.System
-
+	case addb.Role:
+		entity.Type = yamlmodel.Role
 	}
 	entity.Description = component.Description
+	entity.Owner, entity.Team, entity.Contact = component.Owner, component.Team, component.Contact
+	entity.Tags = component.Tags
 	entity.Base = component.Base
+	entity.Mitigations = component.Mitigations
 	entity.Recommendations = component.Recommendations
 	entity.ADM = component.ADM
 
 	// Only for humans
-	entity.Roles = component.Roles
 	entity.Interface = component.Interface
 
 	// Only for programs
+	entity.Roles = component.Roles
 	entity.CodeRepository = component.CodeRepository
 	entity.Languages = component.Languages
 	entity.Dependencies = component.Dependencies
@@ -267,11 +313,32 @@ func (db *Builder) translateADDBComponentToFlow(component *addb.ADDBComponent) (
 	flow.Name = component.Name
 	flow.Description = component.Description
 	flow.Protocol = component.Protocol
+	flow.Owner, flow.Team, flow.Contact = component.Owner, component.Team, component.Contact
+	flow.Tags = component.Tags
 
 	// NOTE: Sender & Receiver are available for an ADDB component
 
+	flow.Mitigations = component.Mitigations
 	flow.Recommendations = component.Recommendations
 	flow.ADM = component.ADM
 
 	return &flow, nil
 }
+
+// Directory of the file an item was defined in, if it was included from
+// another file. Otherwise, the model's directory.
+func itemDir(dir string, modelDir string) string {
+	if dir != "" {
+		return dir
+	}
+	return modelDir
+}
+
+func contains(item string, list []string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
@@ -2,16 +2,53 @@ package loaders
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"addb"
+	"schema"
 	"securitymodel/objmodel"
+	"securitymodel/sbom"
 	"securitymodel/yamlmodel"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Loader struct {
-	builder Builder
+	builder    Builder
+	addb       *addb.ADDB
+	parameters map[string]string
+}
+
+// Values of parameters (see 'parameters' in models) to use when loading
+// models. They override environment variables and defaults in models.
+func (l *Loader) SetParameters(values map[string]string) {
+	l.parameters = values
+}
+
+// ADDB used by the last loaded model.
+func (l *Loader) ADDB() *addb.ADDB {
+	return l.addb
+}
+
+// Problems that did not stop loading, like invalid ADDB entries that were skipped.
+func (l *Loader) Warnings() []error {
+	if l.addb == nil {
+		return nil
+	}
+	return l.addb.Warnings
+}
+
+// IDs of all ADDB entries referenced, directly or via other ADDB entries,
+// by items loaded so far.
+func (l *Loader) ADDBReferences() []string {
+	return l.builder.addbReferences
+}
+
+// SBOM components, by entity ID, that could not be mapped to an ADDB entry.
+func (l *Loader) UnmappedSBOMComponents() map[string][]sbom.Component {
+	return l.builder.unmappedComponents
 }
 
 func (l *Loader) LoadSecurityModel(yamlText string, admDir string) (*objmodel.SecurityModel, []error) {
@@ -22,16 +59,30 @@ func (l *Loader) LoadSecurityModel(yamlText string, admDir string) (*objmodel.Se
 	}
 
 	var m yamlmodel.SecurityModel
-	err := yaml.Unmarshal([]byte(yamlText), &m)
+	problems, err := decodeModel([]byte(yamlText), &m, schema.ValidateModel)
 	if err != nil {
 		return nil, []error{err}
 	}
+	errs = append(errs, problems...)
+
+	params := parameters{defaults: m.Parameters, values: l.parameters}
+	errs = append(errs, params.apply(&m, "")...)
+
+	includeErrs := mergeIncludes(&m, admDir, params)
+	if len(includeErrs) != 0 {
+		errs = append(errs, includeErrs...)
+	}
+	refErrs := resolveEntityRefs(&m, admDir, l.parameters)
+	if len(refErrs) != 0 {
+		errs = append(errs, refErrs...)
+	}
 
 	var addb addb.ADDB
-	err = addb.Init(m.AddbUri)
+	err = initADDB(&addb, m.AddbUri, admDir)
 	if err != nil {
 		errs = append(errs, err)
 	}
+	l.addb = &addb
 
 	idxErrs := l.builder.Index("", &m, admDir, &addb)
 	if len(idxErrs) != 0 {
@@ -57,7 +108,7 @@ func (l *Loader) LoadHuman(yamlText string, admDir string, addbUrl string) (*obj
 	}
 
 	var h yamlmodel.Entity
-	err := yaml.Unmarshal([]byte(yamlText), &h)
+	err := schema.Unmarshal([]byte(yamlText), &h)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -71,6 +122,7 @@ func (l *Loader) LoadHuman(yamlText string, admDir string, addbUrl string) (*obj
 	if err != nil {
 		errs = append(errs, err)
 	}
+	l.addb = &addb
 
 	// Index entity and its parts
 	idxErrs := l.builder.Index(h.Id, &h, admDir, &addb)
@@ -95,7 +147,7 @@ func (l *Loader) LoadProgram(yamlText string, admDir string, addbUrl string) (*o
 	}
 
 	var p yamlmodel.Entity
-	err := yaml.Unmarshal([]byte(yamlText), &p)
+	err := schema.Unmarshal([]byte(yamlText), &p)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -109,6 +161,7 @@ func (l *Loader) LoadProgram(yamlText string, admDir string, addbUrl string) (*o
 	if err != nil {
 		errs = append(errs, err)
 	}
+	l.addb = &addb
 
 	// Index entity and its parts
 	idxErrs := l.builder.Index(p.Id, &p, admDir, &addb)
@@ -133,7 +186,7 @@ func (l *Loader) LoadFlow(yamlText string, admDir string, addbUrl string) (*objm
 	}
 
 	var f yamlmodel.Flow
-	err := yaml.Unmarshal([]byte(yamlText), &f)
+	err := schema.Unmarshal([]byte(yamlText), &f)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -143,6 +196,7 @@ func (l *Loader) LoadFlow(yamlText string, admDir string, addbUrl string) (*objm
 	if err != nil {
 		errs = append(errs, err)
 	}
+	l.addb = &addb
 
 	// Index entity and its parts
 	idxErrs := l.builder.Index(f.Id, &f, admDir, &addb)
@@ -158,3 +212,38 @@ func (l *Loader) LoadFlow(yamlText string, admDir string, addbUrl string) (*objm
 
 	return &flow, errs
 }
+
+////////////////////////////////////////
+// Helper functions
+
+// Decode a security model (or a fragment) that is then checked using
+// 'validate'. Unknown fields and other problems are returned as a list, with
+// the content decoded regardless. Only invalid YAML results in an error.
+func decodeModel(content []byte, m *yamlmodel.SecurityModel, validate func([]byte) []error) ([]error, error) {
+	err := schema.Unmarshal(content, m)
+	if _, ok := err.(*yaml.TypeError); err != nil && !ok {
+		return nil, err
+	}
+	problems := validate(content)
+	if len(problems) == 0 && err != nil { // not covered by the schema
+		problems = append(problems, err)
+	}
+	return problems, nil
+}
+
+// Initialize ADDB from a local directory or a git repository. Relative
+// paths to local repositories are resolved against the model's directory.
+func initADDB(db *addb.ADDB, ref yamlmodel.AddbReference, modelDir string) error {
+	if ref.Repo == "" {
+		return db.Init(ref.Path)
+	}
+
+	repo := ref.Repo
+	isLocalPath := !strings.Contains(repo, "://") && !strings.Contains(repo, "@") && !strings.HasPrefix(repo, "~")
+	if isLocalPath && !filepath.IsAbs(repo) && modelDir != "" {
+		if _, err := os.Stat(filepath.Join(modelDir, repo)); err == nil {
+			repo = filepath.Join(modelDir, repo)
+		}
+	}
+	return db.InitFromGit(repo, ref.Ref)
+}
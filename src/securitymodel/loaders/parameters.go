@@ -0,0 +1,177 @@
+package loaders
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"securitymodel/yamlmodel"
+)
+
+// '${NAME}' in any field of a model
+var variable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Values of parameters used in '${NAME}' variables and 'when' conditions.
+// Values set when loading the model (see 'Loader.SetParameters') take
+// precedence over environment variables, which take precedence over defaults
+// listed under 'parameters'.
+type parameters struct {
+	defaults map[string]string
+	values   map[string]string
+}
+
+// Substitute variables in all fields of 'm' and drop externals, entities and
+// flows whose 'when' condition does not hold. 'file' is the fragment 'm' was
+// read from ("" for the model file).
+func (p parameters) apply(m *yamlmodel.SecurityModel, file string) []error {
+	errs := p.expandFields(reflect.ValueOf(m).Elem())
+
+	var filterErrs []error
+	m.Externals, filterErrs = p.filterEntities(m.Externals)
+	errs = append(errs, filterErrs...)
+	m.Entities, filterErrs = p.filterEntities(m.Entities)
+	errs = append(errs, filterErrs...)
+	m.Flows, filterErrs = p.filterFlows(m.Flows)
+	errs = append(errs, filterErrs...)
+
+	if file != "" {
+		for i, err := range errs {
+			errs[i] = errors.New(file + ": " + err.Error())
+		}
+	}
+	return errs
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (p parameters) lookup(name string) (string, bool) {
+	if value, found := p.values[name]; found {
+		return value, true
+	}
+	if value, found := os.LookupEnv(name); found {
+		return value, true
+	}
+	value, found := p.defaults[name]
+	return value, found
+}
+
+// Replace '${NAME}' in 's' with the value of 'NAME'
+func (p parameters) expand(s string) (string, error) {
+	var err error
+	expanded := variable.ReplaceAllStringFunc(s, func(match string) string {
+		name := strings.TrimSpace(match[2 : len(match)-1])
+		value, found := p.lookup(name)
+		if !found && err == nil {
+			err = undefinedParameter(name)
+		}
+		return value
+	})
+	return expanded, err
+}
+
+// Expand variables in all strings reachable from 'v'. Parameters themselves
+// and 'when' conditions are left as is.
+func (p parameters) expandFields(v reflect.Value) (errs []error) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			errs = append(errs, p.expandFields(v.Elem())...)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+			if !v.Type().Field(i).IsExported() || name == "-" || name == "parameters" || name == "when" {
+				continue
+			}
+			errs = append(errs, p.expandFields(v.Field(i))...)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, p.expandFields(v.Index(i))...)
+		}
+	case reflect.String:
+		expanded, err := p.expand(v.String())
+		if err != nil {
+			errs = append(errs, err)
+		}
+		v.SetString(expanded)
+	}
+	return
+}
+
+func (p parameters) filterEntities(entities []*yamlmodel.Entity) (kept []*yamlmodel.Entity, errs []error) {
+	for _, e := range entities {
+		if e == nil || e.When == "" {
+			kept = append(kept, e)
+			continue
+		}
+		include, err := p.evaluate(e.When)
+		if err != nil {
+			errs = append(errs, errors.New("invalid 'when' in '"+e.Id+"' - "+err.Error()))
+		}
+		if include { // items with invalid conditions are left out
+			kept = append(kept, e)
+		}
+	}
+	return
+}
+
+func (p parameters) filterFlows(flows []*yamlmodel.Flow) (kept []*yamlmodel.Flow, errs []error) {
+	for _, f := range flows {
+		if f == nil || f.When == "" {
+			kept = append(kept, f)
+			continue
+		}
+		include, err := p.evaluate(f.When)
+		if err != nil {
+			errs = append(errs, errors.New("invalid 'when' in '"+f.Id+"' - "+err.Error()))
+		}
+		if include { // items with invalid conditions are left out
+			kept = append(kept, f)
+		}
+	}
+	return
+}
+
+// Evaluate a condition made of comparisons ('name == value' or
+// 'name != value') joined by '&&' and '||'. '&&' takes precedence over '||'.
+func (p parameters) evaluate(condition string) (bool, error) {
+	result := false
+	for _, alternative := range strings.Split(condition, "||") {
+		matched := true
+		for _, comparison := range strings.Split(alternative, "&&") {
+			holds, err := p.compare(comparison)
+			if err != nil {
+				return false, err
+			}
+			matched = matched && holds
+		}
+		result = result || matched
+	}
+	return result, nil
+}
+
+func (p parameters) compare(comparison string) (bool, error) {
+	operator := "=="
+	if strings.Contains(comparison, "!=") {
+		operator = "!="
+	}
+	name, value, found := strings.Cut(comparison, operator)
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return false, errors.New("'" + strings.TrimSpace(comparison) + "' is not a comparison like 'env == prod' or 'env != prod'")
+	}
+	actual, defined := p.lookup(name)
+	if !defined {
+		return false, undefinedParameter(name)
+	}
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	return (actual == value) == (operator == "=="), nil
+}
+
+func undefinedParameter(name string) error {
+	return errors.New("undefined parameter '" + name + "' - add it to 'parameters' or pass it with '--set " + name + "=<value>'")
+}
@@ -0,0 +1,173 @@
+package loaders
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"securitymodel/yamlmodel"
+)
+
+// Replace entities that refer to an entity in another model
+// ('ref: ../platform/platform.smspec#api-gateway') with that entity's
+// definition. Items the shared entity refers to (roles, base, etc.) are
+// imported too, unless this model defines them. Paths in shared entities
+// are resolved against the directory of the model defining them. Referred
+// models use their own parameter defaults, overridden by 'values'.
+func resolveEntityRefs(m *yamlmodel.SecurityModel, modelDir string, values map[string]string) []error {
+	r := refResolver{models: make(map[string]*yamlmodel.SecurityModel), values: values}
+	return r.resolve(m, modelDir, nil)
+}
+
+type refResolver struct {
+	models map[string]*yamlmodel.SecurityModel // models referred so far, by path
+	values map[string]string                   // values of parameters set when loading the model
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// 'stack' contains models whose references are being resolved, to detect models referring to each other.
+func (r *refResolver) resolve(m *yamlmodel.SecurityModel, modelDir string, stack []string) (errs []error) {
+	local := make(map[string]bool)
+	for _, e := range append(append([]*yamlmodel.Entity{}, m.Externals...), m.Entities...) {
+		if e != nil && e.Id != "" {
+			local[e.Id] = true
+		}
+	}
+
+	for _, list := range [][]*yamlmodel.Entity{m.Externals, m.Entities} {
+		for _, entity := range list {
+			if entity == nil || entity.Ref == "" {
+				continue
+			}
+			file, id := parseRef(entity.Ref, itemDir(entity.AdmDir, modelDir))
+			if id == "" {
+				id = entity.Id
+			}
+			if contains(file, stack) {
+				errs = append(errs, errors.New("models refer to each other - "+strings.Join(append(stack, file), " -> ")))
+				continue
+			}
+			source, err := r.load(file, stack)
+			if err != nil {
+				errs = append(errs, errors.New("cannot resolve '"+entity.Ref+"' - "+err.Error()))
+				continue
+			}
+			shared := findEntity(source, id)
+			if shared == nil {
+				errs = append(errs, errors.New("cannot resolve '"+entity.Ref+"' - '"+id+"' not found in '"+file+"'"))
+				continue
+			}
+
+			localId := entity.Id
+			if localId == "" {
+				localId = id
+			}
+			*entity = *shared
+			entity.Id = localId
+			entity.Ref = ""
+			recordSharedEntity(m, localId, source, file, id)
+
+			// Import items referred by the shared entity that this model doesn't define
+			pending := entityReferences(shared)
+			for len(pending) > 0 {
+				ref := pending[0]
+				pending = pending[1:]
+				if local[ref] {
+					continue
+				}
+				item := findEntity(source, ref)
+				if item == nil {
+					continue // reported when the object model is built
+				}
+				imported := *item
+				if findEntityIn(source.Externals, ref) != nil {
+					m.Externals = append(m.Externals, &imported)
+				} else {
+					m.Entities = append(m.Entities, &imported)
+				}
+				local[ref] = true
+				recordSharedEntity(m, ref, source, file, ref)
+				pending = append(pending, entityReferences(item)...)
+			}
+		}
+	}
+	return
+}
+
+// Load a model along with its fragments and resolve its references.
+func (r *refResolver) load(file string, stack []string) (*yamlmodel.SecurityModel, error) {
+	if m, found := r.models[file]; found {
+		return m, nil
+	}
+	m, _, err := readFragment(file) // problems are reported when that model is processed
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(file)
+	params := parameters{defaults: m.Parameters, values: r.values}
+	errs := params.apply(m, file)
+	errs = append(errs, mergeIncludes(m, dir, params)...)
+	errs = append(errs, r.resolve(m, dir, append(stack, file))...)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	for _, e := range append(append([]*yamlmodel.Entity{}, m.Externals...), m.Entities...) {
+		if e != nil && e.AdmDir == "" {
+			e.AdmDir = dir
+		}
+	}
+	r.models[file] = m
+	return m, nil
+}
+
+func recordSharedEntity(m *yamlmodel.SecurityModel, localId string, source *yamlmodel.SecurityModel, file string, id string) {
+	if m.SharedEntities == nil {
+		m.SharedEntities = make(map[string]yamlmodel.EntitySource)
+	}
+	if origin, found := source.SharedEntities[id]; found { // shared entity is itself shared from another model
+		m.SharedEntities[localId] = origin
+		return
+	}
+	m.SharedEntities[localId] = yamlmodel.EntitySource{Title: source.Title, Path: file, Id: id}
+}
+
+// Split '<path>#<id>'. Relative paths are resolved against 'dir'.
+func parseRef(ref string, dir string) (file string, id string) {
+	file, id, _ = strings.Cut(ref, "#")
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return filepath.Clean(file), id
+}
+
+func findEntity(m *yamlmodel.SecurityModel, id string) *yamlmodel.Entity {
+	if e := findEntityIn(m.Externals, id); e != nil {
+		return e
+	}
+	return findEntityIn(m.Entities, id)
+}
+
+func findEntityIn(entities []*yamlmodel.Entity, id string) *yamlmodel.Entity {
+	for _, e := range entities {
+		if e != nil && e.Id == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// IDs of model items (not ADDB entries) an entity refers to.
+func entityReferences(e *yamlmodel.Entity) (refs []string) {
+	all := append(append(append(append([]string{}, e.Base...), e.Roles...), e.Languages...), e.Dependencies...)
+	if e.Interface != "" {
+		all = append(all, e.Interface)
+	}
+	for _, ref := range all {
+		if ref != "" && !strings.HasPrefix(ref, "addb:") {
+			refs = append(refs, ref)
+		}
+	}
+	return
+}
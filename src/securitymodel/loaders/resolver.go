@@ -35,8 +35,22 @@ func (t *Builder) Resolve(id string) (interface{}, []error) {
 	if obj, exists := t.objectIndex[id]; exists { // object alredy indexed
 		return obj, nil
 	} else if yamlObj, exists := t.yamlIndex[id]; exists { // object has to be built from indexed yaml data
+		// Objects are indexed only after they are built. If this object is
+		// still being built, it refers back to itself via other objects.
+		// Only the cycle is reported, not the objects leading to it.
+		if contains(id, t.resolving) {
+			start := 0
+			for t.resolving[start] != id {
+				start++
+			}
+			chain := strings.Join(append(append([]string{}, t.resolving[start:]...), id), " -> ")
+			return nil, []error{errors.New("circular reference - " + chain)}
+		}
+
 		// Build object, index it and return the built object.
+		t.resolving = append(t.resolving, id)
 		obj, errs := t.buildObjectFromYaml(yamlObj)
+		t.resolving = t.resolving[:len(t.resolving)-1]
 		if obj == nil {
 			errs = append(errs, errors.New("unknown error when building object from yaml for '"+id+"'"))
 		}
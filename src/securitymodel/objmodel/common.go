@@ -2,6 +2,7 @@ package objmodel
 
 import (
 	"errors"
+	"strings"
 )
 
 ////////////////////////////////////////
@@ -20,6 +21,8 @@ type CoreSpec interface {
 	SetName(string) error
 	GetDescription() string
 	SetDescription(string) error
+	GetTags() []string
+	SetTags([]string) error
 }
 
 type EntitySpec interface {
@@ -33,6 +36,10 @@ type EntitySpec interface {
 	GetRecommendations() map[string][]string
 	SetRecommendations([]string) error
 	AddRecommendation(reco string) error
+	GetRiskAttributes() RiskAttributes
+	SetRiskAttributes(RiskAttributes) error
+	GetOwnership() Ownership
+	SetOwnership(Ownership) error
 }
 
 type ExternalSpec interface {
@@ -104,6 +111,30 @@ type CoreObject struct {
 	adm []string
 	mitigations []string
 	recommendations []string
+	risk RiskAttributes
+	ownership Ownership
+	tags []string
+}
+
+// Values used to rate risks of attacks on an entity or flow (see 'risk' package).
+// Empty values are not set in the model.
+type RiskAttributes struct {
+	Likelihood string
+	Criticality string
+	DataClassification string
+}
+
+// Person/team responsible for an entity or flow, and how to reach them
+type Ownership struct {
+	Owner string
+	Team string
+	Contact string
+}
+
+// Check if 'name' is the owner or team of an item. Names are matched ignoring
+// case.
+func (o Ownership) OwnedBy(name string) bool {
+	return name != "" && (strings.EqualFold(o.Owner, name) || strings.EqualFold(o.Team, name))
 }
 
 func (c *CoreObject) GetID() string {
@@ -142,6 +173,15 @@ func (c *CoreObject) SetDescription(desc string) error {
 	return nil
 }
 
+func (c *CoreObject) GetTags() []string {
+	return c.tags
+}
+
+func (c *CoreObject) SetTags(tags []string) error {
+	c.tags = tags
+	return nil
+}
+
 func (c *CoreObject) SetADM(admlist []string) error {
 	c.adm = admlist
 	return nil
@@ -160,6 +200,24 @@ func (c *CoreObject) SetRecommendations(reco []string) error {
 	return nil
 }
 
+func (c *CoreObject) GetRiskAttributes() RiskAttributes {
+	return c.risk
+}
+
+func (c *CoreObject) SetRiskAttributes(risk RiskAttributes) error {
+	c.risk = risk
+	return nil
+}
+
+func (c *CoreObject) GetOwnership() Ownership {
+	return c.ownership
+}
+
+func (c *CoreObject) SetOwnership(ownership Ownership) error {
+	c.ownership = ownership
+	return nil
+}
+
 ////////////////////////////////////////
 // Helper functions
 
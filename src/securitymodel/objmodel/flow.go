@@ -1,6 +1,7 @@
 package objmodel
 
 import (
+	"addb"
 	"errors"
 	"securitymodel/yamlmodel"
 )
@@ -34,13 +35,19 @@ func (f *Flow) Init(fl *yamlmodel.Flow, r Resolver) []error {
 
 	if fl.AdmDir != "" {
 		for _, adm := range fl.ADM {
-			adm = fl.AdmDir + "/" + adm
+			if !addb.IsInlineADM(adm) {
+				adm = fl.AdmDir + "/" + adm
+			}
 			f.AddADM(adm)
 		}
 	} else {
 		f.SetADM(fl.ADM)
 	}
 
+	f.SetRiskAttributes(RiskAttributes{Likelihood: fl.Likelihood, Criticality: fl.Criticality, DataClassification: fl.DataClassification})
+	f.SetOwnership(Ownership{Owner: fl.Owner, Team: fl.Team, Contact: fl.Contact})
+	f.SetTags(fl.Tags)
+
 	for _, proto := range fl.Protocol {
 		obj, protoErrs := r(proto)
 		if len(protoErrs) != 0 {
@@ -1,6 +1,7 @@
 package objmodel
 
 import (
+	"addb"
 	"errors"
 	"securitymodel/yamlmodel"
 )
@@ -37,7 +38,9 @@ func (h *Human) Init(e *yamlmodel.Entity, r Resolver) []error {
 
 	if e.AdmDir != "" {
 		for _, adm := range e.ADM {
-			adm = e.AdmDir + "/" + adm
+			if !addb.IsInlineADM(adm) {
+				adm = e.AdmDir + "/" + adm
+			}
 			h.AddADM(adm)
 		}
 	} else {
@@ -46,6 +49,9 @@ func (h *Human) Init(e *yamlmodel.Entity, r Resolver) []error {
 
 	h.SetMitigations(e.Mitigations)
 	h.SetRecommendations(e.Recommendations)
+	h.SetRiskAttributes(RiskAttributes{Likelihood: e.Likelihood, Criticality: e.Criticality, DataClassification: e.DataClassification})
+	h.SetOwnership(Ownership{Owner: e.Owner, Team: e.Team, Contact: e.Contact})
+	h.SetTags(e.Tags)
 
 	if e.Base != nil && len(e.Base) > 0 {
 		for _, base := range e.Base {
@@ -0,0 +1,99 @@
+package objmodel
+
+import (
+	"addb"
+	"errors"
+	"securitymodel/yamlmodel"
+	"strings"
+)
+
+// Node of the deployment view (a zone, host, VM, cluster, namespace or
+// container). Programs deployed on a node inherit its ADM, along with ADM of
+// the nodes it is nested in.
+type Node struct {
+	CoreObject
+	nodeType yamlmodel.NodeType
+	parent   *Node
+	nodes    []*Node
+	entities []string // IDs of programs deployed directly on this node
+}
+
+// Initialize a node and the nodes nested in it. Relative ADM paths are
+// resolved against 'admDir', unless the node was read from a fragment.
+func (n *Node) Init(y *yamlmodel.Node, admDir string) []error {
+	if y == nil {
+		return []error{errors.New("cannot convert nil yaml to deployment node")}
+	}
+	if y.Id == "" {
+		return []error{errors.New("deployment node '" + y.Name + "' doesn't have an 'id'")}
+	}
+	if !contains(string(y.Type), yamlmodel.NodeTypes) {
+		return []error{errors.New("deployment node '" + y.Id + "' has unknown type '" + string(y.Type) + "'. Supported types - " + strings.Join(yamlmodel.NodeTypes, ", "))}
+	}
+	n.SetID(y.Id)
+	n.name = y.Name
+	if n.name == "" {
+		n.name = y.Id
+	}
+	n.description = y.Description
+	n.nodeType = y.Type
+	n.entities = y.Entities
+
+	if y.AdmDir != "" {
+		admDir = y.AdmDir
+	}
+	for _, adm := range y.ADM {
+		if !addb.IsInlineADM(adm) && admDir != "" {
+			adm = admDir + "/" + adm
+		}
+		n.adm = append(n.adm, adm)
+	}
+
+	var errs []error
+	for _, nested := range y.Nodes {
+		child := &Node{parent: n}
+		childErrs := child.Init(nested, admDir)
+		if len(childErrs) != 0 {
+			errs = append(errs, childErrs...)
+			continue
+		}
+		n.nodes = append(n.nodes, child)
+	}
+	return errs
+}
+
+func (n *Node) GetType() yamlmodel.NodeType {
+	return n.nodeType
+}
+
+// Node this node is nested in. Nil for top-level nodes.
+func (n *Node) GetParent() *Node {
+	return n.parent
+}
+
+func (n *Node) GetNodes() []*Node {
+	return n.nodes
+}
+
+// IDs of programs deployed directly on this node
+func (n *Node) GetEntities() []string {
+	return n.entities
+}
+
+func (n *Node) GetADM() (allADM map[string][]string) {
+	allADM = make(map[string][]string)
+	allADM[n.id] = n.adm
+	return
+}
+
+////////////////////////////////////////
+// Helper functions
+
+func contains(item string, list []string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
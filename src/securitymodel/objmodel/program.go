@@ -1,6 +1,7 @@
 package objmodel
 
 import (
+	"addb"
 	"errors"
 	"net/url"
 	"securitymodel/yamlmodel"
@@ -13,6 +14,7 @@ type Program struct {
 	roles          map[string]EntitySpec
 	languages      map[string]ProgramEntitySpec
 	dependencies   map[string]ProgramEntitySpec
+	node           *Node // deployment node this program runs on, if any
 }
 
 func (p *Program) Init(e *yamlmodel.Entity, r Resolver) []error {
@@ -41,7 +43,9 @@ func (p *Program) Init(e *yamlmodel.Entity, r Resolver) []error {
 
 	if e.AdmDir != "" {
 		for _, adm := range e.ADM {
-			adm = e.AdmDir + "/" + adm
+			if !addb.IsInlineADM(adm) {
+				adm = e.AdmDir + "/" + adm
+			}
 			p.AddADM(adm)
 		}
 	} else {
@@ -50,6 +54,9 @@ func (p *Program) Init(e *yamlmodel.Entity, r Resolver) []error {
 
 	p.SetMitigations(e.Mitigations)
 	p.SetRecommendations(e.Recommendations)
+	p.SetRiskAttributes(RiskAttributes{Likelihood: e.Likelihood, Criticality: e.Criticality, DataClassification: e.DataClassification})
+	p.SetOwnership(Ownership{Owner: e.Owner, Team: e.Team, Contact: e.Contact})
+	p.SetTags(e.Tags)
 
 	err = p.SetRepository(e.CodeRepository)
 	if err != nil {
@@ -142,10 +149,23 @@ func (p *Program) GetADM() (allADM map[string][]string) {
 			allADM = merge(allADM, p.id+".languages", l.GetADM())
 		}
 	}
+	for node := p.node; node != nil; node = node.GetParent() {
+		allADM = merge(allADM, p.id+".deployment", node.GetADM())
+	}
 
 	return
 }
 
+// Deployment node this program runs on. Nil if it is not deployed.
+func (p *Program) GetNode() *Node {
+	return p.node
+}
+
+func (p *Program) SetNode(node *Node) error {
+	p.node = node
+	return nil
+}
+
 func (p *Program) AddADM(adm string) error {
 	p.adm = append(p.adm, adm)
 	return nil
@@ -1,6 +1,7 @@
 package objmodel
 
 import (
+	"addb"
 	"errors"
 	"securitymodel/yamlmodel"
 )
@@ -35,7 +36,9 @@ func (rol *Role) Init(e *yamlmodel.Entity, r Resolver) []error {
 
 	if e.AdmDir != "" {
 		for _, adm := range e.ADM {
-			adm = e.AdmDir + "/" + adm
+			if !addb.IsInlineADM(adm) {
+				adm = e.AdmDir + "/" + adm
+			}
 			rol.AddADM(adm)
 		}
 	} else {
@@ -44,6 +47,7 @@ func (rol *Role) Init(e *yamlmodel.Entity, r Resolver) []error {
 
 	rol.SetMitigations(e.Mitigations)
 	rol.SetRecommendations(e.Recommendations)
+	rol.SetTags(e.Tags)
 
 	return errs
 }
@@ -1,7 +1,9 @@
 package objmodel
 
 import (
+	"addb"
 	"errors"
+	"path/filepath"
 	"securitymodel/yamlmodel"
 )
 
@@ -9,10 +11,16 @@ type SecurityModel struct {
 	Title          string
 	DesignDocument string
 	AddbPath       string
+	RiskOverlay    string // path to the risk overlay file, if any
+	addbUri        yamlmodel.AddbReference
 	modelADM       []string
 	Externals      map[string]ExternalSpec
 	Entities       map[string]EntitySpec
 	Flows          map[string]FlowSpec
+	SharedEntities map[string]yamlmodel.EntitySource // entities defined in other models, by their ID
+	Fragments      map[string]string                 // fragment files items were included from, by their ID
+	Deployment     []*Node                           // top-level nodes of the deployment view
+	FileID         string                            // base name of generated files, if not the ID of the title
 }
 
 // Collect all ADMs from program
@@ -36,6 +44,76 @@ func (t *SecurityModel) GetADM() (allADM map[string][]string) {
 	return
 }
 
+// Copy of the model with only the externals, entities and flows for which
+// 'keep' returns true. 'external' is set for external entities. ADM of the
+// model itself is left out, since it doesn't belong to any item. Senders and
+// receivers of the flows are not added (see 'WithFlowEndpoints').
+func (t SecurityModel) Select(keep func(item CoreSpec, external bool) bool) SecurityModel {
+	selected := t
+	selected.modelADM = nil
+	selected.Externals = make(map[string]ExternalSpec)
+	for id, e := range t.Externals {
+		if keep(e, true) {
+			selected.Externals[id] = e
+		}
+	}
+	selected.Entities = make(map[string]EntitySpec)
+	for id, e := range t.Entities {
+		if keep(e, false) {
+			selected.Entities[id] = e
+		}
+	}
+	selected.Flows = make(map[string]FlowSpec)
+	for id, f := range t.Flows {
+		if keep(f, false) {
+			selected.Flows[id] = f
+		}
+	}
+	return selected
+}
+
+// Copy of a selection (see 'Select') with the senders and receivers of its
+// flows added from 'full', so that diagrams don't draw flows to items outside
+// the model. Use it for diagrams only, since the added items bring their ADM
+// along.
+func (t SecurityModel) WithFlowEndpoints(full SecurityModel) SecurityModel {
+	connected := t
+	connected.Externals = make(map[string]ExternalSpec)
+	for id, e := range t.Externals {
+		connected.Externals[id] = e
+	}
+	connected.Entities = make(map[string]EntitySpec)
+	for id, e := range t.Entities {
+		connected.Entities[id] = e
+	}
+	for _, f := range t.Flows {
+		for _, endpoint := range []CoreSpec{f.GetSender(), f.GetReceiver()} {
+			if endpoint == nil {
+				continue
+			}
+			if e, found := full.Externals[endpoint.GetID()]; found {
+				connected.Externals[endpoint.GetID()] = e
+			} else if e, found := full.Entities[endpoint.GetID()]; found {
+				connected.Entities[endpoint.GetID()] = e
+			}
+		}
+	}
+	return connected
+}
+
+// All nodes of the deployment view, parents before the nodes nested in them
+func (t *SecurityModel) Nodes() (nodes []*Node) {
+	var walk func([]*Node)
+	walk = func(list []*Node) {
+		for _, n := range list {
+			nodes = append(nodes, n)
+			walk(n.GetNodes())
+		}
+	}
+	walk(t.Deployment)
+	return
+}
+
 // Collect all ADMs from program
 func (t *SecurityModel) SetADM(adm []string) {
 	t.modelADM = append(t.modelADM, adm...)
@@ -52,11 +130,20 @@ func (t *SecurityModel) Init(ysm *yamlmodel.SecurityModel, r Resolver) []error {
 
 	t.Title = ysm.Title
 	t.DesignDocument = ysm.DesignDocument
-	t.AddbPath = ysm.AddbUri
+	t.AddbPath = ysm.AddbUri.String()
+	t.addbUri = ysm.AddbUri
+	t.SharedEntities = ysm.SharedEntities
+	t.Fragments = ysm.Fragments
+	t.RiskOverlay = ysm.RiskOverlay
+	if t.RiskOverlay != "" && ysm.AdmDir != "" && !filepath.IsAbs(t.RiskOverlay) {
+		t.RiskOverlay = filepath.Join(ysm.AdmDir, t.RiskOverlay)
+	}
 
 	if ysm.AdmDir != "" {
 		for _, adm := range ysm.ModelADM {
-			adm = ysm.AdmDir + "/" + adm
+			if !addb.IsInlineADM(adm) {
+				adm = ysm.AdmDir + "/" + adm
+			}
 			t.modelADM = append(t.modelADM, adm)
 		}
 	} else {
@@ -76,6 +163,10 @@ func (t *SecurityModel) Init(ysm *yamlmodel.SecurityModel, r Resolver) []error {
 	if len(buildErrs) != 0 {
 		errs = append(errs, buildErrs...)
 	}
+	buildErrs = t.buildDeployment(ysm.Deployment, ysm.AdmDir)
+	if len(buildErrs) != 0 {
+		errs = append(errs, buildErrs...)
+	}
 
 	return errs
 }
@@ -179,3 +270,50 @@ func (t *SecurityModel) buildFlows(flows []*yamlmodel.Flow, r Resolver) []error
 	}
 	return errs
 }
+
+// Build deployment nodes and link programs to the nodes they run on
+func (t *SecurityModel) buildDeployment(nodes []*yamlmodel.Node, admDir string) []error {
+	var errs []error
+
+	t.Deployment = nil
+	for _, entry := range nodes {
+		if entry == nil {
+			continue
+		}
+		n := &Node{}
+		nodeErrs := n.Init(entry, admDir)
+		if len(nodeErrs) != 0 {
+			errs = append(errs, nodeErrs...)
+			continue
+		}
+		t.Deployment = append(t.Deployment, n)
+	}
+
+	ids := make(map[string]bool)
+	deployedOn := make(map[string]*Node) // by entity ID
+	for _, n := range t.Nodes() {
+		if ids[n.GetID()] {
+			errs = append(errs, errors.New("deployment node '"+n.GetID()+"' is defined more than once"))
+		}
+		ids[n.GetID()] = true
+		for _, id := range n.GetEntities() {
+			entity, found := t.Entities[id]
+			if !found {
+				errs = append(errs, errors.New("deployment node '"+n.GetID()+"' lists unknown entity '"+id+"'"))
+				continue
+			}
+			program, ok := entity.(*Program)
+			if !ok {
+				errs = append(errs, errors.New("deployment node '"+n.GetID()+"' lists '"+id+"', which is not a program"))
+				continue
+			}
+			if existing, deployed := deployedOn[id]; deployed {
+				errs = append(errs, errors.New("entity '"+id+"' is deployed on both '"+existing.GetID()+"' and '"+n.GetID()+"'"))
+				continue
+			}
+			deployedOn[id] = n
+			program.SetNode(n)
+		}
+	}
+	return errs
+}
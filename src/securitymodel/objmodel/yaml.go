@@ -0,0 +1,194 @@
+package objmodel
+
+import (
+	"addb"
+	"path/filepath"
+	"securitymodel/yamlmodel"
+	"sort"
+)
+
+// Convert the object model back into its YAML form, so it can be written out
+// with 'yamlmodel.Marshal' or 'yamlmodel.Update'. Paths to ADM files are made
+// relative to 'modelDir'. Items from fragments are part of the model, while
+// entities shared from other models are written as references ('ref').
+// Items that are not part of the model are referred to by their ADDB ID.
+// Includes, parameters and conditions are not retained, since they are
+// applied when the model is loaded. The result is marked as resolved, so that
+// 'yamlmodel.Update' refuses to write it over models that use them.
+func (t *SecurityModel) ToYaml(modelDir string) *yamlmodel.SecurityModel {
+	m := yamlmodel.SecurityModel{
+		Title:          t.Title,
+		DesignDocument: t.DesignDocument,
+		AddbUri:        t.addbUri,
+		ModelADM:       relativeADM(t.modelADM, modelDir),
+		RiskOverlay:    relativePath(t.RiskOverlay, modelDir),
+		Resolved:       true,
+	}
+	if len(m.ModelADM) == 0 {
+		m.ModelADM = nil
+	}
+
+	for _, id := range sortedKeys(t.Externals) {
+		m.Externals = append(m.Externals, t.externalToYaml(t.Externals[id]))
+	}
+	for _, id := range sortedKeys(t.Entities) {
+		if source, shared := t.SharedEntities[id]; shared {
+			m.Entities = append(m.Entities, sharedEntityToYaml(id, source, modelDir))
+			continue
+		}
+		m.Entities = append(m.Entities, t.entityToYaml(t.Entities[id], modelDir))
+	}
+	for _, id := range sortedKeys(t.Flows) {
+		m.Flows = append(m.Flows, t.flowToYaml(t.Flows[id], modelDir))
+	}
+	for _, n := range t.Deployment {
+		m.Deployment = append(m.Deployment, nodeToYaml(n, modelDir))
+	}
+	return &m
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func (t *SecurityModel) externalToYaml(e ExternalSpec) *yamlmodel.Entity {
+	entity := yamlmodel.Entity{Id: e.GetID(), Name: e.GetName(), Description: e.GetDescription(), Tags: e.GetTags()}
+	switch obj := e.(type) {
+	case *Human:
+		entity.Type = yamlmodel.Human
+		if obj.userInterface != nil {
+			entity.Interface = t.reference(obj.userInterface)
+		}
+	case *Program:
+		entity.Type = yamlmodel.Program
+		entity.Roles = sortedKeys(obj.roles)
+	}
+	return &entity
+}
+
+func (t *SecurityModel) entityToYaml(e EntitySpec, modelDir string) *yamlmodel.Entity {
+	entity := yamlmodel.Entity{Id: e.GetID(), Name: e.GetName(), Description: e.GetDescription(), Tags: e.GetTags()}
+	risk := e.GetRiskAttributes()
+	entity.Likelihood, entity.Criticality, entity.DataClassification = risk.Likelihood, risk.Criticality, risk.DataClassification
+	ownership := e.GetOwnership()
+	entity.Owner, entity.Team, entity.Contact = ownership.Owner, ownership.Team, ownership.Contact
+	switch obj := e.(type) {
+	case *Human:
+		entity.Type = yamlmodel.Human
+		entity.Mitigations, entity.Recommendations = obj.mitigations, obj.recommendations
+		entity.ADM = relativeADM(obj.adm, modelDir)
+		for _, base := range obj.base {
+			entity.Base = append(entity.Base, t.reference(base))
+		}
+		if obj.userInterface != nil {
+			entity.Interface = t.reference(obj.userInterface)
+		}
+	case *Program:
+		entity.Type = yamlmodel.Program
+		entity.Mitigations, entity.Recommendations = obj.mitigations, obj.recommendations
+		entity.ADM = relativeADM(obj.adm, modelDir)
+		entity.CodeRepository = obj.codeRepository
+		for _, base := range obj.base {
+			entity.Base = append(entity.Base, t.reference(base))
+		}
+		entity.Languages = sortedKeys(obj.languages)
+		for _, id := range sortedKeys(obj.dependencies) { // listed by ID, not reference
+			entity.Dependencies = append(entity.Dependencies, t.reference(obj.dependencies[id]))
+		}
+		entity.Roles = sortedKeys(obj.roles)
+	case *Role:
+		entity.Type = yamlmodel.Role
+		entity.Mitigations, entity.Recommendations = obj.mitigations, obj.recommendations
+		entity.ADM = relativeADM(obj.adm, modelDir)
+	}
+	return &entity
+}
+
+func sharedEntityToYaml(id string, source yamlmodel.EntitySource, modelDir string) *yamlmodel.Entity {
+	path := source.Path
+	if rel, err := filepath.Rel(modelDir, path); err == nil {
+		path = rel
+	}
+	entity := yamlmodel.Entity{Ref: path + "#" + source.Id}
+	if id != source.Id {
+		entity.Id = id
+	}
+	return &entity
+}
+
+func (t *SecurityModel) flowToYaml(f FlowSpec, modelDir string) *yamlmodel.Flow {
+	flow := yamlmodel.Flow{Id: f.GetID(), Name: f.GetName(), Description: f.GetDescription(), Tags: f.GetTags()}
+	risk := f.GetRiskAttributes()
+	flow.Likelihood, flow.Criticality, flow.DataClassification = risk.Likelihood, risk.Criticality, risk.DataClassification
+	ownership := f.GetOwnership()
+	flow.Owner, flow.Team, flow.Contact = ownership.Owner, ownership.Team, ownership.Contact
+	if obj, ok := f.(*Flow); ok {
+		flow.Mitigations, flow.Recommendations = obj.mitigations, obj.recommendations
+		flow.ADM = relativeADM(obj.adm, modelDir)
+		flow.Protocol = sortedKeys(obj.protocol)
+		if obj.sender != nil {
+			flow.Sender = t.reference(obj.sender)
+		}
+		if obj.receiver != nil {
+			flow.Receiver = t.reference(obj.receiver)
+		}
+	}
+	return &flow
+}
+
+func nodeToYaml(n *Node, modelDir string) *yamlmodel.Node {
+	node := yamlmodel.Node{Id: n.GetID(), Type: n.GetType(), Name: n.GetName(), Description: n.GetDescription(),
+		Entities: n.GetEntities(), ADM: relativeADM(n.adm, modelDir)}
+	if len(node.ADM) == 0 {
+		node.ADM = nil
+	}
+	for _, nested := range n.GetNodes() {
+		node.Nodes = append(node.Nodes, nodeToYaml(nested, modelDir))
+	}
+	return &node
+}
+
+// ID used to refer to an item. Items that are not part of the model are from ADDB.
+func (t *SecurityModel) reference(item CoreSpec) string {
+	id := item.GetID()
+	if _, found := t.Entities[id]; found {
+		return id
+	}
+	if _, found := t.Externals[id]; found {
+		return id
+	}
+	if _, found := t.Flows[id]; found {
+		return id
+	}
+	return "addb:" + id
+}
+
+// ADM paths relative to the model's directory. Never nil, since 'adm' is
+// mandatory for most items.
+func relativeADM(adm []string, modelDir string) []string {
+	relative := []string{}
+	for _, path := range adm {
+		if !addb.IsInlineADM(path) {
+			path = relativePath(path, modelDir)
+		}
+		relative = append(relative, path)
+	}
+	return relative
+}
+
+// Path relative to the model's directory
+func relativePath(path string, modelDir string) string {
+	if path != "" && filepath.IsAbs(path) == filepath.IsAbs(modelDir) {
+		if rel, err := filepath.Rel(modelDir, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+func sortedKeys[V any](items map[string]V) (keys []string) {
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,153 @@
+// Subset of the OSCAL System Security Plan (SSP) model used to export security
+// models. See https://pages.nist.gov/OSCAL/reference/latest/system-security-plan/json-outline/
+package oscal
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// OSCAL version exported documents conform to
+const Version = "1.1.2"
+
+// Namespace of properties that are specific to ADSM
+const Namespace = "https://github.com/vinayprograms/adsm/ns/oscal"
+
+type Document struct {
+	SSP SystemSecurityPlan `json:"system-security-plan"`
+}
+
+type SystemSecurityPlan struct {
+	UUID                  string                `json:"uuid"`
+	Metadata              Metadata              `json:"metadata"`
+	ImportProfile         ImportProfile         `json:"import-profile"`
+	SystemCharacteristics SystemCharacteristics `json:"system-characteristics"`
+	SystemImplementation  SystemImplementation  `json:"system-implementation"`
+	ControlImplementation ControlImplementation `json:"control-implementation"`
+}
+
+type Metadata struct {
+	Title        string `json:"title"`
+	LastModified string `json:"last-modified"`
+	Version      string `json:"version"`
+	OSCALVersion string `json:"oscal-version"`
+}
+
+type ImportProfile struct {
+	Href string `json:"href"`
+}
+
+type SystemCharacteristics struct {
+	SystemIds             []SystemId            `json:"system-ids"`
+	SystemName            string                `json:"system-name"`
+	Description           string                `json:"description"`
+	SystemInformation     SystemInformation     `json:"system-information"`
+	Status                Status                `json:"status"`
+	AuthorizationBoundary AuthorizationBoundary `json:"authorization-boundary"`
+}
+
+type SystemId struct {
+	IdentifierType string `json:"identifier-type"`
+	Id             string `json:"id"`
+}
+
+type SystemInformation struct {
+	InformationTypes []InformationType `json:"information-types"`
+}
+
+type InformationType struct {
+	UUID                  string `json:"uuid"`
+	Title                 string `json:"title"`
+	Description           string `json:"description"`
+	ConfidentialityImpact Impact `json:"confidentiality-impact"`
+	IntegrityImpact       Impact `json:"integrity-impact"`
+	AvailabilityImpact    Impact `json:"availability-impact"`
+}
+
+type Impact struct {
+	Base string `json:"base"`
+}
+
+type Status struct {
+	State string `json:"state"`
+}
+
+type AuthorizationBoundary struct {
+	Description string `json:"description"`
+}
+
+type SystemImplementation struct {
+	Users      []User      `json:"users"`
+	Components []Component `json:"components"`
+}
+
+type User struct {
+	UUID        string     `json:"uuid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Props       []Property `json:"props,omitempty"`
+}
+
+type Component struct {
+	UUID        string     `json:"uuid"`
+	Type        string     `json:"type"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Props       []Property `json:"props,omitempty"`
+	Links       []Link     `json:"links,omitempty"`
+	Status      Status     `json:"status"`
+	Protocols   []Protocol `json:"protocols,omitempty"`
+}
+
+type Property struct {
+	Name  string `json:"name"`
+	Ns    string `json:"ns,omitempty"`
+	Value string `json:"value"`
+}
+
+type Link struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel,omitempty"`
+}
+
+type Protocol struct {
+	UUID  string `json:"uuid"`
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+}
+
+type ControlImplementation struct {
+	Description             string                   `json:"description"`
+	ImplementedRequirements []ImplementedRequirement `json:"implemented-requirements"`
+}
+
+type ImplementedRequirement struct {
+	UUID         string        `json:"uuid"`
+	ControlId    string        `json:"control-id"`
+	Props        []Property    `json:"props,omitempty"`
+	ByComponents []ByComponent `json:"by-components"`
+}
+
+type ByComponent struct {
+	ComponentUUID string `json:"component-uuid"`
+	UUID          string `json:"uuid"`
+	Description   string `json:"description"`
+}
+
+// Name-based (version 5) UUID of 'name' within 'namespace'. Exporting a model
+// again generates the same UUIDs, so that exported documents can be compared.
+func UUID(namespace string, name string) string {
+	hash := sha1.Sum([]byte(namespace + "\x00" + name))
+	hash[6] = (hash[6] & 0x0f) | 0x50 // version 5
+	hash[8] = (hash[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
+
+// OSCAL token for a control ID - lower case, with enhancements written as
+// '.n' (NIST 800-53 'AC-2(1)' is 'ac-2.1').
+func ControlId(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	id = strings.ReplaceAll(id, "(", ".")
+	return strings.ReplaceAll(id, ")", "")
+}
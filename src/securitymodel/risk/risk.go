@@ -0,0 +1,147 @@
+package risk
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"schema"
+)
+
+// Likelihood, impact and criticality are rated on the same scale
+type Level int
+
+const (
+	Unknown Level = iota
+	Low
+	Medium
+	High
+	Critical
+)
+
+// Names of levels, from lowest to highest
+var Levels = []string{"low", "medium", "high", "critical"}
+
+// Data classifications, from least to most sensitive. Each maps to the level
+// at the same position in 'Levels'.
+var DataClassifications = []string{"public", "internal", "confidential", "restricted"}
+
+// Level used when neither the attack nor the entity/flow it is listed under
+// has a value
+const Default = Medium
+
+// Prefixes of ADM tags that rate attacks, like '@likelihood:high'
+const (
+	LikelihoodTag = "@likelihood:"
+	ImpactTag     = "@impact:"
+)
+
+// Likelihood and impact of an attack in a risk overlay file. Applies to all
+// entities/flows the attack is listed under, unless 'location' (ID of an
+// entity or flow) is set.
+type Estimate struct {
+	Attack     string `yaml:"attack"`
+	Location   string `yaml:"location"`
+	Likelihood string `yaml:"likelihood"`
+	Impact     string `yaml:"impact"`
+}
+
+func ParseLevel(value string) (Level, error) {
+	for i, name := range Levels {
+		if strings.EqualFold(name, value) {
+			return Level(i + 1), nil
+		}
+	}
+	return Unknown, errors.New("unknown level '" + value + "'. Supported levels - " + strings.Join(Levels, ", "))
+}
+
+func (l Level) String() string {
+	if l == Unknown {
+		return "unknown"
+	}
+	return Levels[l-1]
+}
+
+// Impact of compromising data with classification 'classification'
+func ClassificationImpact(classification string) Level {
+	for i, name := range DataClassifications {
+		if strings.EqualFold(name, classification) {
+			return Level(i + 1)
+		}
+	}
+	return Unknown
+}
+
+// Impact of an attack on an entity/flow, from its criticality and the
+// classification of data it handles, whichever is higher.
+func ElementImpact(criticality string, classification string) Level {
+	impact, _ := ParseLevel(criticality)
+	if c := ClassificationImpact(classification); c > impact {
+		impact = c
+	}
+	return impact
+}
+
+// Risk score - likelihood × impact, from 1 to 16
+func Score(likelihood Level, impact Level) int {
+	return int(likelihood) * int(impact)
+}
+
+// Severity of a risk score
+func Severity(score int) Level {
+	switch {
+	case score >= 9:
+		return Critical
+	case score >= 6:
+		return High
+	case score >= 3:
+		return Medium
+	}
+	return Low
+}
+
+// Likelihood and impact from ADM tags of an attack. Levels that are not
+// tagged are 'Unknown'.
+func FromTags(tags []string) (likelihood Level, impact Level, err error) {
+	for _, tag := range tags {
+		var level *Level
+		value := strings.ToLower(tag)
+		if strings.HasPrefix(value, LikelihoodTag) {
+			level, value = &likelihood, strings.TrimPrefix(value, LikelihoodTag)
+		} else if strings.HasPrefix(value, ImpactTag) {
+			level, value = &impact, strings.TrimPrefix(value, ImpactTag)
+		} else {
+			continue
+		}
+		parsed, parseErr := ParseLevel(value)
+		if parseErr != nil {
+			err = errors.New("invalid tag '" + tag + "' - " + parseErr.Error())
+			continue
+		}
+		*level = parsed
+	}
+	return
+}
+
+// Read estimates from a risk overlay file
+func LoadOverlay(path string) ([]Estimate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var estimates []Estimate
+	if err := schema.Unmarshal(content, &estimates); err != nil {
+		return nil, errors.New("invalid risk overlay '" + path + "' - " + err.Error())
+	}
+	for _, e := range estimates {
+		if e.Attack == "" || (e.Likelihood == "" && e.Impact == "") {
+			return nil, errors.New("risk overlay '" + path + "' contains an entry without 'attack' or without one of 'likelihood' and 'impact'")
+		}
+		for _, value := range []string{e.Likelihood, e.Impact} {
+			if _, err := ParseLevel(value); value != "" && err != nil {
+				return nil, errors.New("risk overlay '" + path + "' rates '" + e.Attack + "' with " + err.Error())
+			}
+		}
+	}
+	return estimates, nil
+}
@@ -0,0 +1,67 @@
+// Subset of the SARIF (Static Analysis Results Interchange Format) log used to
+// export risks of security models. See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+package sarif
+
+// SARIF version exported logs conform to
+const Version = "2.1.0"
+
+// JSON schema of SARIF logs
+const Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string `json:"name"`
+	InformationUri string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Id               string  `json:"id"`
+	Name             string  `json:"name,omitempty"`
+	ShortDescription Message `json:"shortDescription"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleId     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    Message                `json:"message"`
+	Locations  []Location             `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+}
+
+type ArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type LogicalLocation struct {
+	Name               string `json:"name,omitempty"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+}
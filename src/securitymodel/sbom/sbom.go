@@ -0,0 +1,225 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+
+	"addb"
+)
+
+// A software component listed in an SBOM.
+type Component struct {
+	Name    string
+	Version string
+	Purl    string
+}
+
+// Readable identifier for a component. Prefers package-URL if available.
+func (c Component) String() string {
+	if c.Purl != "" {
+		return c.Purl
+	}
+	if c.Version != "" {
+		return c.Name + "@" + c.Version
+	}
+	return c.Name
+}
+
+// Load components from a CycloneDX (JSON/XML) or SPDX (JSON/tag-value) SBOM file.
+func Load(path string) ([]Component, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	components, err := Parse(content)
+	if err != nil {
+		return nil, errors.New("cannot read SBOM '" + path + "' - " + err.Error())
+	}
+	return components, nil
+}
+
+// Parse SBOM content. The format is detected from the content.
+func Parse(content []byte) ([]Component, error) {
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var header struct {
+			BomFormat   string `json:"bomFormat"`
+			SpdxVersion string `json:"spdxVersion"`
+		}
+		if err := json.Unmarshal(trimmed, &header); err != nil {
+			return nil, err
+		}
+		if header.BomFormat == "CycloneDX" {
+			return parseCycloneDXJson(trimmed)
+		} else if header.SpdxVersion != "" {
+			return parseSPDXJson(trimmed)
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCycloneDXXml(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("SPDXVersion:")):
+		return parseSPDXTagValue(trimmed)
+	}
+	return nil, errors.New("unsupported SBOM format. Supported formats are CycloneDX (JSON/XML) and SPDX (JSON/tag-value)")
+}
+
+// Map components to ADDB entries using the SBOM mapping table in ADDB. Returns
+// IDs (with 'addb:' prefix) of mapped entries and components without an entry.
+func MapComponents(components []Component, db *addb.ADDB) (ids []string, unmapped []Component) {
+	seen := make(map[string]bool)
+	for _, c := range components {
+		id, found := db.MapComponent(c.Purl, c.Name, c.Version)
+		if !found {
+			unmapped = append(unmapped, c)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, "addb:"+id)
+		}
+	}
+	return
+}
+
+////////////////////////////////////////
+// Internal functions
+
+type cycloneDXComponent struct {
+	Name       string               `json:"name" xml:"name"`
+	Version    string               `json:"version" xml:"version"`
+	Purl       string               `json:"purl" xml:"purl"`
+	Components []cycloneDXComponent `json:"components" xml:"components>component"`
+}
+
+func parseCycloneDXJson(content []byte) ([]Component, error) {
+	var bom struct {
+		Components []cycloneDXComponent `json:"components"`
+	}
+	if err := json.Unmarshal(content, &bom); err != nil {
+		return nil, err
+	}
+	return flattenCycloneDX(bom.Components), nil
+}
+
+func parseCycloneDXXml(content []byte) ([]Component, error) {
+	var bom struct {
+		XMLName    xml.Name
+		Components []cycloneDXComponent `xml:"components>component"`
+	}
+	if err := xml.Unmarshal(content, &bom); err != nil {
+		return nil, err
+	}
+	if bom.XMLName.Local != "bom" {
+		return nil, errors.New("expected a CycloneDX 'bom' document")
+	}
+	return flattenCycloneDX(bom.Components), nil
+}
+
+// CycloneDX components can contain sub-components.
+func flattenCycloneDX(components []cycloneDXComponent) (flat []Component) {
+	for _, c := range components {
+		flat = append(flat, Component{Name: c.Name, Version: c.Version, Purl: c.Purl})
+		flat = append(flat, flattenCycloneDX(c.Components)...)
+	}
+	return
+}
+
+func parseSPDXJson(content []byte) ([]Component, error) {
+	var doc struct {
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID       string `json:"SPDXID"`
+			Name         string `json:"name"`
+			VersionInfo  string `json:"versionInfo"`
+			ExternalRefs []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	var components []Component
+	for _, pkg := range doc.Packages {
+		if contains(pkg.SPDXID, doc.DocumentDescribes) { // the product described by the SBOM
+			continue
+		}
+		c := Component{Name: pkg.Name, Version: pkg.VersionInfo}
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				c.Purl = ref.ReferenceLocator
+			}
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+func parseSPDXTagValue(content []byte) ([]Component, error) {
+	var components []Component
+	var describes []string
+	var ids []string
+	current := -1 // package whose section is being read
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		tag, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(tag) {
+		case "PackageName":
+			components = append(components, Component{Name: value})
+			ids = append(ids, "")
+			current = len(components) - 1
+		case "FileName", "SnippetSPDXID", "LicenseID": // sections that are not packages
+			current = -1
+		case "SPDXID":
+			if current >= 0 {
+				ids[current] = value
+			}
+		case "PackageVersion":
+			if current >= 0 {
+				components[current].Version = value
+			}
+		case "ExternalRef":
+			fields := strings.Fields(value)
+			if current >= 0 && len(fields) == 3 && fields[1] == "purl" {
+				components[current].Purl = fields[2]
+			}
+		case "Relationship":
+			fields := strings.Fields(value)
+			if len(fields) == 3 && fields[1] == "DESCRIBES" {
+				describes = append(describes, fields[2])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var filtered []Component
+	for i, c := range components {
+		if !contains(ids[i], describes) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+func contains(item string, list []string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"securitymodel/sbom"
+)
+
+// Languages and dependencies found in a code repository.
+type Result struct {
+	Languages  []string
+	Components []sbom.Component
+}
+
+// Directories that are never scanned. They contain third-party or generated code.
+var skippedDirectories = []string{"node_modules", "vendor", "target", "build", "dist", "__pycache__"}
+
+// Inspect build manifests (go.mod, package.json, requirements.txt, pom.xml,
+// Dockerfile) in a local repository and its sub-directories.
+func Scan(repoPath string) (*Result, error) {
+	info, err := os.Stat(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &os.PathError{Op: "scan", Path: repoPath, Err: os.ErrInvalid}
+	}
+
+	var r Result
+	languages := make(map[string]bool)
+	seen := make(map[string]bool)
+	err = filepath.WalkDir(repoPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != repoPath && (strings.HasPrefix(d.Name(), ".") || contains(d.Name(), skippedDirectories)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var language string
+		var components []sbom.Component
+		switch name := d.Name(); {
+		case name == "go.mod":
+			language = "go"
+			components, err = scanGoMod(path)
+		case name == "package.json":
+			language = "javascript"
+			components, err = scanPackageJson(path)
+		case name == "tsconfig.json":
+			language = "typescript"
+		case name == "requirements.txt":
+			language = "python"
+			components, err = scanRequirements(path)
+		case name == "pom.xml":
+			language = "java"
+			components, err = scanPom(path)
+		case name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".dockerfile"):
+			language = "dockerfile"
+			components, err = scanDockerfile(path)
+		}
+		if err != nil {
+			return err
+		}
+		if language != "" {
+			languages[language] = true
+		}
+		for _, c := range components {
+			if !seen[c.String()] {
+				seen[c.String()] = true
+				r.Components = append(r.Components, c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for language := range languages {
+		r.Languages = append(r.Languages, language)
+	}
+	sort.Strings(r.Languages)
+	return &r, nil
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// Direct requirements from a go.mod file. Indirect ones are skipped.
+func scanGoMod(path string) ([]sbom.Component, error) {
+	var components []sbom.Component
+	err := scanLines(path, func(line string, block string) string {
+		if strings.HasPrefix(line, "require (") {
+			return "require"
+		} else if line == ")" {
+			return ""
+		}
+		if strings.HasSuffix(line, "// indirect") {
+			return block
+		}
+		if block == "require" || strings.HasPrefix(line, "require ") {
+			fields := strings.Fields(strings.TrimPrefix(line, "require "))
+			if len(fields) >= 2 {
+				components = append(components, sbom.Component{Name: fields[0], Version: fields[1], Purl: "pkg:golang/" + fields[0] + "@" + fields[1]})
+			}
+		}
+		return block
+	})
+	return components, err
+}
+
+func scanPackageJson(path string) ([]sbom.Component, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, &os.PathError{Op: "scan", Path: path, Err: err}
+	}
+
+	var names []string
+	for name := range pkg.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var components []sbom.Component
+	for _, name := range names {
+		version := strings.TrimLeft(pkg.Dependencies[name], "^~=v ")
+		purl := "pkg:npm/" + strings.Replace(name, "@", "%40", 1)
+		if isExactVersion(version) {
+			purl += "@" + version
+		} else {
+			version = ""
+		}
+		components = append(components, sbom.Component{Name: name, Version: version, Purl: purl})
+	}
+	return components, nil
+}
+
+func scanRequirements(path string) ([]sbom.Component, error) {
+	var components []sbom.Component
+	err := scanLines(path, func(line string, _ string) string {
+		line = strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		if line == "" || strings.HasPrefix(line, "-") {
+			return ""
+		}
+		line = strings.SplitN(line, ";", 2)[0] // environment markers
+		name, version := line, ""
+		if i := strings.IndexAny(line, "=<>!~[ "); i >= 0 {
+			name = line[:i]
+			if _, v, found := strings.Cut(line, "=="); found {
+				version = strings.TrimSpace(v)
+			}
+		}
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+		purl := "pkg:pypi/" + name
+		if version != "" {
+			purl += "@" + version
+		}
+		components = append(components, sbom.Component{Name: name, Version: version, Purl: purl})
+		return ""
+	})
+	return components, err
+}
+
+func scanPom(path string) ([]sbom.Component, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pom struct {
+		Dependencies []struct {
+			GroupId    string `xml:"groupId"`
+			ArtifactId string `xml:"artifactId"`
+			Version    string `xml:"version"`
+		} `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil, &os.PathError{Op: "scan", Path: path, Err: err}
+	}
+
+	var components []sbom.Component
+	for _, dep := range pom.Dependencies {
+		c := sbom.Component{Name: dep.GroupId + ":" + dep.ArtifactId, Purl: "pkg:maven/" + dep.GroupId + "/" + dep.ArtifactId}
+		if dep.Version != "" && !strings.Contains(dep.Version, "${") { // properties are not resolved
+			c.Version = dep.Version
+			c.Purl += "@" + dep.Version
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+// Base images from 'FROM' instructions. References to earlier build stages are skipped.
+func scanDockerfile(path string) ([]sbom.Component, error) {
+	var components []sbom.Component
+	var stages []string
+	err := scanLines(path, func(line string, _ string) string {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			return ""
+		}
+		image := fields[1]
+		if strings.HasPrefix(image, "--") && len(fields) > 2 { // '--platform=...'
+			image = fields[2]
+		}
+		if len(fields) >= 4 && strings.EqualFold(fields[len(fields)-2], "AS") {
+			stages = append(stages, fields[len(fields)-1])
+		}
+		if image == "scratch" || strings.Contains(image, "$") || contains(image, stages) {
+			return ""
+		}
+
+		name, version := image, ""
+		if i := strings.Index(image, "@"); i >= 0 { // digest
+			name, version = image[:i], image[i+1:]
+		} else if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+			name, version = image[:i], image[i+1:]
+		}
+		purl := "pkg:docker/" + name
+		if version != "" {
+			purl += "@" + version
+		}
+		components = append(components, sbom.Component{Name: name, Version: version, Purl: purl})
+		return ""
+	})
+	return components, err
+}
+
+// Call 'process' for each trimmed, non-empty line. 'process' returns the
+// block (if any) that following lines belong to.
+func scanLines(path string, process func(line string, block string) string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	block := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		block = process(line, block)
+	}
+	return scanner.Err()
+}
+
+// Version ranges ('1.x', '>=2.0 <3', '*', etc.) can't be mapped to a single version.
+func isExactVersion(version string) bool {
+	return version != "" && !strings.ContainsAny(version, "xX*<>| ")
+}
+
+func contains(item string, list []string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package schemagen
+
+import (
+	"securitymodel/risk"
+	"securitymodel/yamlmodel"
+)
+
+// Descriptions of items and their fields. A field is looked up as
+// '<kind>.<field>' first, followed by '<field>'. '{item}' is replaced by the
+// kind of item (like 'human' or 'flow').
+var descriptions = map[string]string{
+	// Security model
+	"model":                 "YAML schema for specifying a security model. Utilizes attack/defense modeling as a means to capture security analysis. Security model items are grouped into externals, entities and flows that capture all the interacting components of the system.",
+	"model.title":           "Title for the security model.",
+	"model.design-document": "Path to the design document containing information about all the model items.",
+	"model.addb":            "Location of attack-defense database. Model items under 'base' or 'components' are looked up if not defined in this model. ADM files that reference attacks/defenses from ADDB will also be sourced from this location.",
+	"model.adm":             "Attacks and defenses spanning the entire model. You can capture kill-chains and mitigation-chains here.",
+	"model.include":         "smspec fragments (relative to this file) containing externals, entities, flows and ADM that are part of this model. Can contain glob patterns.",
+	"model.externals":       "List of entities external to this model. They interact with the system captured in this model. Analysis of externals is out-of-scope for this model. Behaviour of external entities cannot be controlled.",
+	"model.entities":        "List of entities participating in this model. These entities must map to those discussed in the design document.",
+	"model.flows":           "List of data flows between participating entities (including external ones).",
+	"model.risk-overlay":    "Path (relative to this model) to a risk overlay file, rating likelihood and impact of attacks. Ratings in the overlay take precedence over '@likelihood:' and '@impact:' tags in ADM.",
+	"model.deployment":      "Deployment view of the model - zones, hosts, VMs, clusters, namespaces and containers, along with the entities running on them.",
+	"model.parameters":      "Parameters used in '${NAME}' variables and 'when' conditions, along with their default values. Defaults can be overridden by environment variables or by passing '--set NAME=VALUE' to adsm.",
+
+	// Kinds of items
+	"external human":   "A human user (external) interacting with the system",
+	"external program": "A program (external) interacting with the system",
+	"human":            "A human who is part of the system",
+	"program":          "A program that is part of the system",
+	"role":             "Role played by an entity when interacting with others.",
+	"flow":             "A data flow between two entities",
+	"reference":        "An entity defined in another security model",
+	"node":             "Infrastructure that entities are deployed on. Attacks and defenses of a node apply to all entities deployed on it and on nodes nested in it.",
+	"addb.human":       "Specification about a human",
+	"addb.program":     "Specification about a program",
+	"addb.role":        "Specification about a role",
+	"addb.flow":        "A flow connecting humans/entities",
+
+	// Fields
+	"id":                  "Unique identifier for this {item}.",
+	"reference.id":        "ID of the entity in this model. Defaults to the ID in the referenced model.",
+	"reference.ref":       "Path to the smspec file defining the entity (relative to this file), followed by '#' and the entity's ID.",
+	"type":                "Type of this {item}.",
+	"name":                "A short title for this {item}.",
+	"description":         "Short description about this {item}.",
+	"design-document":     "Path to the design document describing this {item}.",
+	"base":                "Base {item} specifications from which additional properties are inherited. You can inherit more than one base for this {item}.",
+	"mitigations":         "A freeform, adhoc list of security mitigations currently implemented by this {item}.",
+	"recommendations":     "A freeform, adhoc list of security recommendations for this {item}. NOTE: Use this only when a formal set of attacks and defenses cannot be defined for this {item}.",
+	"adm":                 "List of attack/defense specifications for this {item}. Attacks are directed towards this {item} and defenses are controls implemented by this {item} to block/mitigate attacks.\n\nEach item in the list is either a path to an '.adm' file, relative to the file containing this specification, or ADM content written inline (usually as a YAML literal string) with 'Model', 'Attack' or 'Defense' sections.",
+	"interface":           "Program used by the human to interact with the system (for example, a browser).",
+	"roles":               "Access-control roles played by this program when interacting with other programs/systems. Each role is a separate specification.",
+	"repo":                "Path to the code repository of this program.",
+	"icon":                "Image used to represent this program.",
+	"languages":           "Programming language(s) this program is written in.",
+	"dependencies":        "Packages/Libraries imported-by/included-in this program.",
+	"sbom":                "Path (relative to this model) to a CycloneDX or SPDX SBOM. Components mapped to ADDB entries are added to 'dependencies'.",
+	"protocol":            "Communication protocol stack used in this flow. A single entry indicates the underlying protocol used in the flow. A list of protocols implies a protocol stack, ordered from top (application layer) to bottom (physical-layer).",
+	"sender":              "Entity/Human initiating this flow",
+	"receiver":            "Entity/Human that is the target of this flow",
+	"likelihood":          "Likelihood of attacks on this {item} succeeding. Used to rate risks of attacks that don't specify their likelihood.",
+	"criticality":         "Impact of compromising this {item} on the business. Used to rate risks of attacks that don't specify their impact.",
+	"data-classification": "Classification of the most sensitive data handled by this {item}. Used like 'criticality' ('public' is 'low' and 'restricted' is 'critical'), whichever is higher.",
+	"tags":                "Labels of this {item}, like 'pci' or 'internet-facing'. Used to select items with '-filter' expressions (like 'tag:pci').",
+	"owner":               "Person responsible for this {item}. Reports group unmitigated risks by owner (or 'team', if there is no owner).",
+	"team":                "Team responsible for this {item}.",
+	"contact":             "How to reach the owner/team of this {item}, like an email address or a chat channel.",
+	"node.entities":       "IDs of programs running on this node.",
+	"node.nodes":          "Nodes nested in this node, like namespaces in a cluster or containers on a host.",
+	"node.adm":            "List of attack/defense specifications for this node, like container escapes or host compromise. They apply to all entities deployed on this node and on nodes nested in it.\n\nEach item in the list is either a path to an '.adm' file, relative to the file containing this specification, or ADM content written inline.",
+	"when":                "Condition on parameters, like 'env == prod' or 'env != dev && region == eu'. This {item} is left out of the model if the condition doesn't hold.",
+}
+
+// Values allowed for string fields
+var enums = map[string][]string{
+	"likelihood":          risk.Levels,
+	"criticality":         risk.Levels,
+	"data-classification": risk.DataClassifications,
+}
+
+// Patterns that items of list fields must match. Items of 'adm' are either
+// paths to '.adm' files or inline ADM content.
+var itemPatterns = map[string]string{
+	"adm": `\.adm$|(^|\n)\s*(Model|Attack|Defense):`,
+}
+
+// Patterns that string fields of an item must match
+var patterns = map[string]string{
+	"reference.ref": "^.+#.+$",
+}
+
+// Mandatory fields of each kind of item
+var required = map[string][]string{
+	"model":            {"title"},
+	"external human":   {"id", "type", "name", "description"},
+	"external program": {"id", "type", "name", "description"},
+	"human":            {"id", "type", "name", "description", "adm"},
+	"program":          {"id", "type", "name", "description", "adm"},
+	"role":             {"id", "type", "name", "description", "adm"},
+	"flow":             {"id", "name", "description", "sender", "receiver", "adm"},
+	"reference":        {"ref"},
+	"addb.human":       {"id", "type", "name", "description", "adm"},
+	"addb.program":     {"id", "type", "name", "description", "adm"},
+	"addb.role":        {"id", "type", "name", "description", "adm"},
+	"addb.flow":        {"id", "type", "name", "description", "adm"},
+	"node":             {"id", "type", "name"},
+}
+
+// Values of 'type' for each kind of item
+var types = map[string][]string{
+	"human":   {"human"},
+	"program": {"program", "system"},
+	"role":    {"role"},
+	"flow":    {"flow"},
+	"node":    yamlmodel.NodeTypes,
+}
@@ -0,0 +1,90 @@
+package schemagen
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSON object that keeps its members in the order they were added, so that
+// generated schemas follow the order of fields in Go types.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]interface{})}
+}
+
+func (o *object) set(key string, value interface{}) *object {
+	if _, found := o.values[key]; !found {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+	return o
+}
+
+// Set a member of a nested object, creating intermediate objects as required.
+func (o *object) setPath(path []string, value interface{}) *object {
+	if len(path) == 1 {
+		return o.set(path[0], value)
+	}
+	child, ok := o.values[path[0]].(*object)
+	if !ok {
+		child = newObject()
+		o.set(path[0], child)
+	}
+	child.setPath(path[1:], value)
+	return o
+}
+
+func (o *object) get(key string) interface{} {
+	return o.values[key]
+}
+
+func (o *object) has(key string) bool {
+	_, found := o.values[key]
+	return found
+}
+
+// Add all members of 'other' to this object
+func (o *object) merge(other *object) *object {
+	for _, key := range other.keys {
+		o.set(key, other.values[key])
+	}
+	return o
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Like 'json.Marshal', without escaping HTML characters
+func marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,260 @@
+package schemagen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"addb"
+	"securitymodel/yamlmodel"
+)
+
+const (
+	ModelSchemaFile     = "model-schema.json"
+	ComponentSchemaFile = "component-schema.json"
+)
+
+// Kinds of ADDB entries, in the order they are listed in the component schema
+var componentKinds = []string{"human", "program", "role", "flow"}
+
+// Generate the schema for security models ('model-schema.json') from
+// 'yamlmodel' types. Fields shared with ADDB entries refer to the component
+// schema.
+func ModelSchema() ([]byte, error) {
+	g := generator{}
+	components, err := g.componentOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := g.item(reflect.TypeOf(yamlmodel.SecurityModel{}), "model", "model", nil)
+	if err != nil {
+		return nil, err
+	}
+	root := newObject().set("id", "model-schema").set("title", "Security Model Specification")
+	root.merge(model)
+
+	entity := reflect.TypeOf(yamlmodel.Entity{})
+	subSchemas := newObject()
+	for _, s := range []struct {
+		path []string
+		kind string
+		key  string
+	}{
+		{[]string{"reference"}, "reference", "reference"},
+		{[]string{"externals", "human"}, "human", "external human"},
+		{[]string{"externals", "program"}, "program", "external program"},
+		{[]string{"entities", "human"}, "human", "human"},
+		{[]string{"entities", "program"}, "program", "program"},
+		{[]string{"entities", "role"}, "role", "role"},
+	} {
+		item, err := g.item(entity, s.kind, s.key, components[s.kind])
+		if err != nil {
+			return nil, err
+		}
+		subSchemas.setPath(s.path, item)
+	}
+	flow, err := g.item(reflect.TypeOf(yamlmodel.Flow{}), "flow", "flow", components["flow"])
+	if err != nil {
+		return nil, err
+	}
+	subSchemas.set("flow", flow)
+	node, err := g.item(reflect.TypeOf(yamlmodel.Node{}), "node", "node", nil)
+	if err != nil {
+		return nil, err
+	}
+	subSchemas.set("node", node)
+	root.set("sub-schemas", subSchemas)
+
+	return encode(root)
+}
+
+// Generate the schema for ADDB entries ('component-schema.json') from
+// 'addb.ADDBComponent'.
+func ComponentSchema() ([]byte, error) {
+	g := generator{}
+	components, err := g.componentOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []interface{}
+	options := newObject()
+	for _, kind := range componentKinds {
+		refs = append(refs, newObject().set("$ref", "#/options/"+kind))
+		options.set(kind, components[kind])
+	}
+	root := newObject().
+		set("id", "component-schema").
+		set("title", "Component Security Specification").
+		set("anyOf", refs).
+		set("options", options)
+
+	return encode(root)
+}
+
+////////////////////////////////////////
+// Internal functions
+
+type generator struct{}
+
+// Schemas of each kind of ADDB entry
+func (g generator) componentOptions() (map[string]*object, error) {
+	options := make(map[string]*object)
+	for _, kind := range componentKinds {
+		option, err := g.item(reflect.TypeOf(addb.ADDBComponent{}), kind, "addb."+kind, nil)
+		if err != nil {
+			return nil, err
+		}
+		options[kind] = option
+	}
+	return options, nil
+}
+
+// Schema of an item of 'kind' (see 'schema' tags), made of fields of 't'. 'key'
+// identifies the item in 'descriptions' and 'required'. Fields present in
+// 'component' refer to it instead of being described again.
+func (g generator) item(t reflect.Type, kind string, key string, component *object) (*object, error) {
+	properties := newObject()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !appliesTo(f, kind, strings.HasPrefix(key, "external ")) {
+			continue
+		}
+		if component != nil && component.get("properties").(*object).has(name) {
+			properties.set(name, newObject().set("$ref", ComponentSchemaFile+"#/options/"+kind+"/properties/"+name))
+			continue
+		}
+		property, err := g.property(f.Type, name, kind, key)
+		if err != nil {
+			return nil, err
+		}
+		properties.set(name, property)
+	}
+
+	var mandatory []string
+	for _, name := range required[key] {
+		if properties.has(name) {
+			mandatory = append(mandatory, name)
+		}
+	}
+
+	item := newObject()
+	if description, found := descriptions[key]; found {
+		item.set("description", description)
+	}
+	item.set("type", "object").set("properties", properties)
+	if len(mandatory) > 0 {
+		item.set("required", mandatory)
+	}
+	return item.set("additionalProperties", false), nil
+}
+
+// Schema of a field named 'name' of type 't'
+func (g generator) property(t reflect.Type, name string, kind string, key string) (*object, error) {
+	description, found := describe(name, kind, key)
+	if !found {
+		return nil, errors.New("no description for '" + name + "' of " + key)
+	}
+	property := newObject().set("description", description)
+
+	switch {
+	case name == "addb" && t == reflect.TypeOf(yamlmodel.AddbReference{}):
+		return property.set("oneOf", addbReferenceSchema()), nil
+	case t.Kind() == reflect.String:
+		property.set("type", "string")
+		if name == "type" {
+			property.set("enum", types[kind])
+		} else if values, found := enums[name]; found {
+			property.set("enum", values)
+		}
+		if pattern, found := patterns[key+"."+name]; found {
+			property.set("pattern", pattern)
+		}
+		return property, nil
+	case t.Kind() == reflect.Map && t.Elem().Kind() == reflect.String:
+		values := newObject().set("type", []string{"string", "number", "boolean"})
+		return property.set("type", "object").set("additionalProperties", values), nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		items := newObject().set("type", "string")
+		if pattern, found := itemPatterns[name]; found {
+			items.set("pattern", pattern)
+		}
+		return property.set("type", []string{"array", "null"}).set("items", items), nil
+	case t.Kind() == reflect.Slice && t.Elem() == reflect.TypeOf(&yamlmodel.Entity{}):
+		var variants []interface{}
+		for _, ref := range []string{"#/sub-schemas/" + name + "/human", "#/sub-schemas/" + name + "/program", "#/sub-schemas/" + name + "/role", "#/sub-schemas/reference"} {
+			if name == "externals" && strings.HasSuffix(ref, "/role") { // externals can't be roles
+				continue
+			}
+			variants = append(variants, newObject().set("$ref", ref))
+		}
+		return property.set("type", "array").set("uniqueItems", true).set("items", newObject().set("oneOf", variants)), nil
+	case t.Kind() == reflect.Slice && t.Elem() == reflect.TypeOf(&yamlmodel.Flow{}):
+		return property.set("type", "array").set("uniqueItems", true).set("items", newObject().set("$ref", "#/sub-schemas/flow")), nil
+	case t.Kind() == reflect.Slice && t.Elem() == reflect.TypeOf(&yamlmodel.Node{}):
+		return property.set("type", []string{"array", "null"}).set("items", newObject().set("$ref", "#/sub-schemas/node")), nil
+	}
+	return nil, errors.New("cannot generate schema for '" + name + "' of type " + t.String())
+}
+
+// 'addb' is either a path or a git repository (see 'yamlmodel.AddbReference')
+func addbReferenceSchema() []interface{} {
+	repository := newObject().
+		set("repo", newObject().set("description", "URL of the git repository or path to a local (bare) repository.").set("type", "string")).
+		set("ref", newObject().set("description", "Branch, tag or commit to check out.").set("type", "string"))
+	return []interface{}{
+		newObject().set("description", "Path to a local ADDB directory.").set("type", "string"),
+		newObject().
+			set("description", "Git repository pinned to a revision.").
+			set("type", "object").
+			set("properties", repository).
+			set("required", []string{"repo"}).
+			set("additionalProperties", false),
+	}
+}
+
+// Check if a field applies to a kind of item, based on its 'schema' tag
+func appliesTo(f reflect.StructField, kind string, external bool) bool {
+	tag, found := f.Tag.Lookup("schema")
+	if !found {
+		return true
+	}
+	kinds := strings.Split(tag, ",")
+	if external && !contains("external", kinds) {
+		return false
+	}
+	return contains(kind, kinds)
+}
+
+func describe(name string, kind string, key string) (string, bool) {
+	for _, k := range []string{key + "." + name, kind + "." + name, name} {
+		if description, found := descriptions[k]; found {
+			return strings.ReplaceAll(description, "{item}", kind), true
+		}
+	}
+	return "", false
+}
+
+func encode(root *object) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func contains(item string, list []string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package templates
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"securitymodel/yamlmodel"
+)
+
+// Path of the model-level ADM file, relative to the model's directory
+const ModelADMFile = "adm/model.adm"
+
+// Common architectures a new model can start from. Entities refer to ADDB
+// entries for languages, bases and protocols.
+var architectures = map[string]struct {
+	description string
+	externals   []*yamlmodel.Entity
+	entities    []*yamlmodel.Entity
+	flows       []*yamlmodel.Flow
+}{
+	"blank": {description: "Header only"},
+	"web-app": {
+		description: "Web application with an API server and a database",
+		externals: []*yamlmodel.Entity{
+			{Id: "user", Type: yamlmodel.Human, Name: "User", Description: "A person using the application.", Interface: "browser"},
+		},
+		entities: []*yamlmodel.Entity{
+			program("browser", "Web browser", "Stock web-browser running on user's device."),
+			program("frontend", "Web UI", "Web application served to browsers."),
+			program("api", "API server", "Server that processes requests from the web UI.", "addb:lang.go"),
+			withBase(program("db", "Database", "Database used by the API server to persist data.", "addb:lang.sql"), "addb:db.mysql"),
+		},
+		flows: []*yamlmodel.Flow{
+			flow("use-browser", "Use browser", "User's interactions with their browser.", "user", "browser"),
+			flow("load-ui", "Load UI", "Browser loads the web UI.", "browser", "frontend", "addb:flow.https"),
+			flow("call-api", "Call API", "Web UI running in the browser sends requests to the API server.", "browser", "api", "addb:flow.https"),
+			flow("query-db", "Query database", "API server reads and writes data.", "api", "db"),
+		},
+	},
+	"mobile-app": {
+		description: "Mobile application with a backend",
+		externals: []*yamlmodel.Entity{
+			{Id: "user", Type: yamlmodel.Human, Name: "User", Description: "A person using the mobile application.", Interface: "app"},
+		},
+		entities: []*yamlmodel.Entity{
+			program("app", "Mobile app", "Application installed on user's device."),
+			program("backend", "Backend", "Server that processes requests from the mobile app.", "addb:lang.go"),
+			withBase(program("db", "Database", "Database used by the backend to persist data.", "addb:lang.sql"), "addb:db.mysql"),
+		},
+		flows: []*yamlmodel.Flow{
+			flow("use-app", "Use app", "User's interactions with the mobile app.", "user", "app"),
+			flow("call-backend", "Call backend", "Mobile app sends requests to the backend.", "app", "backend", "addb:flow.https"),
+			flow("query-db", "Query database", "Backend reads and writes data.", "backend", "db"),
+		},
+	},
+	"batch": {
+		description: "Scheduled batch pipeline that processes data from an upstream system",
+		externals: []*yamlmodel.Entity{
+			{Id: "source", Type: yamlmodel.Program, Name: "Data source", Description: "Upstream system providing input data."},
+		},
+		entities: []*yamlmodel.Entity{
+			program("scheduler", "Scheduler", "Starts pipeline runs on a schedule."),
+			program("worker", "Worker", "Reads input, processes it and stores the results.", "addb:lang.go"),
+			withBase(program("store", "Result store", "Database holding processed data.", "addb:lang.sql"), "addb:db.mysql"),
+		},
+		flows: []*yamlmodel.Flow{
+			flow("start-run", "Start run", "Scheduler starts a pipeline run.", "scheduler", "worker"),
+			flow("read-input", "Read input", "Worker fetches input data from the source.", "worker", "source", "addb:flow.https"),
+			flow("store-results", "Store results", "Worker writes processed data.", "worker", "store"),
+		},
+	},
+}
+
+// Names of available templates, sorted
+func Names() (names []string) {
+	for name := range architectures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// One-line description of each template
+func Description(name string) string {
+	return architectures[name].description
+}
+
+// New security model from template 'name', using ADDB at 'addbPath'.
+func New(name string, title string, addbPath string) (*yamlmodel.SecurityModel, error) {
+	a, found := architectures[name]
+	if !found {
+		return nil, errors.New("unknown template '" + name + "'. Supported templates - " + strings.Join(Names(), ", "))
+	}
+	m := yamlmodel.SecurityModel{
+		Title:    title,
+		AddbUri:  yamlmodel.AddbReference{Path: addbPath},
+		ModelADM: []string{ModelADMFile},
+	}
+	// Copies, so that changes to the model don't change the template
+	for _, e := range a.externals {
+		external := *e
+		m.Externals = append(m.Externals, &external)
+	}
+	for _, e := range a.entities {
+		entity := *e
+		m.Entities = append(m.Entities, &entity)
+	}
+	for _, f := range a.flows {
+		flow := *f
+		m.Flows = append(m.Flows, &flow)
+	}
+	return &m, nil
+}
+
+// ADDB references ('addb:<id>') used in lists of a model, like 'languages'
+// and 'protocol'
+func ADDBReferences(m *yamlmodel.SecurityModel) (refs []string) {
+	seen := make(map[string]bool)
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if strings.HasPrefix(id, "addb:") && !seen[id] {
+				seen[id] = true
+				refs = append(refs, id)
+			}
+		}
+	}
+	for _, e := range append(append([]*yamlmodel.Entity{}, m.Externals...), m.Entities...) {
+		add(e.Base...)
+		add(e.Languages...)
+		add(e.Dependencies...)
+		add(e.Roles...)
+	}
+	for _, f := range m.Flows {
+		add(f.Protocol...)
+	}
+	sort.Strings(refs)
+	return
+}
+
+// Remove ADDB references listed in 'refs' from a model
+func RemoveReferences(m *yamlmodel.SecurityModel, refs []string) {
+	keep := func(ids []string) (kept []string) {
+		for _, id := range ids {
+			if !contains(id, refs) {
+				kept = append(kept, id)
+			}
+		}
+		return
+	}
+	for _, e := range append(append([]*yamlmodel.Entity{}, m.Externals...), m.Entities...) {
+		e.Base, e.Languages, e.Dependencies, e.Roles = keep(e.Base), keep(e.Languages), keep(e.Dependencies), keep(e.Roles)
+	}
+	for _, f := range m.Flows {
+		f.Protocol = keep(f.Protocol)
+	}
+}
+
+// Starting point for the model-level ADM file
+func ModelADM(title string) string {
+	return "Model: " + title + `
+  # Attacks and defenses that span more than one entity or flow. For example -
+  #
+  # Attack: Steal session tokens
+  #   Given sessions are identified by tokens
+  #   When tokens are sent over unencrypted connections
+  #   Then attacker impersonates users
+  #
+  # Defense: Encrypt all connections
+  #   Given sessions are identified by tokens
+  #   When tokens are sent over unencrypted connections
+  #   Then connections are rejected unless they use TLS
+`
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func contains(item string, list []string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
+
+func program(id string, name string, description string, languages ...string) *yamlmodel.Entity {
+	return &yamlmodel.Entity{Id: id, Type: yamlmodel.Program, Name: name, Description: description, Languages: languages, ADM: []string{}}
+}
+
+func withBase(e *yamlmodel.Entity, base ...string) *yamlmodel.Entity {
+	e.Base = base
+	return e
+}
+
+func flow(id string, name string, description string, sender string, receiver string, protocol ...string) *yamlmodel.Flow {
+	return &yamlmodel.Flow{Id: id, Name: name, Description: description, Sender: sender, Receiver: receiver, Protocol: protocol, ADM: []string{}}
+}
@@ -0,0 +1,54 @@
+package yamlmodel
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Location of ADDB. It is either a path to a local directory -
+// `addb: ~/addb`, or a git repository pinned to a revision -
+// `addb: {repo: ../addb.git, ref: v2.3.0}`.
+type AddbReference struct {
+	Path string `yaml:"path,omitempty"`
+	Repo string `yaml:"repo,omitempty"`
+	Ref  string `yaml:"ref,omitempty"`
+}
+
+func (a *AddbReference) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&a.Path)
+	}
+	type plain AddbReference // avoids recursive calls to this function
+	if err := value.Decode((*plain)(a)); err != nil {
+		return err
+	}
+	// Both are passed to git, which would read them as options
+	if strings.HasPrefix(a.Repo, "-") {
+		return errors.New("invalid ADDB repository '" + a.Repo + "'")
+	}
+	if strings.HasPrefix(a.Ref, "-") {
+		return errors.New("invalid ADDB revision '" + a.Ref + "'")
+	}
+	return nil
+}
+
+func (a AddbReference) MarshalYAML() (interface{}, error) {
+	if a.Repo == "" {
+		return a.Path, nil
+	}
+	type plain AddbReference
+	return plain(a), nil
+}
+
+// Human readable form of the reference.
+func (a AddbReference) String() string {
+	if a.Repo == "" {
+		return a.Path
+	}
+	if a.Ref == "" {
+		return a.Repo
+	}
+	return a.Repo + "@" + a.Ref
+}
@@ -0,0 +1,78 @@
+package yamlmodel
+
+import (
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Append 'values' to the list 'field' (like 'languages' or 'dependencies')
+// of entity 'entityId' in smspec 'content'. Values already in the list are
+// skipped. Comments and order of other items are retained.
+func AppendToEntityList(content []byte, entityId string, field string, values []string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("security model must be a YAML mapping")
+	}
+
+	entity := findEntityNode(doc.Content[0], entityId)
+	if entity == nil {
+		return nil, errors.New("entity '" + entityId + "' not found")
+	}
+
+	list := mappingValue(entity, field)
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		entity.Content = append(entity.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field}, list)
+	} else if list.Kind == yaml.ScalarNode && list.Tag == "!!null" {
+		*list = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	} else if list.Kind != yaml.SequenceNode {
+		return nil, errors.New("'" + field + "' of '" + entityId + "' is not a list")
+	}
+
+	existing := make(map[string]bool)
+	for _, item := range list.Content {
+		existing[item.Value] = true
+	}
+	for _, value := range values {
+		if !existing[value] {
+			existing[value] = true
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		}
+	}
+
+	return encode(&doc)
+}
+
+////////////////////////////////////////
+// Internal functions
+
+func findEntityNode(model *yaml.Node, entityId string) *yaml.Node {
+	for _, section := range []string{"entities", "externals"} {
+		list := mappingValue(model, section)
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range list.Content {
+			if id := mappingValue(item, "id"); id != nil && id.Value == entityId {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
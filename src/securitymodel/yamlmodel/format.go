@@ -0,0 +1,236 @@
+package yamlmodel
+
+import (
+	"addb"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"schema"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rewrite a security model (or fragment) or a file of ADDB entries in
+// canonical layout - fields in the order they are documented in, block style
+// for items, flow style for short lists and lists whose order doesn't matter
+// ('languages', 'dependencies' and 'roles') sorted. Comments are retained.
+// Files whose documents have an 'id' are treated as ADDB entries and are
+// written with '---' and '...' around each entry.
+func Format(content []byte) ([]byte, error) {
+	nodes := yaml.NewDecoder(bytes.NewReader(content))
+	values := yaml.NewDecoder(bytes.NewReader(content))
+	var docs []*yaml.Node
+	isADDB := false
+	for {
+		var doc yaml.Node
+		if err := nodes.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			return nil, errors.New("expected a security model or ADDB entries")
+		}
+
+		var v interface{} = &SecurityModel{}
+		if mappingValue(doc.Content[0], "id") != nil {
+			v, isADDB = &addb.ADDBComponent{}, true
+		}
+		if err := schema.Decode(values, v); err != nil {
+			return nil, err
+		}
+		sortUnordered(v)
+		doc.Content[0] = withComments(doc.Content[0], valueNode(reflect.ValueOf(v)), reflect.TypeOf(v), true)
+		docs = append(docs, &doc)
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("expected a security model or ADDB entries")
+	}
+
+	if !isADDB {
+		var out []byte
+		for i, doc := range docs {
+			content, err := encode(doc)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				out = append(out, "---\n"...)
+			}
+			out = append(out, separateSections(content)...)
+		}
+		return out, nil
+	}
+
+	var out bytes.Buffer
+	for i, doc := range docs {
+		root := doc.Content[0]
+		if i == 0 && root.HeadComment != "" { // comments at the top of the file (like 'yaml-language-server') precede the first entry
+			out.WriteString(root.HeadComment + "\n")
+			root.HeadComment = ""
+		} else if i > 0 {
+			out.WriteString("\n")
+		}
+		content, err := encode(doc)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---\n")
+		out.Write(content)
+		out.WriteString("...\n")
+	}
+	return out.Bytes(), nil
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// Sort lists whose order has no meaning
+func sortUnordered(v interface{}) {
+	switch item := v.(type) {
+	case *SecurityModel:
+		for _, e := range append(append([]*Entity{}, item.Externals...), item.Entities...) {
+			if e != nil {
+				sort.Strings(e.Languages)
+				sort.Strings(e.Dependencies)
+				sort.Strings(e.Roles)
+			}
+		}
+	case *addb.ADDBComponent:
+		sort.Strings(item.Languages)
+		sort.Strings(item.Dependencies)
+		sort.Strings(item.Roles)
+	}
+}
+
+// Sections of a model that are followed by a blank line
+var sections = regexp.MustCompile(`^(externals|entities|flows|deployment):`)
+
+// Add a blank line before each of 'externals', 'entities' and 'flows' (and
+// comments on them) to separate them from the header.
+func separateSections(content []byte) []byte {
+	lines := strings.SplitAfter(string(content), "\n")
+	for i := 1; i < len(lines); i++ {
+		if !sections.MatchString(lines[i]) {
+			continue
+		}
+		start := i
+		for start > 1 && strings.HasPrefix(lines[start-1], "#") {
+			start--
+		}
+		lines = append(lines[:start], append([]string{"\n"}, lines[start:]...)...)
+		i++
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// Carry comments in 'existing' over to matching nodes of 'canonical', which
+// is the canonical form of a value of type 't'. Fields that are empty (like
+// 'adm: null') are not part of 'canonical' and are retained as is. 'root' is
+// set for the top-level mapping of a document.
+func withComments(existing *yaml.Node, canonical *yaml.Node, t reflect.Type, root bool) *yaml.Node {
+	if canonical == nil || existing.Kind != canonical.Kind {
+		return canonical
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	canonical.HeadComment, canonical.LineComment, canonical.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+
+	switch existing.Kind {
+	case yaml.MappingNode:
+		old := pairs(existing)
+		if root && len(old) > 0 && canonical.HeadComment == "" { // comments at the top stay there, even if the first field moves
+			canonical.HeadComment, old[0][0].HeadComment = old[0][0].HeadComment, ""
+		}
+		fields := pairs(canonical)
+		for _, o := range old {
+			found := false
+			for _, pair := range fields {
+				if o[0].Value == pair[0].Value {
+					pair[0].HeadComment, pair[0].LineComment, pair[0].FootComment = o[0].HeadComment, o[0].LineComment, o[0].FootComment
+					withComments(o[1], pair[1], valueType(t, pair[0].Value), false)
+					found = true
+				}
+			}
+			if !found && t.Kind() == reflect.Struct {
+				fields = append(fields, o)
+			}
+		}
+		if t.Kind() == reflect.Struct {
+			order := fieldOrder(t)
+			sort.SliceStable(fields, func(i, j int) bool {
+				return indexOf(fields[i][0].Value, order) < indexOf(fields[j][0].Value, order)
+			})
+		}
+		canonical.Content = nil
+		for _, pair := range fields {
+			canonical.Content = append(canonical.Content, pair...)
+		}
+
+		// Line comments of keys whose values are written in flow style, like
+		// 'protocol: [http, tls] # comment', stay on the key's line
+		for _, pair := range fields {
+			value := pair[1]
+			if pair[0].LineComment != "" && value.Style == yaml.FlowStyle && value.LineComment == "" && value.Kind != yaml.ScalarNode {
+				value.LineComment, pair[0].LineComment = pair[0].LineComment, ""
+			}
+		}
+
+		// Line comments of items written as '{id: ..., ...}' follow their first field in block style
+		if existing.Style == yaml.FlowStyle && canonical.Style != yaml.FlowStyle && canonical.LineComment != "" && len(canonical.Content) > 1 {
+			first := canonical.Content[1]
+			if first.Kind == yaml.ScalarNode && first.LineComment == "" {
+				first.LineComment, canonical.LineComment = canonical.LineComment, ""
+			}
+		}
+	case yaml.SequenceNode:
+		old := singles(existing)
+		for i, item := range singles(canonical) {
+			k := itemKey(item)
+			for j, o := range old {
+				if (k != "" && itemKey(o) == k) || (k == "" && i == j) {
+					withComments(o[0], item[0], t.Elem(), false)
+					break
+				}
+			}
+			if item[0].HeadComment != "" || item[0].LineComment != "" || item[0].FootComment != "" {
+				canonical.Style = 0 // comments on items need one item per line
+			}
+		}
+	case yaml.ScalarNode:
+		if existing.Value == canonical.Value && existing.Tag != "!!str" && existing.Tag != "" {
+			canonical.Tag = existing.Tag // like numbers in 'parameters'
+		}
+	}
+	return canonical
+}
+
+// Type of the value of field (or key) 'name' in values of type 't'
+func valueType(t reflect.Type, name string) reflect.Type {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if field, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); field == name {
+				return t.Field(i).Type
+			}
+		}
+	}
+	return reflect.TypeOf("")
+}
+
+func indexOf(item string, list []string) int {
+	for i, x := range list {
+		if x == item {
+			return i
+		}
+	}
+	return len(list)
+}
@@ -0,0 +1,368 @@
+package yamlmodel
+
+import (
+	"addb"
+	"bytes"
+	"errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Order of fields in canonical smspec YAML, following SMSPEC.md and ADDB.md.
+// Fields not listed here follow in the order they are declared in.
+var canonicalOrder = map[reflect.Type][]string{
+	reflect.TypeOf(SecurityModel{}): {"design-document", "title", "addb", "adm", "include", "parameters", "risk-overlay", "externals", "entities",
+		"flows", "deployment"},
+	reflect.TypeOf(Entity{}): {"id", "ref", "type", "name", "description", "tags", "when", "likelihood", "criticality",
+		"data-classification", "owner", "team", "contact", "interface", "repo", "icon", "base", "languages", "dependencies", "sbom", "roles", "mitigations", "recommendations", "adm"},
+	reflect.TypeOf(Flow{}): {"id", "name", "description", "tags", "when", "likelihood", "criticality", "data-classification", "owner", "team",
+		"contact", "sender", "receiver", "protocol", "mitigations", "recommendations", "adm"},
+	reflect.TypeOf(Node{}): {"id", "type", "name", "description", "entities", "adm", "nodes"},
+	reflect.TypeOf(addb.ADDBComponent{}): {"id", "type", "name", "description", "tags", "design-document", "owner", "team", "contact", "interface", "repo", "icon", "base",
+		"languages", "dependencies", "roles", "protocol", "mitigations", "recommendations", "adm"},
+}
+
+// Lists of short, single-word items (like IDs and paths) that fit within this
+// width are written in flow style - '[a, b]'.
+const flowListWidth = 60
+
+// Write a security model as canonical smspec YAML. Empty fields are left out,
+// except lists that are empty but not nil (like 'adm: []').
+func Marshal(m *SecurityModel) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{valueNode(reflect.ValueOf(m))}}
+	content, err := encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return separateSections(content), nil
+}
+
+// Write a security model over its existing smspec YAML 'content'. Comments,
+// formatting and order of fields and items that are present in both are
+// retained. Fields and items that are new are added in canonical order, while
+// those missing from 'm' are removed. Items in lists are matched by their
+// 'id' (or 'ref'). Resolved models (see 'SecurityModel.Resolved') cannot be
+// written over content that uses includes, parameters or conditions, since
+// these would be lost.
+func Update(content []byte, m *SecurityModel) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) > 0 && m.Resolved {
+		if feature := unresolvedFeature(doc.Content[0]); feature != "" {
+			return nil, errors.New("cannot write a loaded model over content that uses " + feature + ". It was applied when the model was loaded and would be lost")
+		}
+	}
+	updated := valueNode(reflect.ValueOf(m))
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{updated}}
+	} else {
+		doc.Content[0] = mergeNodes(doc.Content[0], updated)
+	}
+	return encode(&doc)
+}
+
+////////////////////////////////////////
+// Internal functions
+
+// Includes, parameters, conditions or substitutions used in a model, if any
+func unresolvedFeature(n *yaml.Node) string {
+	if n.Kind == yaml.MappingNode {
+		for _, pair := range pairs(n) {
+			switch pair[0].Value {
+			case "include", "parameters":
+				return "'" + pair[0].Value + "'"
+			case "when":
+				return "conditions ('when')"
+			}
+		}
+	}
+	if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "${") {
+		return "substitutions ('${NAME}')"
+	}
+	for _, c := range n.Content {
+		if feature := unresolvedFeature(c); feature != "" {
+			return feature
+		}
+	}
+	return ""
+}
+
+func encode(doc *yaml.Node) ([]byte, error) {
+	restore := protectTrailingSpaces(doc)
+	defer restore()
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	encoder.Close()
+	return []byte(spaces.Replace(out.String())), nil
+}
+
+// Placeholders for spaces and tabs at the end of lines of literal blocks.
+// They are characters from Unicode's private use area, which are unlikely
+// to be in models.
+const spacePlaceholder, tabPlaceholder = "\uE000", "\uE001"
+
+var spaces = strings.NewReplacer(spacePlaceholder, " ", tabPlaceholder, "\t")
+
+var trailingSpaces = regexp.MustCompile(`[ \t]+(\n|$)`)
+
+// yaml.v3 quotes values that have lines ending in spaces instead of writing
+// them as literal blocks ('|'). Replace these spaces with placeholders, so
+// that such blocks are written as they are. Returns a function that puts the
+// original values back.
+func protectTrailingSpaces(n *yaml.Node) (restore func()) {
+	var nodes []*yaml.Node
+	var values []string
+	var walk func(*yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && n.Style == yaml.LiteralStyle && trailingSpaces.MatchString(n.Value) &&
+			!strings.Contains(n.Value, spacePlaceholder) && !strings.Contains(n.Value, tabPlaceholder) {
+			nodes, values = append(nodes, n), append(values, n.Value)
+			n.Value = trailingSpaces.ReplaceAllStringFunc(n.Value, func(s string) string {
+				return strings.NewReplacer(" ", spacePlaceholder, "\t", tabPlaceholder).Replace(s)
+			})
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(n)
+	return func() {
+		for i, n := range nodes {
+			n.Value = values[i]
+		}
+	}
+}
+
+// Canonical YAML node for 'v'. Returns nil for values that are left out.
+func valueNode(v reflect.Value) *yaml.Node {
+	if !v.IsValid() {
+		return nil
+	}
+	if marshaler, ok := v.Interface().(yaml.Marshaler); ok && v.Kind() != reflect.Pointer {
+		value, err := marshaler.MarshalYAML()
+		if err != nil {
+			return nil
+		}
+		return valueNode(reflect.ValueOf(value))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return valueNode(v.Elem())
+	case reflect.Struct:
+		return structNode(v)
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		var keys []string
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			mapping.Content = append(mapping.Content, scalarNode(key), scalarNode(v.MapIndex(reflect.ValueOf(key)).String()))
+		}
+		return mapping
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		width := 0
+		for i := 0; i < v.Len(); i++ {
+			item := valueNode(v.Index(i))
+			if item == nil {
+				continue
+			}
+			if item.Kind != yaml.ScalarNode || strings.ContainsAny(item.Value, " \t\n") {
+				list.Style = 0
+			}
+			width += len(item.Value) + 2
+			list.Content = append(list.Content, item)
+		}
+		if width > flowListWidth {
+			list.Style = 0
+		}
+		return list
+	case reflect.String:
+		if v.String() == "" {
+			return nil
+		}
+		return scalarNode(v.String())
+	}
+	return nil
+}
+
+func structNode(v reflect.Value) *yaml.Node {
+	fields := make(map[string]*yaml.Node)
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+		if !v.Type().Field(i).IsExported() || name == "-" || name == "" {
+			continue
+		}
+		if node := valueNode(v.Field(i)); node != nil {
+			fields[name] = node
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if _, found := canonicalOrder[v.Type()]; !found { // small mappings, like 'addb: {repo: ..., ref: ...}'
+		mapping.Style = yaml.FlowStyle
+	}
+	for _, name := range fieldOrder(v.Type()) {
+		if node, found := fields[name]; found {
+			mapping.Content = append(mapping.Content, scalarNode(name), node)
+		}
+	}
+	return mapping
+}
+
+// Names of fields of struct type 't' in canonical order
+func fieldOrder(t reflect.Type) []string {
+	order := append([]string{}, canonicalOrder[t]...)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if t.Field(i).IsExported() && name != "-" && name != "" && !contains(name, order) {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// Multi-line values are written as literal blocks ('|').
+func scalarNode(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if strings.Contains(value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+// Update 'existing' to match 'updated', retaining comments, formatting and
+// order of 'existing' where possible.
+func mergeNodes(existing *yaml.Node, updated *yaml.Node) *yaml.Node {
+	if existing.Kind == yaml.AliasNode || existing.Kind != updated.Kind {
+		updated.HeadComment, updated.LineComment, updated.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+		return updated
+	}
+
+	switch existing.Kind {
+	case yaml.MappingNode:
+		existing.Content = mergeItems(pairs(existing), pairs(updated), func(pair []*yaml.Node) string { return pair[0].Value },
+			func(old []*yaml.Node, new []*yaml.Node) []*yaml.Node {
+				return []*yaml.Node{old[0], mergeNodes(old[1], new[1])}
+			})
+	case yaml.SequenceNode:
+		existing.Content = mergeItems(singles(existing), singles(updated), itemKey,
+			func(old []*yaml.Node, new []*yaml.Node) []*yaml.Node {
+				return []*yaml.Node{mergeNodes(old[0], new[0])}
+			})
+	case yaml.ScalarNode:
+		if existing.Value != updated.Value {
+			existing.Value, existing.Tag = updated.Value, updated.Tag
+			if updated.Style == yaml.LiteralStyle || existing.Style == yaml.LiteralStyle {
+				existing.Style = updated.Style
+			}
+		}
+	}
+	return existing
+}
+
+// Merge lists of entries (key-value pairs of mappings or items of sequences)
+// matched by 'key'. Existing entries keep their order. New entries are placed
+// after the entry that precedes them in 'updated'. Entries without a key are
+// replaced.
+func mergeItems(existing [][]*yaml.Node, updated [][]*yaml.Node, key func([]*yaml.Node) string, merge func([]*yaml.Node, []*yaml.Node) []*yaml.Node) []*yaml.Node {
+	wanted := make(map[string][]*yaml.Node)
+	for _, entry := range updated {
+		wanted[key(entry)] = entry
+	}
+	present := make(map[string]bool)
+	var merged [][]*yaml.Node
+	for _, entry := range existing {
+		k := key(entry)
+		if new, found := wanted[k]; found && k != "" && !present[k] {
+			present[k] = true
+			merged = append(merged, merge(entry, new))
+		}
+	}
+
+	position := -1 // index in 'merged' after which new entries are inserted
+	for _, entry := range updated {
+		k := key(entry)
+		if k != "" && present[k] {
+			for i, m := range merged {
+				if key(m) == k {
+					position = i
+				}
+			}
+			continue
+		}
+		present[k] = true
+		merged = append(merged[:position+1], append([][]*yaml.Node{entry}, merged[position+1:]...)...)
+		position++
+	}
+
+	var nodes []*yaml.Node
+	for _, entry := range merged {
+		nodes = append(nodes, entry...)
+	}
+	return nodes
+}
+
+func pairs(mapping *yaml.Node) (entries [][]*yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		entries = append(entries, mapping.Content[i:i+2])
+	}
+	return
+}
+
+func singles(sequence *yaml.Node) (entries [][]*yaml.Node) {
+	for _, item := range sequence.Content {
+		entries = append(entries, []*yaml.Node{item})
+	}
+	return
+}
+
+// Items of lists are identified by their 'id' (or 'ref'), if they are
+// mappings, or by their value.
+func itemKey(entry []*yaml.Node) string {
+	item := entry[0]
+	if item.Kind != yaml.MappingNode {
+		return item.Value
+	}
+	for _, field := range []string{"id", "ref"} {
+		if value := mappingValue(item, field); value != nil {
+			return field + ":" + value.Value
+		}
+	}
+	return ""
+}
+
+func contains(item string, list []string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
@@ -3,14 +3,35 @@ package yamlmodel
 type SecurityModel struct {
 	Title string				`yaml:"title"`
 	DesignDocument string `yaml:"design-document"`
-	AddbUri string `yaml:"addb"`
+	AddbUri AddbReference `yaml:"addb"`
 	ModelADM []string `yaml:"adm,flow"`
+	Includes []string `yaml:"include,flow"`	// smspec fragments merged into this model
 	Externals []*Entity `yaml:"externals,flow"`
 	Entities []*Entity `yaml:"entities,flow"`
 	Flows []*Flow `yaml:"flows,flow"`
+	Parameters map[string]string `yaml:"parameters"`	// defaults of parameters used in '${NAME}' and 'when'
+	RiskOverlay string `yaml:"risk-overlay"`	// likelihood/impact of attacks, overriding ADM tags
+	Deployment []*Node `yaml:"deployment,flow"`	// infrastructure (zones, hosts, containers, etc.) entities run on
 
 	// internal variable to locate adm
-	AdmDir string
+	AdmDir string `yaml:"-"`
+
+	// internal variable. Entities defined in other models (see 'ref'), by their ID in this model.
+	SharedEntities map[string]EntitySource `yaml:"-"`
+
+	// internal variable. Fragment files (see 'include') items were defined in, by their ID.
+	Fragments map[string]string `yaml:"-"`
+
+	// internal variable. Set for models converted from a loaded model, in which
+	// includes, parameters and conditions were already applied (see 'Update').
+	Resolved bool `yaml:"-"`
+}
+
+// Model that defines an entity shared with other models
+type EntitySource struct {
+	Title string	// title of the model
+	Path string		// path to the model file
+	Id string			// ID of the entity in that model
 }
 
 type ItemType string
@@ -21,27 +42,45 @@ const (
 	Role		ItemType = "role"
 )
 
+// 'schema' tags list the kinds of entities a field applies to ('external'
+// extends it to external entities of those kinds). Fields without one apply
+// to all entities. See 'schemagen'.
 type Entity struct {
 	Id string `yaml:"id"`
-	Type ItemType `yaml:"type"`
-	Name string `yaml:"name"`
-	Description string `yaml:"description"`
-	Base []string `yaml:"base"`
-	Mitigations []string `yaml:"mitigations"`	// Not applicable for external entities
-	Recommendations []string `yaml:"recommendations"`	// Not applicable for external entities
-	ADM []string `yaml:"adm"`							// Not applicable for external entities
+	Ref string `yaml:"ref" schema:"reference"`	// '<path to smspec>#<id>' of an entity defined in another model
+	Type ItemType `yaml:"type" schema:"human,program,role,external"`
+	Name string `yaml:"name" schema:"human,program,role,external"`
+	Description string `yaml:"description" schema:"human,program,role,external"`
+	Base []string `yaml:"base" schema:"human,program"`
+	Mitigations []string `yaml:"mitigations" schema:"human,program,role"`	// Not applicable for external entities
+	Recommendations []string `yaml:"recommendations" schema:"human,program,role"`	// Not applicable for external entities
+	ADM []string `yaml:"adm" schema:"human,program,role"`							// Not applicable for external entities
 	
 	// Only for humans
-	Interface string `yaml:"interface"`
+	Interface string `yaml:"interface" schema:"human,external"`
 
 	// Only for programs/systems
-	Roles []string `yaml:"roles"`
-	CodeRepository string `yaml:"repo"`							// Not applicable for external entities
-	Languages []string `yaml:"languages"`
-	Dependencies []string	`yaml:"dependencies"`			// Not applicable for external entities
+	Roles []string `yaml:"roles" schema:"program,external"`
+	CodeRepository string `yaml:"repo" schema:"program"`							// Not applicable for external entities
+	Icon string `yaml:"icon" schema:"program,external"`							// Image used to represent this entity
+	Languages []string `yaml:"languages" schema:"program"`
+	Dependencies []string	`yaml:"dependencies" schema:"program"`			// Not applicable for external entities
+	SBOM string `yaml:"sbom" schema:"program"`							// CycloneDX/SPDX file. Mapped components are added to 'Dependencies'.
+	When string `yaml:"when"`							// Condition on parameters. Entity is dropped if it doesn't hold.
+	Tags []string `yaml:"tags" schema:"human,program,role,external"`	// Labels used to select items with filter expressions
+
+	// Used to rate risks of attacks on this entity
+	Likelihood string `yaml:"likelihood" schema:"human,program"`
+	Criticality string `yaml:"criticality" schema:"human,program"`
+	DataClassification string `yaml:"data-classification" schema:"human,program"`
+
+	// Who is responsible for this entity
+	Owner string `yaml:"owner" schema:"human,program"`
+	Team string `yaml:"team" schema:"human,program"`
+	Contact string `yaml:"contact" schema:"human,program"`
 
 	// internal variable to locate adm
-	AdmDir string
+	AdmDir string `yaml:"-"`
 }
 
 type Flow struct {
@@ -54,7 +93,47 @@ type Flow struct {
 	Mitigations []string `yaml:"mitigations"`
 	Recommendations []string `yaml:"recommendations"`
 	ADM []string `yaml:"adm"`
+	When string `yaml:"when"`	// Condition on parameters. Flow is dropped if it doesn't hold.
+	Tags []string `yaml:"tags"`	// Labels used to select flows with filter expressions
+
+	// Used to rate risks of attacks on this flow
+	Likelihood string `yaml:"likelihood"`
+	Criticality string `yaml:"criticality"`
+	DataClassification string `yaml:"data-classification"`
+
+	// Who is responsible for this flow
+	Owner string `yaml:"owner"`
+	Team string `yaml:"team"`
+	Contact string `yaml:"contact"`
 
 	// internal variable to locate adm
-	AdmDir string
-}
\ No newline at end of file
+	AdmDir string `yaml:"-"`
+}
+// Node of the deployment view - a zone, host, VM, cluster, namespace or
+// container. Nodes can be nested. ADM of a node applies to entities deployed
+// on it and on nodes nested in it.
+type Node struct {
+	Id string `yaml:"id"`
+	Type NodeType `yaml:"type"`
+	Name string `yaml:"name"`
+	Description string `yaml:"description"`
+	Entities []string `yaml:"entities"`	// IDs of programs running on this node
+	ADM []string `yaml:"adm"`
+	Nodes []*Node `yaml:"nodes"`	// nodes nested in this node
+
+	// internal variable to locate adm
+	AdmDir string `yaml:"-"`
+}
+
+type NodeType string
+const (
+	Zone NodeType = "zone"
+	Host NodeType = "host"
+	VM NodeType = "vm"
+	Cluster NodeType = "cluster"
+	Namespace NodeType = "namespace"
+	Container NodeType = "container"
+)
+
+// Supported types of deployment nodes
+var NodeTypes = []string{string(Zone), string(Host), string(VM), string(Cluster), string(Namespace), string(Container)}
@@ -0,0 +1,180 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"os/exec"
+	"path/filepath"
+	smloaders "securitymodel/loaders"
+	"securitymodel/objmodel"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestADDBIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeADDBEntry(t, root, "flows/http.smspec", "flow.http", "flow")
+	writeADDBEntry(t, root, "flows/wip-tls.smspec", "flow.tls", "flow")
+	writeADDBEntry(t, root, "flows/wip-keep.smspec", "flow.keep", "flow")
+	writeADDBEntry(t, root, "vendor/lib/lib.smspec", "lib.vendored", "program")
+	writeADDBEntry(t, root, "lang/go.smspec", "lang.go", "program")
+	writeADDBEntry(t, root, "lang/draft/rust.smspec", "lang.rust", "program")
+	writeADDBEntry(t, root, "lang/draft.smspec", "lang.draft", "program")
+	writeFile(t, root, ".gitignore", "# comments are skipped\nwip-*.smspec\n!wip-keep.smspec\nvendor/\n")
+	writeFile(t, root, "lang/.addbignore", "/draft/\n")
+
+	var db addb.ADDB
+	err := db.Init(root)
+	assert.Nil(t, err)
+
+	for _, id := range []string{"flow.http", "flow.keep", "lang.go", "lang.draft"} {
+		_, err := db.GetComponent("addb:" + id)
+		assert.Nil(t, err, id)
+	}
+	for _, id := range []string{"flow.tls", "lib.vendored", "lang.rust"} {
+		_, err := db.GetComponent("addb:" + id)
+		assert.NotNil(t, err, id)
+	}
+}
+
+func TestADDBIgnoreRulesDoNotLeakToSiblings(t *testing.T) {
+	root := t.TempDir()
+	writeADDBEntry(t, root, "a/skip.smspec", "a.skip", "program")
+	writeADDBEntry(t, root, "b/skip.smspec", "b.skip", "program")
+	writeADDBEntry(t, root, "b/deep/nested/other.smspec", "b.other", "program")
+	writeFile(t, root, "a/.gitignore", "skip.smspec\n")
+	writeFile(t, root, ".gitignore", "**/nested/*.smspec\n")
+
+	var db addb.ADDB
+	err := db.Init(root)
+	assert.Nil(t, err)
+
+	_, err = db.GetComponent("addb:a.skip")
+	assert.NotNil(t, err)
+	_, err = db.GetComponent("addb:b.skip")
+	assert.Nil(t, err)
+	_, err = db.GetComponent("addb:b.other")
+	assert.NotNil(t, err)
+}
+
+func TestADDBFromGitRepository(t *testing.T) {
+	t.Setenv(addb.CacheDirEnv, t.TempDir())
+	repo := createADDBRepository(t)
+
+	var db addb.ADDB
+	err := db.InitFromGit(repo, "v1.0.0")
+	assert.Nil(t, err)
+	assert.Len(t, db.Revision, 40)
+	component, err := db.GetComponent("addb:lang.go")
+	assert.Nil(t, err)
+	assert.Equal(t, "Go v1", component.Name)
+	_, err = db.GetComponent("addb:lang.rust")
+	assert.NotNil(t, err) // added after 'v1.0.0'
+
+	// Latest revision of the default branch
+	var latest addb.ADDB
+	err = latest.InitFromGit(repo, "")
+	assert.Nil(t, err)
+	assert.NotEqual(t, db.Revision, latest.Revision)
+	component, err = latest.GetComponent("addb:lang.go")
+	assert.Nil(t, err)
+	assert.Equal(t, "Go v2", component.Name)
+
+	// Pinning to a commit gives the same content as the tag
+	var pinned addb.ADDB
+	err = pinned.InitFromGit(repo, db.Revision)
+	assert.Nil(t, err)
+	assert.Equal(t, db.Location, pinned.Location)
+
+	var missing addb.ADDB
+	err = missing.InitFromGit(repo, "v9.9.9")
+	assert.NotNil(t, err)
+}
+
+func TestSecurityModelWithADDBFromGitRepository(t *testing.T) {
+	t.Setenv(addb.CacheDirEnv, t.TempDir())
+	repo := createADDBRepository(t)
+	yaml := `
+title: Pinned ADDB
+addb: {repo: ` + filepath.Base(repo) + `, ref: v1.0.0}
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    languages: [addb:lang.go]
+    adm: []
+`
+	var l smloaders.Loader
+	m, errs := l.LoadSecurityModel(yaml, filepath.Dir(repo))
+	assert.Empty(t, errs)
+	assert.Equal(t, filepath.Base(repo)+"@v1.0.0", m.AddbPath)
+	backend := m.Entities["backend"].(*objmodel.Program)
+	assert.Equal(t, "Go v1", backend.GetLanguages()["addb:lang.go"].GetName())
+}
+
+func TestADDBRepositoryIsNotAnOption(t *testing.T) {
+	t.Setenv(addb.CacheDirEnv, t.TempDir())
+	marker := filepath.Join(t.TempDir(), "ran")
+	yaml := `
+title: Option as repository
+addb: {repo: "--upload-pack=touch ` + marker + `"}
+entities: []
+`
+	var l smloaders.Loader
+	_, errs := l.LoadSecurityModel(yaml, "")
+	assert.Contains(t, errorMessages(errs), "invalid ADDB repository '--upload-pack=touch "+marker+"'")
+
+	// Not read as an option when called directly either
+	var db addb.ADDB
+	err := db.InitFromGit("--upload-pack=touch "+marker, "")
+	assert.NotNil(t, err)
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Create a bare git repository containing two revisions of an ADDB. The
+// first one is tagged 'v1.0.0'.
+func createADDBRepository(t *testing.T) string {
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	bare := filepath.Join(dir, "addb.git")
+	runGit(t, dir, "init", "--quiet", work)
+
+	writeFile(t, work, "languages/go.smspec", "---\nid: lang.go\nname: Go v1\ndescription: Go\ntype: program\nadm: []\n...\n")
+	runGit(t, work, "add", "-A")
+	runGit(t, work, "commit", "--quiet", "-m", "first")
+	runGit(t, work, "tag", "v1.0.0")
+
+	writeFile(t, work, "languages/go.smspec", "---\nid: lang.go\nname: Go v2\ndescription: Go\ntype: program\nadm: []\n...\n")
+	writeADDBEntry(t, work, "languages/rust.smspec", "lang.rust", "program")
+	runGit(t, work, "add", "-A")
+	runGit(t, work, "commit", "--quiet", "-m", "second")
+
+	runGit(t, dir, "clone", "--quiet", "--bare", work, bare)
+	return bare
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	args = append([]string{"-c", "user.name=adsm", "-c", "user.email=adsm@localhost", "-c", "init.defaultBranch=main"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(output))
+}
+
+func writeADDBEntry(t *testing.T, root string, path string, id string, itemType string) {
+	writeFile(t, root, path, "---\nid: "+id+"\nname: "+id+"\ndescription: Test entry\ntype: "+itemType+"\nadm: []\n...\n")
+}
+
+func writeFile(t *testing.T, root string, path string, content string) {
+	fullPath := filepath.Join(root, path)
+	err := os.MkdirAll(filepath.Dir(fullPath), 0700)
+	assert.Nil(t, err)
+	err = os.WriteFile(fullPath, []byte(content), 0600)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineADM(t *testing.T) {
+	dir := createInlineADMModel(t)
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	adm := m.Entities["backend"].GetADM()["backend"]
+	assert.Len(t, adm, 2)
+	assert.Equal(t, filepath.Join(dir, "adm/backend.adm"), adm[0])
+	assert.True(t, addb.IsInlineADM(adm[1])) // not resolved against the model's directory
+	assert.Equal(t, "inline ADM 'Backend'", addb.ADMSource(adm[1]))
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"stat", "-e", filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "ADM: "+filepath.Join(dir, "adm/backend.adm")+", ATTACKS:1")
+	assert.Contains(t, out, "ADM: inline ADM 'Backend', ATTACKS:1")
+	assert.Contains(t, out, "ADM: inline ADM 'Cache', ATTACKS:1") // from ADDB
+}
+
+func TestReportWithInlineADM(t *testing.T) {
+	dir := createInlineADMModel(t)
+	outDir := t.TempDir()
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outDir, "report/Inline_ADM.sm.md"))
+	assert.Nil(t, err)
+	report := string(content)
+	assert.Contains(t, report, "* Replay requests (under `entities → backend`)")
+	assert.Contains(t, report, "* Read stale sessions (under `entities → backend → dependencies → cache`)")
+}
+
+func TestInlineADMIsNotAPath(t *testing.T) {
+	assert.False(t, addb.IsInlineADM("adm/model.adm"))
+	assert.False(t, addb.IsInlineADM("adm/Model: v2.adm"))
+	assert.True(t, addb.IsInlineADM("Attack: Replay requests\n  When requests are not signed\n"))
+	assert.Equal(t, "inline ADM", addb.ADMSource("Attack: Replay requests\n"))
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Create a model whose 'backend' has both an ADM file and inline ADM, and
+// depends on an ADDB entry with inline ADM.
+func createInlineADMModel(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	writeFile(t, addbDir, "cache.smspec", `---
+id: cache
+type: program
+name: Cache
+description: In-memory cache
+adm:
+  - |
+    Model: Cache
+      Attack: Read stale sessions
+        When sessions are not invalidated
+...
+`)
+	writeFile(t, dir, "adm/backend.adm", "Model: Backend file\n  Attack: Exhaust connections\n    When connections are not pooled\n")
+	writeFile(t, dir, "model.smspec", `title: Inline ADM
+addb: `+addbDir+`
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    dependencies: [addb:cache]
+    adm:
+      - adm/backend.adm
+      - |
+        Model: Backend
+          Attack: Replay requests
+            When requests are not signed
+`)
+	return dir
+}
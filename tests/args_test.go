@@ -27,7 +27,15 @@ func TestShowHelp(t *testing.T) {
 			"\nstat: List model components\n" +
 			"  -e\tList in-scope entities only.\n" +
 			"  -f\tList flows only.\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -locked\n" +
+			"    \tFail if ADDB content differs from the model's lock file.\n" +
 			"  -r\tList roles only.\n" +
+			"  -s\tShow STRIDE coverage of external entities, entities and flows only.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"  -t\tShow CAPEC, ATT&CK and CWE references of attacks and defenses only.\n" +
 			"  -x\tList external entities only.\n" + 
 			"\n" +
 			"diag: Generate security model and ADM diagrams.\n" +
@@ -35,18 +43,75 @@ func TestShowHelp(t *testing.T) {
 			"    \tGenerate ADM decision graph only.\n" +
 			"  -d string\n" +
 			"    \tOutput directory for diagrams. (default \"./\")\n" +
+			"  -deploy\n" +
+			"    \tGenerate deployment diagram only.\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
 			"  -sm\n" + 
 			"    \tGenerate security model diagram only.\n" +
 			"\n" +
 			"report: Generate security report as markdown file.\n" +
 			"  -d string\n" +
-			"    	Output directory for generated report. (default \"./\")\n"/* +
+			"    	Output directory for generated report. (default \"./\")\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -format string\n" +
+			"    \tReport format. Supported values - markdown,compliance,csv,sarif. (default \"markdown\")\n" +
+			"  -locked\n" +
+			"    \tFail if ADDB content differs from the model's lock file.\n" +
+			"  -owner OWNER\n" +
+			"    \tOnly report on entities and flows owned by OWNER (owner or team).\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
 			"\n" +
-			"export: Export security model and report to other formats.\n" +
+			"lock: Record ADDB content used by the model in a '.smspec.lock' file.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"\n" +
+			"sbom: Import SBOM components as dependencies of a program and list components without an ADDB entry.\n" +
+			"  -e string\n" +
+			"    \tID of the entity (program/system) to import dependencies into. Required with '-i'.\n" +
+			"  -i string\n" +
+			"    \tCycloneDX or SPDX file to import. If not specified, SBOMs referred by entities are checked.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"\n" +
+			"scan: Suggest languages and dependencies by scanning programs' local repositories.\n" +
+			"  -e string\n" +
+			"    \tScan only the repository of this entity.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"  -w\tAdd suggested languages and dependencies to the model file.\n" +
+			"\n" +
+			"schema: Generate JSON schemas for models and ADDB entries in the directory specified as [PATH].\n" +
+			"\n" +
+			"fmt: Rewrite smspec files (models, fragments and ADDB entries) in canonical layout.\n" +
+			"  -check\n" +
+			"    \tList files that are not formatted and fail if there are any.\n" +
+			"  -w\tWrite formatted content back to the files instead of printing it.\n" +
+			"\n" +
+			"init: Create a new model as [PATH] (or 'model.smspec' in directory [PATH]) with a model-level ADM file.\n" +
+			"  -addb string\n" +
+			"    \tADDB used by the model. (default \"~/addb\")\n" +
+			"  -i\tPrompt for external entities, entities and flows to add.\n" +
+			"  -t string\n" +
+			"    \tTemplate to start from. Supported values - batch,blank,mobile-app,web-app. (default \"blank\")\n" +
+			"  -title string\n" +
+			"    \tTitle of the model.\n" +
+			"\n" +
+			"export: Export security model to other formats.\n" +
 			"  -d string\n" +
 			"    	Output directory for exported files. (default \"./\")\n" +
 			"  -f string\n" +
-			"    	Output format. Supported values - json,xml. (default \"json\")\n"*/
+			"    	Output format. Supported values - oscal. (default \"oscal\")\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -profile URL\n" +
+			"    \tURL of the OSCAL profile (or catalog) the plan imports. Defaults to the 'profile' of frameworks in ADDB's control catalogs.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n"
 
 	assert.Equal(t, out, expected)
 }
@@ -65,7 +130,15 @@ func TestUninitializedArgsStruct_ShowHelp(t *testing.T) {
 			"\nstat: List model components\n" +
 			"  -e\tList in-scope entities only.\n" +
 			"  -f\tList flows only.\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -locked\n" +
+			"    \tFail if ADDB content differs from the model's lock file.\n" +
 			"  -r\tList roles only.\n" +
+			"  -s\tShow STRIDE coverage of external entities, entities and flows only.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"  -t\tShow CAPEC, ATT&CK and CWE references of attacks and defenses only.\n" +
 			"  -x\tList external entities only.\n" + 
 			"\n" +
 			"diag: Generate security model and ADM diagrams.\n" +
@@ -73,18 +146,75 @@ func TestUninitializedArgsStruct_ShowHelp(t *testing.T) {
 			"    \tGenerate ADM decision graph only.\n" +
 			"  -d string\n" +
 			"    \tOutput directory for diagrams. (default \"./\")\n" +
+			"  -deploy\n" +
+			"    \tGenerate deployment diagram only.\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
 			"  -sm\n" + 
 			"    \tGenerate security model diagram only.\n" +
 			"\n" +
 			"report: Generate security report as markdown file.\n" +
 			"  -d string\n" +
-			"    	Output directory for generated report. (default \"./\")\n"/* +
+			"    	Output directory for generated report. (default \"./\")\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -format string\n" +
+			"    \tReport format. Supported values - markdown,compliance,csv,sarif. (default \"markdown\")\n" +
+			"  -locked\n" +
+			"    \tFail if ADDB content differs from the model's lock file.\n" +
+			"  -owner OWNER\n" +
+			"    \tOnly report on entities and flows owned by OWNER (owner or team).\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"\n" +
+			"lock: Record ADDB content used by the model in a '.smspec.lock' file.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"\n" +
+			"sbom: Import SBOM components as dependencies of a program and list components without an ADDB entry.\n" +
+			"  -e string\n" +
+			"    \tID of the entity (program/system) to import dependencies into. Required with '-i'.\n" +
+			"  -i string\n" +
+			"    \tCycloneDX or SPDX file to import. If not specified, SBOMs referred by entities are checked.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"\n" +
+			"scan: Suggest languages and dependencies by scanning programs' local repositories.\n" +
+			"  -e string\n" +
+			"    \tScan only the repository of this entity.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n" +
+			"  -w\tAdd suggested languages and dependencies to the model file.\n" +
+			"\n" +
+			"schema: Generate JSON schemas for models and ADDB entries in the directory specified as [PATH].\n" +
+			"\n" +
+			"fmt: Rewrite smspec files (models, fragments and ADDB entries) in canonical layout.\n" +
+			"  -check\n" +
+			"    \tList files that are not formatted and fail if there are any.\n" +
+			"  -w\tWrite formatted content back to the files instead of printing it.\n" +
+			"\n" +
+			"init: Create a new model as [PATH] (or 'model.smspec' in directory [PATH]) with a model-level ADM file.\n" +
+			"  -addb string\n" +
+			"    \tADDB used by the model. (default \"~/addb\")\n" +
+			"  -i\tPrompt for external entities, entities and flows to add.\n" +
+			"  -t string\n" +
+			"    \tTemplate to start from. Supported values - batch,blank,mobile-app,web-app. (default \"blank\")\n" +
+			"  -title string\n" +
+			"    \tTitle of the model.\n" +
 			"\n" +
-			"export: Export security model and report to other formats.\n" +
+			"export: Export security model to other formats.\n" +
 			"  -d string\n" +
 			"    	Output directory for exported files. (default \"./\")\n" +
 			"  -f string\n" +
-			"    	Output format. Supported values - json,xml. (default \"json\")\n"*/
+			"    	Output format. Supported values - oscal. (default \"oscal\")\n" +
+			"  -filter EXPR\n" +
+			"    \tOnly include externals, entities and flows matching EXPR (like 'tag:pci and type:program').\n" +
+			"  -profile URL\n" +
+			"    \tURL of the OSCAL profile (or catalog) the plan imports. Defaults to the 'profile' of frameworks in ADDB's control catalogs.\n" +
+			"  -set NAME=VALUE\n" +
+			"    \tOverride the value of a model parameter with NAME=VALUE. Can be repeated.\n"
 
 	assert.Equal(t, out, expected)
 }
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCatalogTag(t *testing.T) {
+	id, kind, ok, err := addb.ParseCatalogTag("@capec:66")
+	assert.Equal(t, []interface{}{"CAPEC-66", addb.CAPEC, true, nil}, []interface{}{id, kind, ok, err})
+	id, _, _, _ = addb.ParseCatalogTag("@CWE:cwe-89")
+	assert.Equal(t, "CWE-89", id)
+	id, kind, _, _ = addb.ParseCatalogTag("@attack:t1059.001")
+	assert.Equal(t, "T1059.001", id)
+	assert.Equal(t, addb.ATTACK, kind)
+
+	_, _, ok, err = addb.ParseCatalogTag("@stride:tampering")
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	_, _, ok, err = addb.ParseCatalogTag("@attack:1190")
+	assert.True(t, ok)
+	assert.EqualError(t, err, "malformed ATT&CK ID in tag '@attack:1190'")
+
+	assert.Equal(t, "https://attack.mitre.org/techniques/T1059/001/", addb.CatalogURL("T1059.001"))
+	assert.Equal(t, "https://cwe.mitre.org/data/definitions/89.html", addb.CatalogURL("CWE-89"))
+}
+
+func TestCatalogLoading(t *testing.T) {
+	dir := createCatalogADDB(t)
+
+	var db addb.ADDB
+	assert.Nil(t, db.Init(dir))
+	catalog, err := db.Catalog()
+	assert.Nil(t, err)
+	assert.True(t, catalog.Has(addb.CAPEC))
+	assert.True(t, catalog.Has(addb.ATTACK))
+	assert.False(t, catalog.Has(addb.CWE))
+
+	entry, found := catalog.Lookup("T1190")
+	assert.True(t, found)
+	assert.Equal(t, addb.CatalogEntry{Id: "T1190", Kind: addb.ATTACK, Name: "Exploit Public-Facing Application",
+		URL: "https://attack.mitre.org/techniques/T1190"}, *entry)
+	_, found = catalog.Lookup("T1000") // revoked
+	assert.False(t, found)
+	entry, _ = catalog.Lookup("CAPEC-7")
+	assert.Equal(t, "Blind SQL Injection", entry.Name)
+	assert.True(t, entry.Deprecated)
+
+	// JSON files that are not STIX bundles are skipped
+	writeFile(t, filepath.Join(dir, addb.CatalogDir), "index.json", `{"type": "collection", "objects": 3}`)
+	writeFile(t, filepath.Join(dir, addb.CatalogDir), "versions.json", `["v1", "v2"]`)
+	var withMetadata addb.ADDB
+	assert.Nil(t, withMetadata.Init(dir))
+	catalog, err = withMetadata.Catalog()
+	assert.Nil(t, err)
+	assert.True(t, catalog.Has(addb.ATTACK))
+	assert.Equal(t, []string{
+		"skipped '" + filepath.Join(dir, addb.CatalogDir, "index.json") + "' - not a STIX bundle",
+		"skipped '" + filepath.Join(dir, addb.CatalogDir, "versions.json") + "' - not a STIX bundle",
+	}, errorMessages(catalog.Warnings))
+
+	writeFile(t, filepath.Join(dir, addb.CatalogDir), "notes.csv", "Title,Text\n")
+	var invalid addb.ADDB
+	assert.Nil(t, invalid.Init(dir))
+	_, err = invalid.Catalog()
+	assert.EqualError(t, err, "invalid catalog '"+filepath.Join(dir, addb.CatalogDir, "notes.csv")+"' - unknown CSV format. Expected CAPEC or CWE CSV files")
+}
+
+func TestTechniqueStat(t *testing.T) {
+	dir := createCatalogModel(t)
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"stat", "-t", filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\tTechnique: CAPEC-7 (Blind SQL Injection) - ATTACKS:1, MITIGATED:0\n"+
+		"\tTechnique: CAPEC-66 (SQL Injection) - ATTACKS:2, MITIGATED:1, DEFENSES:1\n"+
+		"\tTechnique: T1190 (Exploit Public-Facing Application) - ATTACKS:1, MITIGATED:1\n"+
+		"\tTechnique: T9999 - ATTACKS:1, MITIGATED:1\n"+ // not in catalog
+		"\tTechnique: CWE-89 - ATTACKS:0, MITIGATED:0, DEFENSES:1\n")
+	assert.Contains(t, out, "WARNING: 'CAPEC-7' (tag of attack 'Guess table names') is deprecated\n")
+	assert.Contains(t, out, "WARNING: 'T9999' (tag of attack 'Inject queries') is not in ADDB's ATT&CK catalog\n")
+	assert.Contains(t, out, "WARNING: attack 'Inject queries' has a malformed CAPEC ID in tag '@capec:sqli'\n")
+	assert.NotContains(t, out, "Entity: Backend") // only references are shown
+}
+
+func TestThreatReferencesReport(t *testing.T) {
+	dir := createCatalogModel(t)
+	outDir := t.TempDir()
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "## Threat References\n\n")
+	assert.Contains(t, string(content), ""+
+		"| Reference | Name | Attacks (mitigated) | Defenses |\n"+
+		"|---|---|---|---|\n"+
+		"| [CAPEC-7](https://capec.mitre.org/data/definitions/7.html) | Blind SQL Injection | Guess table names (0/1) |  |\n"+
+		"| [CAPEC-66](https://capec.mitre.org/data/definitions/66.html) | SQL Injection | Guess table names, Inject queries (1/2) | Use prepared statements |\n"+
+		"| [T1190](https://attack.mitre.org/techniques/T1190) | Exploit Public-Facing Application | Inject queries (1/1) |  |\n"+
+		"| [T9999](https://attack.mitre.org/techniques/T9999/) |  | Inject queries (1/1) |  |\n"+
+		"| [CWE-89](https://cwe.mitre.org/data/definitions/89.html) |  |  | Use prepared statements |\n")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// ADDB with an ATT&CK STIX bundle and a CAPEC CSV file
+func createCatalogADDB(t *testing.T) string {
+	dir := t.TempDir()
+	writeADDBEntry(t, dir, "cache.smspec", "cache", "program")
+	writeFile(t, filepath.Join(dir, addb.CatalogDir), "enterprise-attack.json", `{
+  "type": "bundle",
+  "objects": [
+    {"type": "attack-pattern", "name": "Exploit Public-Facing Application",
+     "external_references": [{"source_name": "mitre-attack", "external_id": "T1190", "url": "https://attack.mitre.org/techniques/T1190"}]},
+    {"type": "attack-pattern", "name": "Old technique", "revoked": true,
+     "external_references": [{"source_name": "mitre-attack", "external_id": "T1000"}]},
+    {"type": "course-of-action", "name": "Update software",
+     "external_references": [{"source_name": "mitre-attack", "external_id": "M1051"}]}
+  ]
+}`)
+	writeFile(t, filepath.Join(dir, addb.CatalogDir), "capec.csv", "'ID,Name,Abstraction,Status\n"+
+		"66,SQL Injection,Standard,Draft\n"+
+		"7,Blind SQL Injection,Detailed,Deprecated\n")
+	return dir
+}
+
+// Model with attacks and defenses tagged with catalog references
+func createCatalogModel(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := createCatalogADDB(t)
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+addbDir+`
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    adm:
+      - |
+        Model: Backend
+          @capec:66 @attack:T1190 @attack:T9999 @capec:sqli
+          Attack: Inject queries
+            When queries are built from input
+          @cwe:89 @capec:CAPEC-66
+          Defense: Use prepared statements
+            When queries are built from input
+          @capec:66 @capec:7
+          Attack: Guess table names
+            When errors are verbose
+`)
+	return dir
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseControlTag(t *testing.T) {
+	framework, id, ok, err := addb.ParseControlTag("@control:NIST-800-53:AC-2(1)")
+	assert.Equal(t, []interface{}{"nist-800-53", "AC-2(1)", true, nil}, []interface{}{framework, id, ok, err})
+	_, _, ok, _ = addb.ParseControlTag("@capec:66")
+	assert.False(t, ok)
+	_, _, ok, err = addb.ParseControlTag("@control:A.8.24")
+	assert.True(t, ok)
+	assert.EqualError(t, err, "malformed control reference '@control:A.8.24'. Expected '@control:<framework>:<control ID>'")
+
+	text, tags := addb.MitigationControls("Rotate keys @control:iso-27001:A.8.24 every 90 days")
+	assert.Equal(t, "Rotate keys every 90 days", text)
+	assert.Equal(t, []string{"@control:iso-27001:A.8.24"}, tags)
+}
+
+func TestControlCatalog(t *testing.T) {
+	dir := createControlsADDB(t)
+	writeFile(t, filepath.Join(dir, "soc2"), "controls.yaml", `- {framework: soc2, name: SOC 2, controls: [{id: CC6.1, title: Logical access security}]}
+- {framework: ISO-27001, controls: [{id: A.5.16, title: Identity management}]}
+`)
+
+	var db addb.ADDB
+	assert.Nil(t, db.Init(dir))
+	assert.Len(t, db.Frameworks(), 2)
+	control, found := db.LookupControl("iso-27001", "a.5.16") // frameworks in more than one catalog are combined
+	assert.True(t, found)
+	assert.Equal(t, "Identity management", control.Title)
+	assert.Len(t, db.Frameworks()[0].Controls, 3)
+
+	writeFile(t, filepath.Join(dir, "soc2"), "controls.yaml", "- {framework: iso-27001, controls: [{id: A.8.24}]}\n")
+	var invalid addb.ADDB
+	assert.EqualError(t, invalid.Init(dir), "control catalog '"+filepath.Join(dir, "soc2", "controls.yaml")+"' lists control 'A.8.24' of framework 'iso-27001' more than once")
+}
+
+func TestComplianceReport(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+createControlsADDB(t)+`
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    mitigations:
+      - Rotate keys every 90 days @control:iso-27001:A.8.24
+    adm:
+      - |
+        Model: Backend
+          Attack: Read traffic
+            When traffic is not encrypted
+          @control:iso-27001:A.8.24 @control:nist-800-53:SC-8 @control:iso-27001:A.9.99
+          Defense: Encrypt traffic
+            When traffic is not encrypted
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: backend
+    receiver: backend
+    adm:
+      - |
+        Model: Orders
+          Attack: Read orders
+            When orders are sent over the network
+          @control:iso-27001:A.8.24
+          Defense: Encrypt traffic
+            When orders are sent over the network
+`)
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"report", "-format", "compliance", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "WARNING: '@control:iso-27001:A.9.99' (tag of defense 'Encrypt traffic') refers to a control that is not in ADDB's control catalogs\n")
+
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.compliance.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "## ISO/IEC 27001:2022 Annex A (`iso-27001`)\n\n"+
+		"| Control | Title | Defense/Mitigation | Implemented by |\n"+
+		"|---|---|---|---|\n"+
+		"| A.5.15 | Access control | **none** | |\n"+
+		"| A.8.24 | Use of cryptography | Encrypt traffic (defense) | `entities → backend`, `flows → orders` |\n"+
+		"| A.8.24 | Use of cryptography | Rotate keys every 90 days (mitigation) | `entities → backend` |\n"+
+		"| A.9.99 |  | Encrypt traffic (defense) | `entities → backend` |\n\n"+ // not in catalog
+		"### Controls without defenses\n\n"+
+		"* A.5.15 - Access control\n")
+	assert.Contains(t, string(content), "## `nist-800-53`\n\n"+
+		"| Control | Title | Defense/Mitigation | Implemented by |\n"+
+		"|---|---|---|---|\n"+
+		"| SC-8 |  | Encrypt traffic (defense) | `entities → backend` |\n")
+	_, err = os.Stat(filepath.Join(outDir, "report", "Store.sm.md")) // only the compliance report is generated
+	assert.True(t, os.IsNotExist(err))
+
+	err = sendToParseArgs([]string{"report", "-format", "pdf", filepath.Join(dir, "model.smspec")})
+	assert.EqualError(t, err, "unsupported report format 'pdf'. Supported formats - markdown, compliance, csv, sarif")
+}
+
+func TestReportWithoutControlTags(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Store
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    mitigations:
+      - Rotate keys every 90 days @control:iso-27001:A.8.24
+      - "@control:iso-27001:A.5.15 @control:soc2:CC6.1"
+`)
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	harness.ReadAndRelease()
+	assert.Nil(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "* Rotate keys every 90 days\n")
+	assert.Contains(t, string(content), "* iso-27001:A.5.15, soc2:CC6.1\n") // mitigation with tags only
+	assert.NotContains(t, string(content), "@control:")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// ADDB with a control catalog for ISO 27001
+func createControlsADDB(t *testing.T) string {
+	dir := t.TempDir()
+	writeADDBEntry(t, dir, "cache.smspec", "cache", "program")
+	writeFile(t, dir, "controls.yaml", `- framework: iso-27001
+  name: ISO/IEC 27001:2022 Annex A
+  profile: https://grc.example.com/profiles/iso-27001.json
+  controls:
+    - {id: A.5.15, title: Access control}
+    - {id: A.8.24, title: Use of cryptography}
+`)
+	return dir
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/objmodel"
+	"securitymodel/yamlmodel"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployment(t *testing.T) {
+	dir := t.TempDir()
+	writeDeployedModel(t, dir)
+	model, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+
+	assert.Len(t, model.Deployment, 1)
+	var ids []string
+	for _, n := range model.Nodes() {
+		ids = append(ids, n.GetID())
+	}
+	assert.Equal(t, []string{"cluster", "payments", "backend-pod"}, ids)
+
+	// Programs inherit ADM of the node they run on and of nodes it is nested in
+	backend := model.Entities["backend"].(*objmodel.Program)
+	assert.Equal(t, "backend-pod", backend.GetNode().GetID())
+	adm := backend.GetADM()
+	assert.Contains(t, adm, "backend.deployment.backend-pod")
+	assert.Equal(t, []string{filepath.Join(dir, "escape.adm")}, adm["backend.deployment.cluster"])
+	assert.Contains(t, model.GetADM(), "sm.entities.backend.deployment.cluster")
+	assert.Nil(t, model.Entities["frontend"].(*objmodel.Program).GetNode())
+
+	// Written back with the model
+	m := model.ToYaml(dir)
+	assert.Equal(t, yamlmodel.Cluster, m.Deployment[0].Type)
+	assert.Equal(t, []string{"escape.adm"}, m.Deployment[0].ADM)
+	assert.Equal(t, []string{"backend"}, m.Deployment[0].Nodes[0].Nodes[0].Entities)
+}
+
+func TestInvalidDeployment(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "addb"), 0700))
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+filepath.Join(dir, "addb")+`
+entities:
+  - {id: backend, type: program, name: Backend, description: Business logic, adm: []}
+  - {id: admin, type: human, name: Admin, description: Operator, adm: []}
+deployment:
+  - id: host
+    type: host
+    name: Host
+    entities: [backend, admin, db]
+    nodes:
+      - {id: vm, type: vm, name: VM, entities: [backend]}
+      - {id: host, type: container, name: Container}
+  - {id: rack, type: datacenter, name: Rack}
+`)
+	_, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Contains(t, errorMessages(errs), "deployment node 'rack' has unknown type 'datacenter'. Supported types - zone, host, vm, cluster, namespace, container")
+	assert.Contains(t, errorMessages(errs), "deployment node 'host' lists 'admin', which is not a program")
+	assert.Contains(t, errorMessages(errs), "deployment node 'host' lists unknown entity 'db'")
+	assert.Contains(t, errorMessages(errs), "entity 'backend' is deployed on both 'host' and 'vm'")
+	assert.Contains(t, errorMessages(errs), "deployment node 'host' is defined more than once")
+}
+
+func TestDeploymentDiagramAndReport(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeDeployedModel(t, dir)
+
+	err := sendToParseArgs([]string{"diag", "-deploy", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "Store.deployment.dot"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "  subgraph cluster_node_cluster{\n")
+	assert.Contains(t, string(content), "<<b>Production<br></br>cluster</b><br/>cluster | A: 1 | D: 0>")
+	assert.Contains(t, string(content), "      subgraph cluster_node_backend_pod{\n") // nested in 'payments'
+	_, err = os.Stat(filepath.Join(outDir, "Store.sm.dot"))                           // only the deployment diagram is generated
+	assert.True(t, os.IsNotExist(err))
+
+	err = sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err = os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "[graphviz file](resources/Store.deployment.dot)")
+	assert.Contains(t, string(content), "### Deployment\n\n"+
+		"* Production cluster (cluster)\n"+
+		"  * Payments (namespace)\n"+
+		"    * Backend pod (container) - Backend\n")
+	assert.Contains(t, string(content), "* Escape container (under `entities → backend → deployment → cluster`)")
+	assert.NotContains(t, string(content), "(under `entities → frontend → deployment")
+	_, err = os.Stat(filepath.Join(outDir, "report", "resources", "Store.deployment.dot"))
+	assert.Nil(t, err)
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Model with 'backend' deployed in a container nested in a cluster, and
+// 'frontend' not deployed
+func writeDeployedModel(t *testing.T, dir string) {
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "addb"), 0700))
+	writeFile(t, dir, "escape.adm", `Model: Cluster
+  Attack: Escape container
+    When workloads run as root
+`)
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+filepath.Join(dir, "addb")+`
+entities:
+  - {id: backend, type: program, name: Backend, description: Business logic, adm: []}
+  - {id: frontend, type: program, name: Frontend, description: Web pages, adm: []}
+deployment:
+  - id: cluster
+    type: cluster
+    name: Production cluster
+    adm: [escape.adm]
+    nodes:
+      - id: payments
+        type: namespace
+        name: Payments
+        nodes:
+          - id: backend-pod
+            type: container
+            name: Backend pod
+            entities: [backend]
+`)
+}
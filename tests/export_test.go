@@ -0,0 +1,151 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"schema"
+	"securitymodel/oscal"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSCALHelpers(t *testing.T) {
+	assert.Equal(t, "ac-2.1", oscal.ControlId("AC-2(1)"))
+	assert.Equal(t, "a.8.24", oscal.ControlId("A.8.24"))
+	assert.Equal(t, oscal.UUID("adsm:Store", "sm"), oscal.UUID("adsm:Store", "sm"))
+	assert.NotEqual(t, oscal.UUID("adsm:Store", "sm"), oscal.UUID("adsm:Shop", "sm"))
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", oscal.UUID("adsm:Store", "sm"))
+}
+
+func TestExportOSCAL(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+createControlsADDB(t)+`
+externals:
+  - {id: user, type: human, name: User, description: Shopper}
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    data-classification: restricted
+    mitigations:
+      - Keys are rotated every 90 days @control:iso-27001:A.8.24
+    adm:
+      - |
+        Model: Backend
+          Attack: Read traffic
+            When traffic is not encrypted
+          @control:iso-27001:A.8.24
+          Defense: Encrypt traffic
+            When traffic is not encrypted
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: user
+    receiver: backend
+    adm: []
+`)
+
+	err := sendToParseArgs([]string{"export", "-f", "oscal", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "Store.ssp.json"))
+	assert.Nil(t, err)
+	assert.Empty(t, validateOSCAL(t, content))
+	var document oscal.Document
+	assert.Nil(t, json.Unmarshal(content, &document))
+	ssp := document.SSP
+
+	assert.Equal(t, "https://grc.example.com/profiles/iso-27001.json", ssp.ImportProfile.Href) // from the control catalog
+	assert.Equal(t, "Store", ssp.SystemCharacteristics.SystemName)
+	assert.Equal(t, "fips-199-high", ssp.SystemCharacteristics.SystemInformation.InformationTypes[0].ConfidentialityImpact.Base)
+	assert.Len(t, ssp.SystemImplementation.Users, 1) // humans are users
+	assert.Equal(t, "User", ssp.SystemImplementation.Users[0].Title)
+
+	components := ssp.SystemImplementation.Components
+	assert.Len(t, components, 3)
+	assert.Equal(t, []string{"this-system", "software", "interconnection"}, []string{components[0].Type, components[1].Type, components[2].Type})
+	assert.Equal(t, "Backend", components[1].Title)
+	assert.Equal(t, []oscal.Link{{Href: "#" + ssp.SystemImplementation.Users[0].UUID, Rel: "sender"}, {Href: "#" + components[1].UUID, Rel: "receiver"}}, components[2].Links)
+
+	assert.Equal(t, []oscal.ImplementedRequirement{{
+		UUID:      ssp.ControlImplementation.ImplementedRequirements[0].UUID,
+		ControlId: "a.8.24",
+		Props:     []oscal.Property{{Name: "framework", Ns: oscal.Namespace, Value: "iso-27001"}},
+		ByComponents: []oscal.ByComponent{{
+			ComponentUUID: components[1].UUID,
+			UUID:          ssp.ControlImplementation.ImplementedRequirements[0].ByComponents[0].UUID,
+			Description:   "Defense: Encrypt traffic\nMitigation: Keys are rotated every 90 days",
+		}},
+	}}, ssp.ControlImplementation.ImplementedRequirements)
+
+	err = sendToParseArgs([]string{"export", "-f", "xml", filepath.Join(dir, "model.smspec")})
+	assert.EqualError(t, err, "unsupported export format 'xml'. Supported formats - oscal")
+}
+
+func TestExportOSCALWithoutHumans(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+createControlsADDB(t)+`
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    mitigations:
+      - Keys are rotated every 90 days @control:nist-800-53:SC-12
+`)
+
+	err := sendToParseArgs([]string{"export", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.EqualError(t, err, "cannot export security model 'Store' to OSCAL - framework 'nist-800-53' has no 'profile' in ADDB's control catalogs. Add one or pass '-profile'")
+
+	err = sendToParseArgs([]string{"export", "-profile", "https://grc.example.com/profiles/moderate.json", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "Store.ssp.json"))
+	assert.Nil(t, err)
+	assert.Empty(t, validateOSCAL(t, content))
+	var document oscal.Document
+	assert.Nil(t, json.Unmarshal(content, &document))
+	assert.Equal(t, "https://grc.example.com/profiles/moderate.json", document.SSP.ImportProfile.Href)
+	assert.Len(t, document.SSP.SystemImplementation.Users, 1)
+	assert.Equal(t, "System owner", document.SSP.SystemImplementation.Users[0].Title)
+
+	writeFile(t, dir, "model.smspec", `title: Store
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    mitigations:
+      - Keys are rotated every 90 days
+`)
+	err = sendToParseArgs([]string{"export", "-profile", "https://grc.example.com/profiles/moderate.json", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.EqualError(t, err, "cannot export security model 'Store' to OSCAL - no defenses or mitigations refer to controls (see '@control:' tags)")
+}
+
+func TestOSCALSchemaValidation(t *testing.T) { // checks the schema used by export tests
+	errs := errorMessages(validateOSCAL(t, []byte(`{"system-security-plan": {"uuid": "not-a-uuid", "extra": true}}`)))
+	assert.Contains(t, errs, "line 1: oscal-ssp-oscal-ssp:system-security-plan - 'not-a-uuid' in 'uuid' does not match '^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[45][0-9A-Fa-f]{3}-[89ABab][0-9A-Fa-f]{3}-[0-9A-Fa-f]{12}$'")
+	assert.Contains(t, errs, "line 1: oscal-ssp-oscal-ssp:system-security-plan - missing 'metadata'")
+	assert.Contains(t, errs, "line 1: oscal-ssp-oscal-ssp:system-security-plan - unknown field 'extra'")
+
+	errs = errorMessages(validateOSCAL(t, []byte(`{"system-security-plan": {"system-implementation": {"users": null, "components": []}}}`)))
+	assert.Contains(t, errs, "line 1: oscal-ssp-oscal-ssp:system-implementation - 'users' must be a list")
+	assert.Contains(t, errs, "line 1: oscal-ssp-oscal-ssp:system-implementation - 'components' must have at least 1 item(s)")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Errors in an OSCAL document, validated against the SSP JSON schema in
+// 'examples/oscal' (a subset of NIST's schema covering exported fields).
+func validateOSCAL(t *testing.T, content []byte) []error {
+	schemaContent, err := os.ReadFile(filepath.Join("examples", "oscal", "oscal_ssp_schema.json"))
+	assert.Nil(t, err)
+	return schema.Validate(content, schemaContent)
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/filter"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	_, err := filter.Parse("tag:pci and (type:program or not id:db*)")
+	assert.Nil(t, err)
+	_, err = filter.Parse(`name:"Order service"`)
+	assert.Nil(t, err)
+
+	_, err = filter.Parse("tag:pci and")
+	assert.EqualError(t, err, "invalid filter 'tag:pci and' - unexpected end of expression")
+	_, err = filter.Parse("(tag:pci or tag:gdpr")
+	assert.EqualError(t, err, "invalid filter '(tag:pci or tag:gdpr' - missing ')'")
+	_, err = filter.Parse("pci")
+	assert.EqualError(t, err, "invalid filter 'pci' - 'pci' is not a term like 'tag:pci'")
+	_, err = filter.Parse("label:pci")
+	assert.EqualError(t, err, "invalid filter 'label:pci' - unknown key 'label'. Supported keys - id, name, type, scope, tag, owner, team")
+	_, err = filter.Parse("tag:pci tag:gdpr")
+	assert.EqualError(t, err, "invalid filter 'tag:pci tag:gdpr' - unexpected 'tag:gdpr'")
+}
+
+func TestApplyFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeTaggedModel(t, dir)
+	model, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{"pci", "internet-facing"}, model.Entities["backend"].GetTags())
+	assert.Equal(t, []string{"PCI"}, model.Externals["user"].GetTags())
+
+	selected := func(text string) (ids []string) {
+		e, err := filter.Parse(text)
+		assert.Nil(t, err)
+		m := filter.Apply(*model, e)
+		for id := range m.Externals {
+			ids = append(ids, id)
+		}
+		for id := range m.Entities {
+			ids = append(ids, id)
+		}
+		for id := range m.Flows {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return
+	}
+	assert.Equal(t, []string{"backend", "orders", "user"}, selected("tag:pci")) // tags are matched ignoring case
+	assert.Equal(t, []string{"backend"}, selected("tag:pci and type:program"))
+	assert.Equal(t, []string{"backend", "frontend"}, selected("id:*end and not scope:external"))
+	assert.Equal(t, []string{"frontend", "orders"}, selected("not tag:internet-* and (scope:internal)"))
+	assert.Equal(t, []string{"frontend"}, selected("tag:*dmz")) // wildcards match '/'
+	assert.Equal(t, []string{"frontend"}, selected("tag:zone/*"))
+	assert.Equal(t, []string{"backend"}, selected("team:payments or owner:nobody"))
+}
+
+func TestFilterCommands(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeTaggedModel(t, dir)
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"stat", "-e", "-filter", "tag:pci", filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\tEntity: Backend\n")
+	assert.NotContains(t, out, "Frontend")
+	assert.NotContains(t, out, "External Entity") // '-e' is a filter too
+
+	err = sendToParseArgs([]string{"diag", "-sm", "-filter", "tag:pci", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "Store.sm.dot"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "backend")
+	assert.NotContains(t, string(content), "frontend")
+
+	err = sendToParseArgs([]string{"report", "-filter", "not tag:pci", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err = os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "* Inject scripts (under `entities → frontend`)")
+	assert.NotContains(t, string(content), "Run as root")
+
+	err = sendToParseArgs([]string{"export", "-filter", "tag:", filepath.Join(dir, "model.smspec")})
+	assert.EqualError(t, err, "invalid filter 'tag:' - 'tag:' is not a term like 'tag:pci'")
+}
+
+func TestDiagWithFilter(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeTaggedModel(t, dir)
+
+	err := sendToParseArgs([]string{"diag", "-sm", "-filter", "type:flow", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "Store.sm.dot"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "user -> backend[")
+	assert.Contains(t, string(content), "  user[label=\"User\"") // sender and receiver of the flow are drawn as items of the model
+	assert.Contains(t, string(content), "    backend[label=\"{Backend}")
+	assert.NotContains(t, string(content), "frontend")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"stat", "-filter", "type:flow", filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\tFlow: Orders\n")
+	assert.NotContains(t, out, "Entity:") // 'stat' lists matching items only
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Model with items in and out of PCI scope
+func writeTaggedModel(t *testing.T, dir string) {
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "addb"), 0700))
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+filepath.Join(dir, "addb")+`
+externals:
+  - {id: user, type: human, name: User, description: Shopper, tags: [PCI]}
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    tags: [pci, internet-facing]
+    team: payments
+    adm:
+      - |
+        Model: Backend
+          Attack: Run as root
+            When service runs
+  - id: frontend
+    type: program
+    name: Frontend
+    description: Web pages
+    tags: [zone/dmz]
+    adm:
+      - |
+        Model: Frontend
+          Attack: Inject scripts
+            When input is not escaped
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    tags: [pci]
+    sender: user
+    receiver: backend
+    adm: []
+`)
+}
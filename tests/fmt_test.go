@@ -0,0 +1,230 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"securitymodel/yamlmodel"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFormatModel(t *testing.T) {
+	formatted, err := yamlmodel.Format([]byte(`# yaml-language-server: $schema=../schemas/model-schema.json
+title: "Store"
+design-document: design.md
+parameters: {replicas: 3, env: staging}
+adm: ["adm/store.adm"]
+addb: "../addb"
+entities:
+  - {id: browser, type: program, name: Browser, description: "Web browser", roles: [write, read], adm: []} # stock browser
+  # Business logic
+  - id: backend
+    name: Backend
+    type: program
+    adm: null
+    description: |
+      Processes orders.
+      Runs ${replicas} instances.
+    languages: [addb:lang.go]
+flows:
+  - id: orders
+    adm: []
+    receiver: backend
+    sender: browser
+    name: Orders
+    description: Orders placed by users
+`))
+	assert.Nil(t, err)
+	expected := `# yaml-language-server: $schema=../schemas/model-schema.json
+design-document: design.md
+title: Store
+addb: ../addb
+adm: [adm/store.adm]
+parameters:
+  env: staging
+  replicas: 3
+
+entities:
+  - id: browser # stock browser
+    type: program
+    name: Browser
+    description: Web browser
+    roles: [read, write]
+    adm: []
+  # Business logic
+  - id: backend
+    type: program
+    name: Backend
+    description: |
+      Processes orders.
+      Runs ${replicas} instances.
+    languages: ['addb:lang.go']
+    adm: null
+
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: browser
+    receiver: backend
+    adm: []
+`
+	assert.Equal(t, expected, string(formatted))
+
+	// Formatting is stable
+	again, err := yamlmodel.Format(formatted)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(again))
+}
+
+func TestFormatADDBEntries(t *testing.T) {
+	formatted, err := yamlmodel.Format([]byte(`# yaml-language-server: $schema=../component-schema.json
+
+---
+id: flow.https
+name: HTTPS
+description: HTTP over TLS
+type: flow
+protocol:
+  - addb:flow.http
+  - addb:flow.tls
+adm: []
+...
+---
+# Uses TLS 1.3 only
+id: flow.tls
+type: flow
+adm: [tls.adm]
+name: TLS
+description: Transport layer security
+...`))
+	assert.Nil(t, err)
+	assert.Equal(t, `# yaml-language-server: $schema=../component-schema.json
+
+---
+id: flow.https
+type: flow
+name: HTTPS
+description: HTTP over TLS
+protocol: ['addb:flow.http', 'addb:flow.tls']
+adm: []
+...
+
+---
+# Uses TLS 1.3 only
+id: flow.tls
+type: flow
+name: TLS
+description: Transport layer security
+adm: [tls.adm]
+...
+`, string(formatted))
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, file := range []string{"examples/simple.smspec", "examples/addb/languages/common.smspec"} {
+		content, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		formatted, err := yamlmodel.Format(content)
+		assert.Nil(t, err)
+		assert.Equal(t, decodeDocuments(t, content), decodeDocuments(t, formatted), file)
+
+		again, err := yamlmodel.Format(formatted)
+		assert.Nil(t, err)
+		assert.Equal(t, string(formatted), string(again), file)
+	}
+
+	// Line comments of lists written in flow style stay on their line
+	content, err := os.ReadFile("examples/simple.smspec")
+	assert.Nil(t, err)
+	formatted, err := yamlmodel.Format(content)
+	assert.Nil(t, err)
+	assert.Contains(t, string(formatted), "    protocol: [http, tls, tcp, ip] # We are specifying a stack here.\n")
+	assert.NotContains(t, string(formatted), "adm: null # We are specifying a stack here.")
+
+	// Literal blocks are retained as is, including spaces at the end of lines
+	content, err = os.ReadFile("examples/addb/languages/common.smspec")
+	assert.Nil(t, err)
+	formatted, err = yamlmodel.Format(content)
+	assert.Nil(t, err)
+	assert.Contains(t, string(formatted), "  - |\n    If you use string inputs to calculate file paths, make sure you sanitize them to avoid path-traversal attacks. \n")
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := yamlmodel.Format([]byte("title: Store\nentities:\n  - id: backend\n    langauges: [addb:lang.go]\n"))
+	assert.EqualError(t, err, "yaml: unmarshal errors:\n  line 4: unknown field 'langauges', did you mean 'languages'?")
+
+	_, err = yamlmodel.Format([]byte("- not a model\n"))
+	assert.EqualError(t, err, "expected a security model or ADDB entries")
+}
+
+func TestFmtCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: Store\naddb: addb\nentities:\n  - {id: backend, type: program, name: Backend, description: Business logic, adm: []}\n")
+	writeADDBEntry(t, dir, "addb/cache.smspec", "cache", "program")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"fmt", "-check", dir})
+	out, _ := harness.ReadAndRelease()
+	assert.EqualError(t, err, "2 file(s) are not formatted. Use 'adsm fmt -w' to format them")
+	assert.Contains(t, out, filepath.Join(dir, "addb/cache.smspec")+" is not formatted\n"+filepath.Join(dir, "model.smspec")+" is not formatted\n")
+
+	harness.Hook()
+	err = sendToParseArgs([]string{"fmt", "-w", dir})
+	out, _ = harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Formatted "+filepath.Join(dir, "model.smspec"))
+	content, err := os.ReadFile(filepath.Join(dir, "model.smspec"))
+	assert.Nil(t, err)
+	assert.Equal(t, "title: Store\naddb: addb\n\nentities:\n  - id: backend\n    type: program\n    name: Backend\n    description: Business logic\n    adm: []\n", string(content))
+	content, err = os.ReadFile(filepath.Join(dir, "addb/cache.smspec"))
+	assert.Nil(t, err)
+	assert.Equal(t, "---\nid: cache\ntype: program\nname: cache\ndescription: Test entry\nadm: []\n...\n", string(content))
+
+	harness.Hook()
+	err = sendToParseArgs([]string{"fmt", "-check", dir})
+	out, _ = harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.NotContains(t, out, "not formatted")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Values of all YAML documents in 'content'. Lists that 'fmt' sorts are sorted.
+func decodeDocuments(t *testing.T, content []byte) (docs []interface{}) {
+	var sortLists func(v interface{})
+	sortLists = func(v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for key, item := range value {
+				if list, ok := item.([]interface{}); ok && (key == "languages" || key == "dependencies" || key == "roles") {
+					sort.Slice(list, func(i, j int) bool { return list[i].(string) < list[j].(string) })
+				}
+				sortLists(item)
+			}
+		case []interface{}:
+			for _, item := range value {
+				sortLists(item)
+			}
+		}
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			return
+		}
+		if doc != nil {
+			sortLists(doc)
+			docs = append(docs, doc)
+		}
+	}
+}
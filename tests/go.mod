@@ -8,12 +8,16 @@ replace securitymodel => ../src/securitymodel
 
 replace addb => ../src/addb
 
+replace schema => ../src/schema
+
 replace libadm => ../../adm/src/libadm
 
 require (
+	addb v0.0.0-00010101000000-000000000000
 	args v0.0.0-00010101000000-000000000000
 	github.com/stretchr/testify v1.8.1
 	libadm v0.0.0-00010101000000-000000000000
+	schema v0.0.0-00010101000000-000000000000
 	securitymodel v0.0.0-00010101000000-000000000000
 )
 
@@ -29,5 +33,4 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/image v0.5.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	addb v0.0.0-00010101000000-000000000000 // indirect
 )
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	smloaders "securitymodel/loaders"
+	"securitymodel/objmodel"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelWithIncludes(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "addb"), 0700))
+	writeFile(t, dir, "model.smspec", `title: Product
+addb: `+filepath.Join(dir, "addb")+`
+adm: [product.adm]
+include: [platform/platform.smspec, services/*.smspec]
+entities:
+  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    adm: [frontend.adm]
+`)
+	writeFile(t, dir, "platform/platform.smspec", `adm: [platform.adm]
+include: [storage/db.smspec]
+externals:
+  - id: user
+    type: human
+    name: User
+    description: Product user
+    interface: frontend
+`)
+	writeFile(t, dir, "platform/storage/db.smspec", `entities:
+  - id: db
+    type: program
+    name: Database
+    description: Stores records
+    adm: [db.adm]
+`)
+	writeFile(t, dir, "services/backend.smspec", `entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    adm: [adm/backend.adm]
+flows:
+  - id: query
+    name: Query
+    description: Backend reads records
+    sender: backend
+    receiver: db
+    adm: [query.adm]
+`)
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	assert.Len(t, m.Externals, 1)
+	assert.Len(t, m.Entities, 3)
+	assert.Len(t, m.Flows, 1)
+
+	assert.Equal(t, []string{dir + "/frontend.adm"}, m.Entities["frontend"].GetADM()["frontend"])
+	assert.Equal(t, []string{filepath.Join(dir, "platform/storage") + "/db.adm"}, m.Entities["db"].GetADM()["db"])
+	assert.Equal(t, []string{filepath.Join(dir, "services") + "/adm/backend.adm"}, m.Entities["backend"].GetADM()["backend"])
+	assert.Equal(t, []string{filepath.Join(dir, "services") + "/query.adm"}, m.Flows["query"].GetADM()["query"])
+	assert.Equal(t, []string{dir + "/product.adm", dir + "/platform/platform.adm"}, m.GetADM()["sm"])
+}
+
+func TestModelWithDuplicateIdsAcrossIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Product
+include: [a.smspec, b.smspec]
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+`)
+	writeFile(t, dir, "a.smspec", "entities:\n  - {id: backend, type: program, name: Backend, description: Copy}\n")
+	writeFile(t, dir, "b.smspec", "include: [a.smspec]\nexternals:\n  - {id: backend, type: human, name: Backend, description: Same ID}\n")
+
+	_, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Contains(t, errorMessages(errs), ("'backend' is defined in both the main model and '" + filepath.Join(dir, "a.smspec") + "'"))
+	assert.Contains(t, errorMessages(errs), ("'backend' is defined in both the main model and '" + filepath.Join(dir, "b.smspec") + "'"))
+}
+
+func TestModelWithDiamondIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: Product\naddb: "+t.TempDir()+"\ninclude: [b.smspec, c.smspec]\n")
+	writeFile(t, dir, "b.smspec", "include: [d.smspec]\nentities:\n  - {id: backend, type: program, name: Backend, description: Business logic, adm: []}\n")
+	writeFile(t, dir, "c.smspec", "include: [d.smspec]\nentities:\n  - {id: frontend, type: program, name: Frontend, description: Web pages, adm: []}\n")
+	writeFile(t, dir, "d.smspec", "externals:\n  - {id: user, type: human, name: User, description: Customer}\nadm: [adm/shared.adm]\n")
+	writeFile(t, dir, "adm/shared.adm", "Model: Shared\n  Attack: Phish users\n    When users click links\n")
+
+	model, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	assert.Len(t, model.Externals, 1) // 'd.smspec' is merged once
+	assert.Len(t, model.Entities, 2)
+	assert.Len(t, model.GetADM()["sm"], 1)
+}
+
+func TestModelWithIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: Product\ninclude: [a.smspec, missing.smspec]\n")
+	writeFile(t, dir, "a.smspec", "include: [b.smspec]\n")
+	writeFile(t, dir, "b.smspec", "include: [a.smspec]\n")
+
+	_, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	a, b := filepath.Join(dir, "a.smspec"), filepath.Join(dir, "b.smspec")
+	assert.Contains(t, errorMessages(errs), ("include cycle - " + a + " -> " + b + " -> " + a))
+	assert.Contains(t, errorMessages(errs)[1], "cannot include '"+filepath.Join(dir, "missing.smspec")+"'")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+func loadModelFile(t *testing.T, path string) (*objmodel.SecurityModel, []error) {
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	var l smloaders.Loader
+	return l.LoadSecurityModel(string(content), filepath.Dir(path))
+}
+
+func errorMessages(errs []error) (messages []string) {
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/objmodel"
+	"securitymodel/templates"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitFromTemplates(t *testing.T) {
+	dir := t.TempDir()
+	addbDir := createTemplateADDB(t, dir)
+
+	for _, template := range templates.Names() {
+		err := sendToParseArgs([]string{"init", "-t", template, "-title", "Store", "-addb", addbDir, filepath.Join(dir, template)})
+		assert.Nil(t, err)
+
+		m, errs := loadModelFile(t, filepath.Join(dir, template, "model.smspec"))
+		assert.Empty(t, errs, template)
+		assert.Equal(t, "Store", m.Title)
+		assert.FileExists(t, filepath.Join(dir, template, "adm/model.adm"))
+		if template != "blank" {
+			assert.NotEmpty(t, m.Entities, template)
+			assert.NotEmpty(t, m.Flows, template)
+		}
+
+		// Generated models are formatted
+		harness := output_interceptor{}
+		harness.Hook()
+		err = sendToParseArgs([]string{"fmt", "-check", filepath.Join(dir, template)})
+		harness.ReadAndRelease()
+		assert.Nil(t, err, template)
+	}
+}
+
+func TestInitWithMissingADDBEntries(t *testing.T) {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	writeADDBEntry(t, addbDir, "flows.smspec", "flow.https", "flow")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"init", "-t", "web-app", "-addb", addbDir, filepath.Join(dir, "store.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "WARNING: 'addb:lang.go' is not in ADDB. Removed it from the model.")
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "store.smspec"))
+	assert.Empty(t, errs)
+	assert.Equal(t, "Security model", m.Title)
+	assert.Contains(t, m.Flows["call-api"].GetProtocol(), "addb:flow.https")
+
+	// Existing models are not overwritten
+	err = sendToParseArgs([]string{"init", filepath.Join(dir, "store.smspec")})
+	assert.EqualError(t, err, "'"+filepath.Join(dir, "store.smspec")+"' already exists")
+}
+
+func TestInitInteractive(t *testing.T) {
+	dir := t.TempDir()
+	addbDir := createTemplateADDB(t, dir)
+	writeFile(t, dir, "answers.txt", `Admin console
+admin
+human
+Administrator
+Manages the store
+console
+
+console
+service
+program
+Admin console
+
+audit
+role
+Audit logs
+Read audit logs
+
+manage
+Manage store
+Administrator manages the store
+admin
+console
+
+`)
+	answers, err := os.Open(filepath.Join(dir, "answers.txt"))
+	assert.Nil(t, err)
+	defer answers.Close()
+	stdin := os.Stdin
+	os.Stdin = answers
+	defer func() { os.Stdin = stdin }()
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"init", "-i", "-addb", addbDir, filepath.Join(dir, "console")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "'service' is not one of - human, program, role\nType (human/program/role) [program]: ")
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "console/model.smspec"))
+	assert.Empty(t, errs)
+	assert.Equal(t, "Admin console", m.Title)
+	assert.Equal(t, "Administrator", m.Externals["admin"].GetName())
+	assert.Equal(t, "Admin console", m.Entities["console"].GetName())
+	assert.Equal(t, "Admin console", m.Entities["console"].GetDescription()) // empty answers take defaults
+	assert.Equal(t, "Read audit logs", m.Entities["audit"].GetDescription())
+	assert.Equal(t, "admin", m.Flows["manage"].GetSender().GetID())
+	assert.IsType(t, &objmodel.Program{}, m.Entities["console"]) // re-prompted for an unknown type
+}
+
+func TestInitWithoutPath(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	err = sendToParseArgs([]string{"init", "-h"})
+	assert.EqualError(t, err, "invalid path - '-h'. Path of the new model (or its directory) must be the last argument")
+	_, err = os.Stat(filepath.Join(dir, "-h"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// ADDB with entries referred by templates
+func createTemplateADDB(t *testing.T, dir string) string {
+	addbDir := filepath.Join(dir, "addb")
+	writeADDBEntry(t, addbDir, "lang/go.smspec", "lang.go", "program")
+	writeADDBEntry(t, addbDir, "lang/sql.smspec", "lang.sql", "program")
+	writeADDBEntry(t, addbDir, "db/mysql.smspec", "db.mysql", "program")
+	writeADDBEntry(t, addbDir, "flows/https.smspec", "flow.https", "flow")
+	return addbDir
+}
@@ -285,6 +285,46 @@ func TestSecurityModelWithADDBReferences(t *testing.T) {
 	// TODO
 }
 
+func TestSecurityModelWithCircularReferences(t *testing.T) {
+	addbDir := t.TempDir()
+	writeFile(t, addbDir, "frameworks/a.smspec", "---\nid: fw.a\nname: A\ndescription: A\ntype: program\nadm: []\ndependencies: [addb:fw.b]\n...\n")
+	writeFile(t, addbDir, "frameworks/b.smspec", "---\nid: fw.b\nname: B\ndescription: B\ntype: program\nadm: []\ndependencies: [addb:fw.a]\n...\n")
+	yaml := `
+title: Circular references
+addb: ` + addbDir + `
+entities:
+  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    base: [backend]
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    base: [frontend]
+    dependencies: [addb:fw.a]
+  - id: db
+    type: program
+    name: Database
+    description: Stores records
+`
+	var l smloaders.Loader
+	m, errs := l.LoadSecurityModel(yaml, "")
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Contains(t, messages, "circular reference - frontend -> backend -> frontend")
+	assert.Contains(t, messages, "circular reference - addb:fw.a -> addb:fw.b -> addb:fw.a")
+
+	// Rest of the model is still built
+	assert.Len(t, m.Entities, 3)
+	assert.Equal(t, "Database", m.Entities["db"].GetName())
+	assert.NotNil(t, m.GetADM())
+}
+
 ////////////////////////////////////////
 // Helper functions
 
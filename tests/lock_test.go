@@ -0,0 +1,81 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	writeFile(t, addbDir, "languages/go.smspec", "---\nid: lang.go\nname: Go\ndescription: Go\ntype: program\nadm: [adm/go.adm]\ndependencies: [addb:lang.common]\n...\n")
+	writeFile(t, addbDir, "languages/adm/go.adm", "Model: Go\n  Attack: Exploit cgo\n")
+	writeADDBEntry(t, addbDir, "languages/common.smspec", "lang.common", "program")
+	writeADDBEntry(t, addbDir, "languages/unused.smspec", "lang.unused", "program")
+	writeFile(t, dir, "model.smspec", `title: Locked model
+addb: `+addbDir+`
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    languages: [addb:lang.go]
+`)
+	modelPath := filepath.Join(dir, "model.smspec")
+
+	err := sendToParseArgs([]string{"lock", modelPath})
+	assert.Nil(t, err)
+	lock, err := addb.ReadLockFile(modelPath + ".lock")
+	assert.Nil(t, err)
+	assert.Equal(t, addbDir, lock.Addb)
+	assert.Len(t, lock.Entries, 2) // 'lang.common' is pulled in by 'lang.go'
+	assert.Equal(t, "lang.common", lock.Entries[0].Id)
+	assert.Equal(t, "lang.go", lock.Entries[1].Id)
+	assert.Equal(t, "languages/go.smspec", lock.Entries[1].File)
+	assert.Equal(t, "languages/adm/go.adm", lock.Entries[1].ADM[0].File)
+
+	err = sendToParseArgs([]string{"stat", "-locked", modelPath})
+	assert.Nil(t, err)
+
+	// Unrelated ADDB changes don't invalidate the lock
+	writeADDBEntry(t, addbDir, "languages/unused.smspec", "lang.unused", "system")
+	err = sendToParseArgs([]string{"stat", "-locked", modelPath})
+	assert.Nil(t, err)
+
+	// Changes to ADM used by the model
+	writeFile(t, addbDir, "languages/adm/go.adm", "Model: Go\n  Attack: Exploit unsafe\n")
+	harness := output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"stat", modelPath})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err) // only a warning without '-locked'
+	assert.Contains(t, out, "WARNING: ADM file 'languages/adm/go.adm' (used by 'lang.go') changed")
+
+	err = sendToParseArgs([]string{"report", "-d", dir, "-locked", modelPath})
+	assert.NotNil(t, err)
+}
+
+func TestLockedWithoutLockFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: Unlocked model\n")
+	modelPath := filepath.Join(dir, "model.smspec")
+
+	err := sendToParseArgs([]string{"stat", "-locked", modelPath})
+	assert.NotNil(t, err)
+	err = sendToParseArgs([]string{"stat", modelPath})
+	assert.Nil(t, err)
+}
+
+func TestLockInvalidModel(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: [unclosed\n")
+
+	err := sendToParseArgs([]string{"lock", filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "model.smspec.lock"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"securitymodel/objmodel"
+	"securitymodel/sarif"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ownedModel = `entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    owner: alice
+    team: team-payments
+    contact: payments@example.com
+    criticality: high
+    adm:
+      - |
+        Model: Backend
+          Attack: Run as root
+            When service runs
+  - id: frontend
+    type: program
+    name: Frontend
+    description: Web pages
+    team: team-web
+    adm:
+      - |
+        Model: Frontend
+          Attack: Inject scripts
+            When input is not escaped
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: frontend
+    receiver: backend
+    adm:
+      - |
+        Model: Orders
+          Attack: Replay orders
+            When orders are not signed
+`
+
+func TestOwnership(t *testing.T) {
+	dir := t.TempDir()
+	writeOwnedModel(t, dir)
+	model, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	ownership := model.Entities["backend"].GetOwnership()
+	assert.Equal(t, objmodel.Ownership{Owner: "alice", Team: "team-payments", Contact: "payments@example.com"}, ownership)
+	assert.True(t, ownership.OwnedBy("Team-Payments"))
+	assert.True(t, ownership.OwnedBy("alice"))
+	assert.False(t, ownership.OwnedBy(""))
+	assert.Equal(t, objmodel.Ownership{}, model.Flows["orders"].GetOwnership())
+
+	scoped := model.Select(func(item objmodel.CoreSpec, external bool) bool {
+		entity, ok := item.(objmodel.EntitySpec)
+		return ok && entity.GetOwnership().OwnedBy("team-web")
+	})
+	assert.Len(t, scoped.Entities, 1)
+	assert.Contains(t, scoped.Entities, "frontend")
+	assert.Empty(t, scoped.Flows)
+	assert.Len(t, model.Entities, 2) // original model is not changed
+}
+
+func TestReportRisksByOwner(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeOwnedModel(t, dir)
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "### Risks by owner\n\n"+
+		"Risks grouped by the owner (or team) of the entity/flow they are listed under.\n\n"+
+		"#### alice (team: team-payments, contact: payments@example.com)\n\n"+
+		"* Run as root (under `entities → backend`) - risk score **6** (likelihood: medium, impact: high)\n\n"+
+		"#### team-web\n\n"+
+		"* Inject scripts (under `entities → frontend`) - risk score **4** (likelihood: medium, impact: medium)\n\n"+
+		"#### No owner\n\n"+
+		"* Replay orders (under `flows → orders`) - risk score **4** (likelihood: medium, impact: medium)\n")
+
+	// Only items of a team
+	err = sendToParseArgs([]string{"report", "-owner", "team-payments", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err = os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "* Run as root (under `entities → backend`)")
+	assert.NotContains(t, string(content), "Inject scripts")
+	assert.NotContains(t, string(content), "Replay orders")
+}
+
+func TestReportFlowAcrossTeams(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	model := strings.Replace(ownedModel, "    sender: frontend\n", "    team: team-payments\n    sender: frontend\n", 1)
+	writeFile(t, dir, "model.smspec", "title: Store\naddb: "+t.TempDir()+"\n"+model)
+
+	err := sendToParseArgs([]string{"report", "-owner", "team-payments", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "* Replay orders (under `flows → orders`)")
+	assert.NotContains(t, string(content), "Inject scripts") // sender of 'orders' is owned by another team
+
+	err = sendToParseArgs([]string{"report", "-format", "csv", "-filter", "id:orders", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err = os.ReadFile(filepath.Join(outDir, "report", "Store.risks.csv"))
+	assert.Nil(t, err)
+	assert.Equal(t, "owner,team,contact,attack,location,likelihood,impact,score,severity\n"+
+		",team-payments,,Replay orders,flows → orders,medium,medium,4,medium\n", string(content))
+}
+
+func TestReportRisksCSV(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeOwnedModel(t, dir)
+
+	err := sendToParseArgs([]string{"report", "-format", "csv", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.risks.csv"))
+	assert.Nil(t, err)
+	assert.Equal(t, "owner,team,contact,attack,location,likelihood,impact,score,severity\n"+
+		"alice,team-payments,payments@example.com,Run as root,entities → backend,medium,high,6,high\n"+
+		",team-web,,Inject scripts,entities → frontend,medium,medium,4,medium\n"+
+		",,,Replay orders,flows → orders,medium,medium,4,medium\n", string(content))
+}
+
+func TestReportSARIF(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeOwnedModel(t, dir)
+
+	err := sendToParseArgs([]string{"report", "-format", "sarif", "-owner", "alice", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sarif"))
+	assert.Nil(t, err)
+	var log sarif.Log
+	assert.Nil(t, json.Unmarshal(content, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	run := log.Runs[0]
+	assert.Equal(t, "adsm", run.Tool.Driver.Name)
+	assert.Equal(t, []sarif.Rule{{Id: "Run_as_root", Name: "Run as root", ShortDescription: sarif.Message{Text: "Unmitigated attack - Run as root"}}}, run.Tool.Driver.Rules)
+	assert.Len(t, run.Results, 1)
+	result := run.Results[0]
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, filepath.ToSlash(filepath.Join(dir, "model.smspec")), result.Locations[0].PhysicalLocation.ArtifactLocation.Uri)
+	assert.Equal(t, "sm.entities.backend", result.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, map[string]interface{}{"likelihood": "medium", "impact": "high", "score": float64(6), "severity": "high",
+		"owner": "alice", "team": "team-payments", "contact": "payments@example.com"}, result.Properties)
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Model with an entity owned by a person, an entity owned by a team and a flow
+// without an owner
+func writeOwnedModel(t *testing.T, dir string) {
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "addb"), 0700))
+	writeFile(t, dir, "model.smspec", "title: Store\naddb: "+filepath.Join(dir, "addb")+"\n"+ownedModel)
+}
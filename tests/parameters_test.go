@@ -0,0 +1,145 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	smloaders "securitymodel/loaders"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterDefaults(t *testing.T) {
+	dir := createParameterizedModel(t)
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	assert.Equal(t, "Store (staging)", m.Title)
+	assert.Equal(t, "Serves staging-eu traffic", m.Entities["backend"].GetDescription())
+	assert.NotContains(t, m.Entities, "waf") // 'when: env == prod'
+	assert.Contains(t, m.Entities, "debugger")
+	assert.Contains(t, m.Flows, "debug")
+	assert.Equal(t, filepath.Join(dir, "adm/staging.adm"), m.Entities["backend"].GetADM()["backend"][0])
+}
+
+func TestParametersSetWhenLoading(t *testing.T) {
+	dir := createParameterizedModel(t)
+	t.Setenv("region", "us") // environment overrides defaults
+
+	content, err := os.ReadFile(filepath.Join(dir, "model.smspec"))
+	assert.Nil(t, err)
+	var l smloaders.Loader
+	l.SetParameters(map[string]string{"env": "prod"})
+	m, errs := l.LoadSecurityModel(string(content), dir)
+	assert.Empty(t, errs)
+	assert.Equal(t, "Store (prod)", m.Title)
+	assert.Equal(t, "Serves prod-us traffic", m.Entities["backend"].GetDescription())
+	assert.Contains(t, m.Entities, "waf")
+	assert.NotContains(t, m.Entities, "debugger") // dropped from the fragment too
+	assert.NotContains(t, m.Flows, "debug")
+
+	// Values set when loading override the environment
+	l = smloaders.Loader{}
+	l.SetParameters(map[string]string{"env": "prod", "region": "ap"})
+	m, _ = l.LoadSecurityModel(string(content), dir)
+	assert.Equal(t, "Serves prod-ap traffic", m.Entities["backend"].GetDescription())
+}
+
+func TestParameterErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Store (${stage})
+addb: `+t.TempDir()+`
+parameters: {env: staging}
+include: [debug.smspec]
+entities:
+  - {id: backend, type: program, name: Backend, description: Business logic, adm: [], when: env = prod}
+`)
+	writeFile(t, dir, "debug.smspec", "parameters: {debug: true}\n")
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Equal(t, []string{
+		"undefined parameter 'stage' - add it to 'parameters' or pass it with '--set stage=<value>'",
+		"invalid 'when' in 'backend' - 'env = prod' is not a comparison like 'env == prod' or 'env != prod'",
+		"'parameters' can only be specified in the main model, found in '" + filepath.Join(dir, "debug.smspec") + "'",
+	}, errorMessages(errs))
+	assert.NotContains(t, m.Entities, "backend") // condition could not be evaluated
+}
+
+func TestReportWithParameters(t *testing.T) {
+	dir := createParameterizedModel(t)
+	outDir := t.TempDir()
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, "-set", "env=prod", filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report/Store_prod.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "* Flood requests (under `entities → backend`)") // from 'adm/prod.adm'
+	assert.NotContains(t, string(content), "Read test data")
+}
+
+func TestLockWithParameters(t *testing.T) {
+	dir := createParameterizedModel(t)
+	modelPath := filepath.Join(dir, "model.smspec")
+
+	// 'waf' and its ADDB dependency are only in the 'prod' variant
+	err := sendToParseArgs([]string{"lock", modelPath})
+	assert.Nil(t, err)
+	err = sendToParseArgs([]string{"report", "-d", t.TempDir(), "-locked", "-set", "env=prod", modelPath})
+	assert.NotNil(t, err)
+
+	err = sendToParseArgs([]string{"lock", "-set", "env=prod", modelPath})
+	assert.Nil(t, err)
+	err = sendToParseArgs([]string{"report", "-d", t.TempDir(), "-locked", "-set", "env=prod", modelPath})
+	assert.Nil(t, err)
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Create a model with staging/prod variants. ADDB location is taken from the
+// environment.
+func createParameterizedModel(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := t.TempDir()
+	t.Setenv("ADSM_TEST_ADDB", addbDir)
+	writeADDBEntry(t, addbDir, "libs/filter.smspec", "lib.filter", "program")
+	writeFile(t, dir, "adm/staging.adm", "Model: Staging\n  Attack: Read test data\n    When test data is real\n")
+	writeFile(t, dir, "adm/prod.adm", "Model: Prod\n  Attack: Flood requests\n    When traffic is not filtered\n")
+	writeFile(t, dir, "model.smspec", `title: Store (${env})
+addb: ${ADSM_TEST_ADDB}
+parameters:
+  env: staging
+  region: eu
+include: [debug.smspec]
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Serves ${env}-${region} traffic
+    adm: ["adm/${env}.adm"] # quoted, as '{' starts a mapping in flow style
+  - id: waf
+    type: program
+    name: Web application firewall
+    description: Filters traffic
+    dependencies: [addb:lib.filter]
+    adm: []
+    when: env == prod
+`)
+	writeFile(t, dir, "debug.smspec", `entities:
+  - id: debugger
+    type: human
+    name: Debugger
+    description: Developer debugging ${env}
+    adm: []
+    when: env != prod
+flows:
+  - id: debug
+    name: Debug
+    description: Developer attaches a debugger
+    sender: debugger
+    receiver: backend
+    adm: []
+    when: "env != prod && region == eu || env == staging"
+`)
+	return dir
+}
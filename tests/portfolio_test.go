@@ -0,0 +1,125 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectoryWithModelsInSubdirectories(t *testing.T) {
+	dir := createPortfolio(t)
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"stat", "-e", dir})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+
+	// ADM is resolved relative to each model's own directory
+	assert.Contains(t, out, "ADM: "+filepath.Join(dir, "payments")+"/adm/service.adm, ATTACKS:1")
+	assert.Contains(t, out, "ADM: "+filepath.Join(dir, "orders")+"/adm/service.adm, ATTACKS:2")
+	assert.Contains(t, out, "ADM: "+filepath.Join(dir, "orders/db")+"/db.adm, ATTACKS:1")
+	assert.NotContains(t, out, "no such file")
+
+	// Fragments are not processed as models
+	assert.Contains(t, out, "Found 2 file(s)")
+	assert.Contains(t, out, "PORTFOLIO: 2 model(s), 0 with errors\n"+
+		"\tOrders ("+filepath.Join(dir, "orders/model.smspec")+"): 0 external(s), 2 entities, 0 flow(s), 0 error(s)\n"+
+		"\tPayments ("+filepath.Join(dir, "payments/model.smspec")+"): 0 external(s), 1 entities, 0 flow(s), 0 error(s)\n")
+}
+
+func TestPortfolioReport(t *testing.T) {
+	dir := createPortfolio(t)
+	outDir := t.TempDir()
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, dir})
+	assert.Nil(t, err)
+	assert.FileExists(t, filepath.Join(outDir, "report/Orders.sm.md"))
+	assert.FileExists(t, filepath.Join(outDir, "report/Payments.sm.md"))
+
+	content, err := os.ReadFile(filepath.Join(outDir, "report/index.md"))
+	assert.Nil(t, err)
+	index := string(content)
+	assert.Contains(t, index, "| [Orders](Orders.sm.md) | `"+filepath.Join(dir, "orders/model.smspec")+"` | 0 | 2 | 0 | 3 | 0 |\n")
+	assert.Contains(t, index, "| [Payments](Payments.sm.md) | `"+filepath.Join(dir, "payments/model.smspec")+"` | 0 | 1 | 0 | 1 | 0 |\n")
+	assert.Contains(t, index, "| **Total** | | 0 | 3 | 0 | 4 | 0 |\n")
+	assert.Contains(t, index, "| `lang.go` | 2 |\n")
+	assert.Contains(t, index, "| Steal card numbers | Orders, Payments |\n")
+	assert.NotContains(t, index, "| Tamper orders |")
+
+	// No index for a single model
+	singleOutDir := t.TempDir()
+	err = sendToParseArgs([]string{"report", "-d", singleOutDir, filepath.Join(dir, "payments/model.smspec")})
+	assert.Nil(t, err)
+	assert.NoFileExists(t, filepath.Join(singleOutDir, "report/index.md"))
+}
+
+func TestPortfolioWithDuplicateTitles(t *testing.T) {
+	dir := createPortfolio(t)
+	outDir := t.TempDir()
+	content, err := os.ReadFile(filepath.Join(dir, "payments/model.smspec"))
+	assert.Nil(t, err)
+	writeFile(t, dir, "refunds/model.smspec", string(content))
+	writeFile(t, dir, "refunds/adm/service.adm", "Model: Refunds\n  Attack: Refund twice\n    When refunds are not tracked\n")
+
+	err = sendToParseArgs([]string{"report", "-d", outDir, dir})
+	assert.Nil(t, err)
+	content, err = os.ReadFile(filepath.Join(outDir, "report/index.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "| [Payments](Payments.sm.md) | `"+filepath.Join(dir, "payments/model.smspec")+"` |")
+	assert.Contains(t, string(content), "| [Payments](Payments_2.sm.md) | `"+filepath.Join(dir, "refunds/model.smspec")+"` |")
+
+	// Each report links its own diagrams
+	content, err = os.ReadFile(filepath.Join(outDir, "report/Payments_2.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "Refund twice")
+	assert.Contains(t, string(content), "(resources/Payments_2.sm.dot)")
+	assert.FileExists(t, filepath.Join(outDir, "report/resources/Payments_2.adm.dot"))
+	content, err = os.ReadFile(filepath.Join(outDir, "report/Payments.sm.md"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(content), "Refund twice")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Create a directory with two models that use identical file names.
+func createPortfolio(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := t.TempDir()
+	writeADDBEntry(t, addbDir, "lang/go.smspec", "lang.go", "program")
+	writeFile(t, dir, "payments/model.smspec", `title: Payments
+addb: `+addbDir+`
+entities:
+  - id: service
+    type: program
+    name: Payment Service
+    description: Processes payments
+    languages: [addb:lang.go]
+    adm: [adm/service.adm]
+`)
+	writeFile(t, dir, "payments/adm/service.adm", "Model: Payments\n  Attack: Steal card numbers\n    When card numbers are stored\n")
+	writeFile(t, dir, "orders/model.smspec", `title: Orders
+addb: `+addbDir+`
+include: [db/db.smspec]
+entities:
+  - id: service
+    type: program
+    name: Order Service
+    description: Manages orders
+    languages: [addb:lang.go]
+    adm: [adm/service.adm]
+`)
+	writeFile(t, dir, "orders/adm/service.adm", "Model: Orders\n  Attack: Steal card numbers\n    When card numbers are stored\n  Attack: Tamper orders\n    When orders are modified\n")
+	writeFile(t, dir, "orders/db/db.smspec", `entities:
+  - id: db
+    type: program
+    name: Orders Database
+    description: Stores orders
+    adm: [db.adm]
+`)
+	writeFile(t, dir, "orders/db/db.adm", "Model: Database\n  Attack: Dump tables\n    When tables are read\n")
+	return dir
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/objmodel"
+	"securitymodel/yamlmodel"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossModelEntityReference(t *testing.T) {
+	dir := createSharedEntityModels(t)
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "orders/orders.smspec"))
+	assert.Empty(t, errs)
+	gateway, ok := m.Entities["gateway"].(*objmodel.Program)
+	assert.True(t, ok)
+	assert.Equal(t, "API Gateway", gateway.GetName())
+	assert.Equal(t, []string{filepath.Join(dir, "platform") + "/adm/gateway.adm"}, gateway.GetADM()["gateway"])
+	assert.Contains(t, gateway.GetRoles(), "gateway-admin") // imported along with the gateway
+	assert.Equal(t, yamlmodel.EntitySource{Title: "Platform", Path: filepath.Join(dir, "platform/platform.smspec"), Id: "api-gateway"}, m.SharedEntities["gateway"])
+	assert.Contains(t, m.SharedEntities, "gateway-admin")
+	assert.NotContains(t, m.SharedEntities, "orders")
+	assert.Equal(t, "API Gateway", m.Flows["place-order"].GetReceiver().GetName())
+}
+
+func TestCrossModelEntityReferenceErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.smspec", "title: A\nentities:\n  - {id: x, ref: 'b.smspec#y'}\n  - {id: missing, ref: 'b.smspec#none'}\n")
+	writeFile(t, dir, "b.smspec", "title: B\nentities:\n  - {id: y, ref: 'a.smspec#x'}\n")
+
+	_, errs := loadModelFile(t, filepath.Join(dir, "a.smspec"))
+	messages := errorMessages(errs)
+	assert.Equal(t, "cannot resolve 'b.smspec#y' - cannot resolve 'a.smspec#x' - models refer to each other - "+
+		filepath.Join(dir, "b.smspec")+" -> "+filepath.Join(dir, "a.smspec")+" -> "+filepath.Join(dir, "b.smspec"), messages[0])
+}
+
+func TestReportWithSharedEntities(t *testing.T) {
+	dir := createSharedEntityModels(t)
+	outDir := t.TempDir()
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, dir})
+	assert.Nil(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outDir, "report/Orders.sm.md"))
+	assert.Nil(t, err)
+	report := string(content)
+	assert.Contains(t, report, "* Lose orders (under `entities → orders`)")
+	assert.Contains(t, report, "### Shared entities\n\nRisks of entities defined in other security models. They are also listed in reports of those models.\n\n"+
+		"* Bypass rate limits (under `entities → gateway`, defined in `Platform` - `"+filepath.Join(dir, "platform/platform.smspec")+"`)")
+
+	// Shared entity's risks are counted once, for the model defining it
+	content, err = os.ReadFile(filepath.Join(outDir, "report/index.md"))
+	assert.Nil(t, err)
+	index := string(content)
+	assert.Contains(t, index, "| [Orders](Orders.sm.md) | `"+filepath.Join(dir, "orders/orders.smspec")+"` | 1 | 3 | 1 | 1 | 0 |\n")
+	assert.Contains(t, index, "| [Platform](Platform.sm.md) | `"+filepath.Join(dir, "platform/platform.smspec")+"` | 0 | 2 | 0 | 1 | 0 |\n")
+	assert.NotContains(t, index, "| Bypass rate limits |")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Create a platform model and an orders model that refers to platform's API gateway.
+func createSharedEntityModels(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := t.TempDir()
+	writeFile(t, dir, "platform/platform.smspec", `title: Platform
+addb: `+addbDir+`
+entities:
+  - id: api-gateway
+    type: program
+    name: API Gateway
+    description: Routes requests to services
+    roles: [gateway-admin]
+    adm: [adm/gateway.adm]
+  - id: gateway-admin
+    type: role
+    name: Gateway Administrator
+    description: Configures routes
+    adm: []
+`)
+	writeFile(t, dir, "platform/adm/gateway.adm", "Model: Gateway\n  Attack: Bypass rate limits\n    When requests are sent quickly\n")
+	writeFile(t, dir, "orders/orders.smspec", `title: Orders
+addb: `+addbDir+`
+externals:
+  - id: customer
+    type: human
+    name: Customer
+    description: Places orders
+    interface: gateway
+entities:
+  - id: gateway
+    ref: ../platform/platform.smspec#api-gateway
+  - id: orders
+    type: program
+    name: Order Service
+    description: Manages orders
+    adm: [orders.adm]
+flows:
+  - id: place-order
+    name: Place order
+    description: Gateway forwards orders
+    sender: orders
+    receiver: gateway
+    adm: []
+`)
+	writeFile(t, dir, "orders/orders.adm", "Model: Orders\n  Attack: Lose orders\n    When the service crashes\n")
+	return dir
+}
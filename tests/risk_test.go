@@ -0,0 +1,118 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/risk"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskLevels(t *testing.T) {
+	level, err := risk.ParseLevel("High")
+	assert.Nil(t, err)
+	assert.Equal(t, risk.High, level)
+	_, err = risk.ParseLevel("severe")
+	assert.EqualError(t, err, "unknown level 'severe'. Supported levels - low, medium, high, critical")
+
+	// Impact is the higher of criticality and data-classification
+	assert.Equal(t, risk.Critical, risk.ElementImpact("low", "restricted"))
+	assert.Equal(t, risk.High, risk.ElementImpact("high", "internal"))
+	assert.Equal(t, risk.Unknown, risk.ElementImpact("", ""))
+
+	assert.Equal(t, 12, risk.Score(risk.Critical, risk.High))
+	assert.Equal(t, risk.Critical, risk.Severity(9))
+	assert.Equal(t, risk.Low, risk.Severity(2))
+
+	likelihood, impact, err := risk.FromTags([]string{"@stride:tampering", "@Likelihood:low", "@impact:huge"})
+	assert.EqualError(t, err, "invalid tag '@impact:huge' - unknown level 'huge'. Supported levels - low, medium, high, critical")
+	assert.Equal(t, risk.Low, likelihood)
+	assert.Equal(t, risk.Unknown, impact)
+}
+
+func TestRiskOverlay(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "risks.yaml", "- {attack: Replay orders, location: orders, likelihood: high}\n")
+	estimates, err := risk.LoadOverlay(filepath.Join(dir, "risks.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, []risk.Estimate{{Attack: "Replay orders", Location: "orders", Likelihood: "high"}}, estimates)
+
+	writeFile(t, dir, "risks.yaml", "- {attack: Replay orders, likelihood: likely}\n")
+	_, err = risk.LoadOverlay(filepath.Join(dir, "risks.yaml"))
+	assert.EqualError(t, err, "risk overlay '"+filepath.Join(dir, "risks.yaml")+"' rates 'Replay orders' with unknown level 'likely'. Supported levels - low, medium, high, critical")
+
+	writeFile(t, dir, "risks.yaml", "- {attack: Replay orders}\n")
+	_, err = risk.LoadOverlay(filepath.Join(dir, "risks.yaml"))
+	assert.EqualError(t, err, "risk overlay '"+filepath.Join(dir, "risks.yaml")+"' contains an entry without 'attack' or without one of 'likelihood' and 'impact'")
+}
+
+func TestReportRisksSortedByScore(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+	writeFile(t, dir, "risks.yaml", `- {attack: Exhaust connections, likelihood: critical}
+- {attack: Run as root, location: orders, likelihood: medium, impact: low}
+`)
+	writeFile(t, dir, "model.smspec", `title: Store
+risk-overlay: risks.yaml
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    criticality: high
+    adm:
+      - |
+        Model: Backend
+          @likelihood:high
+          Attack: Run as root
+            When service runs
+          Attack: Exhaust connections
+            When connections are not pooled
+          Attack: Leak logs
+            When logs are verbose
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: backend
+    receiver: backend
+    likelihood: low
+    data-classification: restricted
+    adm:
+      - |
+        Model: Orders
+          Attack: Replay orders
+            When orders are not signed
+          Attack: Run as root
+            When orders are processed
+`)
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), ""+
+		"* Exhaust connections (under `entities → backend`) - risk score **12** (likelihood: critical, impact: high)\n"+ // overlay for all locations
+		"* Run as root (under `entities → backend`) - risk score **9** (likelihood: high, impact: high)\n"+ // ADM tag, criticality of entity
+		"* Leak logs (under `entities → backend`) - risk score **6** (likelihood: medium, impact: high)\n"+ // default likelihood
+		"* Replay orders (under `flows → orders`) - risk score **4** (likelihood: low, impact: critical)\n"+ // values of flow
+		"* Run as root (under `flows → orders`) - risk score **2** (likelihood: medium, impact: low)\n") // overlay for a location
+	assert.Contains(t, string(content), "### Heat map\n\nNumber of risks by likelihood and impact, along with their severity.\n\n"+
+		"| Likelihood / Impact | Low | Medium | High | Critical |\n"+
+		"|---|---|---|---|---|\n"+
+		"| Critical | | | 1 (critical) | |\n"+
+		"| High | | | 1 (critical) | |\n"+
+		"| Medium | 1 (low) | | 1 (high) | |\n"+
+		"| Low | | | | 1 (medium) |\n")
+}
+
+func TestRiskFieldsAreValidated(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", `title: Store
+entities:
+  - {id: backend, type: program, name: Backend, description: Business logic, criticality: severe, adm: []}
+`)
+	_, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Contains(t, errorMessages(errs), "line 3: program 'backend' - 'criticality' must be 'low', 'medium', 'high' or 'critical'")
+}
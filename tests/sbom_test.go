@@ -0,0 +1,213 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	smloaders "securitymodel/loaders"
+	"securitymodel/objmodel"
+	"securitymodel/sbom"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cycloneDXSBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "metadata": {"component": {"name": "backend", "version": "1.0.0"}},
+  "components": [
+    {"name": "gin", "version": "v1.9.0", "purl": "pkg:golang/github.com/gin-gonic/gin@v1.9.0",
+     "components": [{"name": "json-iterator", "version": "1.1.12", "purl": "pkg:golang/github.com/json-iterator/go@v1.1.12"}]},
+    {"name": "openssl", "version": "3.0.8"},
+    {"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0"}
+  ]
+}`
+
+const spdxTagValueSBOM = `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-backend
+
+PackageName: backend
+SPDXID: SPDXRef-backend
+PackageVersion: 1.0.0
+
+FileName: ./main.go
+SPDXID: SPDXRef-main
+
+PackageName: gin
+SPDXID: SPDXRef-gin
+PackageVersion: v1.9.0
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/gin-gonic/gin@v1.9.0?type=module
+`
+
+func TestSBOMFormats(t *testing.T) {
+	components, err := sbom.Parse([]byte(cycloneDXSBOM))
+	assert.Nil(t, err)
+	assert.Len(t, components, 4) // includes nested component
+	assert.Equal(t, "pkg:golang/github.com/json-iterator/go@v1.1.12", components[1].Purl)
+
+	components, err = sbom.Parse([]byte(spdxTagValueSBOM))
+	assert.Nil(t, err)
+	assert.Len(t, components, 1) // described package is not a dependency
+	assert.Equal(t, "gin", components[0].Name)
+	assert.Equal(t, "v1.9.0", components[0].Version)
+
+	components, err = sbom.Parse([]byte(`{"spdxVersion": "SPDX-2.3", "documentDescribes": ["SPDXRef-backend"],
+		"packages": [{"SPDXID": "SPDXRef-backend", "name": "backend"},
+		             {"SPDXID": "SPDXRef-openssl", "name": "openssl", "versionInfo": "3.0.8"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []sbom.Component{{Name: "openssl", Version: "3.0.8"}}, components)
+
+	_, err = sbom.Parse([]byte("name,version\ngin,v1.9.0\n"))
+	assert.NotNil(t, err)
+}
+
+func TestSecurityModelWithSBOM(t *testing.T) {
+	dir := createSBOMFixture(t)
+
+	content, err := os.ReadFile(filepath.Join(dir, "model.smspec"))
+	assert.Nil(t, err)
+
+	var l smloaders.Loader
+	m, errs := l.LoadSecurityModel(string(content), dir)
+	assert.Empty(t, errs)
+	backend := m.Entities["backend"].(*objmodel.Program)
+	deps := backend.GetDependencies()
+	assert.Len(t, deps, 3)
+	assert.Contains(t, deps, "lib.gin")     // purl without version
+	assert.Contains(t, deps, "lib.openssl") // name and version
+	assert.Contains(t, deps, "lib.jsoniter")
+
+	unmapped := l.UnmappedSBOMComponents()["backend"]
+	assert.Equal(t, []sbom.Component{{Name: "left-pad", Version: "1.3.0", Purl: "pkg:npm/left-pad@1.3.0"}}, unmapped)
+}
+
+func TestSBOMCommand(t *testing.T) {
+	dir := createSBOMFixture(t)
+	writeFile(t, dir, "frontend.spdx", spdxTagValueSBOM)
+	modelPath := filepath.Join(dir, "model.smspec")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"sbom", modelPath})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "ENTITY: backend\n\tNo ADDB entry for:\n\t  - pkg:npm/left-pad@1.3.0\n")
+
+	harness = output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"sbom", "-i", filepath.Join(dir, "frontend.spdx"), "-e", "frontend", modelPath})
+	out, _ = harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\tMapped 1 of 1 SBOM component(s)\n\tAdd to 'dependencies' of 'frontend':\n\t  - addb:lib.gin\n\tUpdated "+modelPath+"\n")
+
+	// Mapped components are added to the model
+	content, err := os.ReadFile(modelPath)
+	assert.Nil(t, err)
+	var l smloaders.Loader
+	m, errs := l.LoadSecurityModel(string(content), dir)
+	assert.Empty(t, errs)
+	assert.Contains(t, m.Entities["frontend"].(*objmodel.Program).GetDependencies(), "lib.gin")
+
+	harness = output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"sbom", "-i", filepath.Join(dir, "frontend.spdx"), "-e", "frontend", modelPath})
+	out, _ = harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.NotContains(t, out, "Updated")
+
+	err = sendToParseArgs([]string{"sbom", "-i", filepath.Join(dir, "frontend.spdx"), modelPath})
+	assert.NotNil(t, err) // entity is required
+}
+
+func TestSBOMCommandWithIncludedEntity(t *testing.T) {
+	dir := createSBOMFixture(t)
+	writeFile(t, dir, "frontend.spdx", spdxTagValueSBOM)
+	modelPath := filepath.Join(dir, "model.smspec")
+	content, err := os.ReadFile(modelPath)
+	assert.Nil(t, err)
+	model := strings.Replace(string(content), `  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    adm: []
+`, "", 1) + "include: [parts/frontend.smspec]\n"
+	writeFile(t, dir, "model.smspec", model)
+	writeFile(t, dir, "parts/frontend.smspec", `entities:
+  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    adm: []
+`)
+	fragmentPath := filepath.Join(dir, "parts", "frontend.smspec")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"sbom", "-i", filepath.Join(dir, "frontend.spdx"), "-e", "frontend", modelPath})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\tUpdated "+fragmentPath+"\n")
+
+	// The fragment the entity came from is updated, the model is left as is
+	content, err = os.ReadFile(modelPath)
+	assert.Nil(t, err)
+	assert.Equal(t, model, string(content))
+	content, err = os.ReadFile(fragmentPath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "addb:lib.gin")
+
+	content, err = os.ReadFile(modelPath)
+	assert.Nil(t, err)
+	var l smloaders.Loader
+	m, errs := l.LoadSecurityModel(string(content), dir)
+	assert.Empty(t, errs)
+	assert.Contains(t, m.Entities["frontend"].(*objmodel.Program).GetDependencies(), "lib.gin")
+}
+
+func TestSBOMCommandWithInvalidModel(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: [unclosed\n")
+	writeFile(t, dir, "frontend.spdx", spdxTagValueSBOM)
+
+	err := sendToParseArgs([]string{"sbom", "-i", filepath.Join(dir, "frontend.spdx"), "-e", "frontend", filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Create an ADDB with an SBOM mapping table and a model whose 'backend'
+// refers to a CycloneDX SBOM.
+func createSBOMFixture(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	writeADDBEntry(t, addbDir, "libraries/gin.smspec", "lib.gin", "program")
+	writeADDBEntry(t, addbDir, "libraries/jsoniter.smspec", "lib.jsoniter", "program")
+	writeADDBEntry(t, addbDir, "libraries/openssl.smspec", "lib.openssl", "program")
+	writeFile(t, addbDir, "libraries/sbom-mapping.yaml", `- {purl: "pkg:golang/github.com/gin-gonic/gin", id: addb:lib.gin}
+- {purl: "pkg:golang/github.com/json-iterator/go@v1.1.12", id: lib.jsoniter}
+- {name: openssl, version: 3.0.8, id: lib.openssl}
+- {name: left-pad, id: lib.missing}
+`)
+	writeFile(t, dir, "sbom/backend.cdx.json", cycloneDXSBOM)
+	writeFile(t, dir, "model.smspec", `title: SBOM model
+addb: `+addbDir+`
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    sbom: sbom/backend.cdx.json
+    dependencies: [addb:lib.gin]
+    adm: []
+  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    adm: []
+`)
+	return dir
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/scan"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanRepository(t *testing.T) {
+	repo := createScanFixture(t, t.TempDir())
+
+	result, err := scan.Scan(repo)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dockerfile", "go", "javascript", "python"}, result.Languages)
+
+	var purls []string
+	for _, c := range result.Components {
+		purls = append(purls, c.Purl)
+	}
+	assert.ElementsMatch(t, []string{
+		"pkg:golang/github.com/gin-gonic/gin@v1.9.0",
+		"pkg:golang/gopkg.in/yaml.v3@v3.0.1",
+		"pkg:npm/%40angular/core@16.0.0",
+		"pkg:npm/express",
+		"pkg:pypi/flask@2.3.2",
+		"pkg:pypi/requests",
+		"pkg:docker/golang@1.20",
+		"pkg:docker/alpine@3.18",
+	}, purls)
+}
+
+func TestScanCommand(t *testing.T) {
+	dir := t.TempDir()
+	createScanFixture(t, filepath.Join(dir, "code"))
+	addbDir := filepath.Join(dir, "addb")
+	writeADDBEntry(t, addbDir, "lang/go.smspec", "lang.go", "program")
+	writeADDBEntry(t, addbDir, "lang/docker.smspec", "lang.docker", "program")
+	writeADDBEntry(t, addbDir, "libs/gin.smspec", "libs.go.gin", "program")
+	writeADDBEntry(t, addbDir, "libs/alpine.smspec", "containers.alpine", "program")
+	writeFile(t, addbDir, "sbom-mapping.yaml", `- {language: Dockerfile, id: lang.docker}
+- {purl: "pkg:golang/github.com/gin-gonic/gin", id: libs.go.gin}
+- {purl: "pkg:docker/alpine", id: containers.alpine}
+`)
+	writeFile(t, dir, "model.smspec", `title: Scanned model
+addb: `+addbDir+`
+entities:
+  # Business logic
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    repo: code
+    languages: [addb:lang.go]
+  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    repo: https://example.com/frontend.git
+`)
+	modelPath := filepath.Join(dir, "model.smspec")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"scan", modelPath})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\tAdd to 'languages' of 'backend':\n\t  - addb:lang.docker\n")
+	assert.NotContains(t, out, "  - addb:lang.go\n") // already in the model
+	assert.Contains(t, out, "\tAdd to 'dependencies' of 'backend':\n\t  - addb:containers.alpine\n\t  - addb:libs.go.gin\n")
+	assert.Contains(t, out, "\tNo ADDB entry for languages: javascript, python\n")
+	assert.Contains(t, out, "\t  - pkg:pypi/flask@2.3.2\n")
+	assert.NotContains(t, out, "Frontend") // not a local repository
+
+	err = sendToParseArgs([]string{"scan", "-e", "backend", "-w", modelPath})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(modelPath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "# Business logic")
+	assert.Contains(t, string(content), "languages: ['addb:lang.go', 'addb:lang.docker']")
+	assert.Contains(t, string(content), "dependencies: ['addb:containers.alpine', 'addb:libs.go.gin']")
+
+	// Nothing more to add
+	harness = output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"scan", "-e", "backend", modelPath})
+	out, _ = harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.NotContains(t, out, "Add to")
+
+	err = sendToParseArgs([]string{"scan", "-e", "frontend", modelPath})
+	assert.NotNil(t, err)
+}
+
+func TestScanCommandWithInvalidModel(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: [unclosed\n")
+
+	err := sendToParseArgs([]string{"scan", filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+}
+
+////////////////////////////////////////
+// Helper functions
+
+func createScanFixture(t *testing.T, repo string) string {
+	writeFile(t, repo, "go.mod", `module example.com/backend
+
+go 1.20
+
+require github.com/gin-gonic/gin v1.9.0
+
+require (
+	gopkg.in/yaml.v3 v3.0.1
+	golang.org/x/sys v0.8.0 // indirect
+)
+`)
+	writeFile(t, repo, "web/package.json", `{"name": "web", "dependencies": {"@angular/core": "16.0.0", "express": "^4.x"}}`)
+	writeFile(t, repo, "web/node_modules/express/package.json", `{"name": "express", "dependencies": {"debug": "2.6.9"}}`)
+	writeFile(t, repo, "tools/requirements.txt", "# tools\nflask==2.3.2\nrequests>=2.0 ; python_version > '3.7'\n-r other.txt\n")
+	writeFile(t, repo, "Dockerfile", "FROM golang:1.20 AS build\nRUN go build\nFROM build AS test\nFROM alpine:3.18\nCOPY --from=build /app /app\n")
+	return repo
+}
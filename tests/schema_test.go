@@ -0,0 +1,192 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"path/filepath"
+	"schema"
+	smloaders "securitymodel/loaders"
+	"securitymodel/objmodel"
+	"securitymodel/schemagen"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityModelWithUnknownFields(t *testing.T) {
+	yaml := `title: Typos
+addb: ` + t.TempDir() + `
+externals:
+  - id: user
+    type: human
+    name: User
+    description: Regular user
+    interface: frontend
+entities:
+  - id: frontend
+    type: program
+    name: Frontend
+    description: User interface
+    dependecies: [backend]
+    interface: browser
+    adm: [frontend.adm]
+  - id: backend
+    type: programm
+    name: Backend
+    description: Business logic
+    adm: []
+flows:
+  - id: request
+    name: Request
+    description: Frontend calls backend
+    sender: frontend
+    receiver: backend
+    recomendations: [Use TLS]
+    adm: [request.txt]
+`
+	var l smloaders.Loader
+	m, errs := l.LoadSecurityModel(yaml, "")
+	assert.Equal(t, []string{ // followed by errors in resolving 'browser' and 'backend'
+		"line 14: program 'frontend' - unknown field 'dependecies', did you mean 'dependencies'?",
+		"line 15: program 'frontend' - 'interface' can only be used in a human",
+		"line 18: entry 'backend' - unknown type 'programm', did you mean 'program'?",
+		"line 28: flow 'request' - unknown field 'recomendations', did you mean 'recommendations'?",
+		"line 29: flow 'request' - 'request.txt' in 'adm' does not match '\\.adm$|(^|\\n)\\s*(Model|Attack|Defense):'",
+	}, errorMessages(errs)[:5])
+	assert.NotNil(t, m) // problems are reported, but the model is still loaded
+	assert.Len(t, m.Entities, 1)
+	assert.Len(t, m.Flows, 1)
+}
+
+func TestFieldsNotApplicableToItem(t *testing.T) {
+	errs := schema.ValidateModel([]byte(`title: Misplaced fields
+externals:
+  - {id: user, type: human, name: User, description: Regular user, adm: [user.adm]}
+entities:
+  - {id: backend, type: program, name: Backend, description: Business logic, protocol: [https], adm: []}
+flows:
+  - {id: call, name: Call, description: User calls backend, sender: user, receiver: backend, languages: [go], adm: []}
+`))
+	assert.Equal(t, []string{
+		"line 3: external human 'user' - 'adm' cannot be used in an external human",
+		"line 5: program 'backend' - 'protocol' can only be used in a flow",
+		"line 7: flow 'call' - 'languages' can only be used in a program",
+	}, errorMessages(errs))
+}
+
+func TestFragmentWithUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.smspec", "title: Product\naddb: "+t.TempDir()+"\ninclude: [db.smspec]\n")
+	writeFile(t, dir, "db.smspec", "entities:\n  - {id: db, type: program, name: Database, description: Stores records, adm: [], langauges: [sql]}\n")
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "db.smspec") + ": line 2: program 'db' - unknown field 'langauges', did you mean 'languages'?",
+	}, errorMessages(errs))
+	assert.Contains(t, m.Entities, "db")
+}
+
+func TestADDBWithUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "flows/https.smspec", "---\nid: flow.https\ntype: flow\nname: HTTPS\ndescription: HTTP over TLS\nprotocl: [flow.tls]\nadm: []\n...\n")
+
+	writeFile(t, dir, "flows/tls.smspec", "---\nid: flow.tls\ntype: flow\nname: TLS\ndescription: TLS\nadm: []\n...\n---\nid: flow.ssl\ntype: flwo\nname: SSL\ndescription: SSL\nadm: []\n...\n")
+
+	// Only invalid entries are skipped
+	var db addb.ADDB
+	err := db.Init(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"invalid ADDB entries in '" + filepath.Join(dir, "flows/https.smspec") + "' - line 6: flow 'flow.https' - unknown field 'protocl', did you mean 'protocol'?",
+		"invalid ADDB entries in '" + filepath.Join(dir, "flows/tls.smspec") + "' - " + "line 10: entry 'flow.ssl' - unknown type 'flwo', did you mean 'flow'?",
+	}, errorMessages(db.Warnings))
+	_, err = db.GetComponent("addb:flow.https")
+	assert.NotNil(t, err)
+	_, err = db.GetComponent("addb:flow.ssl")
+	assert.NotNil(t, err)
+	component, err := db.GetComponent("addb:flow.tls")
+	assert.Nil(t, err)
+	assert.Equal(t, "TLS", component.Name)
+}
+
+func TestModelWithInvalidADDBEntry(t *testing.T) {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	writeADDBEntry(t, addbDir, "lang/go.smspec", "lang.go", "program")
+	writeFile(t, addbDir, "lang/rust.smspec", "---\nid: lang.rust\ntype: program\nname: Rust\n...\n")
+	writeFile(t, dir, "model.smspec", "title: Product\naddb: "+addbDir+"\nentities:\n  - {id: backend, type: program, name: Backend, description: Business logic, adm: [], languages: [addb:lang.go]}\n")
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"stat", "-e", filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "WARNING: invalid ADDB entries in '"+filepath.Join(addbDir, "lang/rust.smspec")+"' - ")
+	assert.NotContains(t, out, "ERROR:")
+}
+
+func TestModelWithADDBRole(t *testing.T) {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	role := "---\nid: role.admin\ntype: role\nname: Admin\ndescription: Administrator of the system\nadm: []\nrecommendations: [Require MFA]\n...\n"
+	assert.Empty(t, schema.ValidateComponents([]byte(role)))
+	writeFile(t, addbDir, "roles/admin.smspec", role)
+	writeFile(t, dir, "model.smspec", "title: Product\naddb: "+addbDir+"\nentities:\n  - {id: console, type: program, name: Console, description: Admin UI, adm: [], roles: [addb:role.admin]}\n")
+
+	var db addb.ADDB
+	assert.Nil(t, db.Init(addbDir))
+	model, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	console := model.Entities["console"].(*objmodel.Program)
+	assert.IsType(t, &objmodel.Role{}, console.GetRoles()["addb:role.admin"])
+	assert.Equal(t, []string{"Require MFA"}, console.GetRoles()["addb:role.admin"].GetRecommendations()["Admin"])
+}
+
+func TestStrictDecoding(t *testing.T) {
+	var mapping []addb.SBOMMapping
+	err := schema.Unmarshal([]byte("- {name: openssl, verison: 3.0.8, id: lib.openssl}\n"), &mapping)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "line 1: unknown field 'verison', did you mean 'version'?")
+	assert.Equal(t, "openssl", mapping[0].Name) // rest of the document is still decoded
+
+	err = schema.Unmarshal([]byte(""), &mapping)
+	assert.Nil(t, err)
+}
+
+func TestEmbeddedSchemasAreUpToDate(t *testing.T) {
+	for _, file := range []string{"model-schema.json", "component-schema.json"} {
+		expected, err := os.ReadFile(filepath.Join("..", "schemas", file))
+		assert.Nil(t, err)
+		embedded, err := os.ReadFile(filepath.Join("..", "src", "schema", file))
+		assert.Nil(t, err)
+		assert.Equal(t, string(expected), string(embedded), "run 'go generate' in src/schema after changing "+file)
+	}
+}
+
+func TestSchemasMatchTypes(t *testing.T) {
+	for file, generate := range map[string]func() ([]byte, error){
+		schemagen.ModelSchemaFile:     schemagen.ModelSchema,
+		schemagen.ComponentSchemaFile: schemagen.ComponentSchema,
+	} {
+		generated, err := generate()
+		assert.Nil(t, err)
+		committed, err := os.ReadFile(filepath.Join("..", "schemas", file))
+		assert.Nil(t, err)
+		assert.Equal(t, string(generated), string(committed), "run 'adsm schema schemas' after changing types in 'yamlmodel' or 'addb'")
+	}
+}
+
+func TestSchemaCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"schema", dir})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Generated "+filepath.Join(dir, "model-schema.json")+"\n")
+
+	content, err := os.ReadFile(filepath.Join(dir, "component-schema.json"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `"$ref": "#/options/role"`)
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"addb"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttackTags(t *testing.T) {
+	tags := addb.AttackTags(`@stride:denial-of-service
+Model: Backend
+  @stride:tampering @critical # trailing comment
+  Attack: Modify requests
+    When requests are not signed
+
+  @stride:spoofing
+  Defense: Sign requests
+    When requests are not signed
+
+  Attack: Flood requests
+`)
+	assert.Equal(t, []string{"@stride:denial-of-service", "@stride:tampering", "@critical"}, tags["Modify requests"])
+	assert.Equal(t, []string{"@stride:denial-of-service"}, tags["Flood requests"]) // defense tags don't carry over
+}
+
+func TestSTRIDEMappingTable(t *testing.T) {
+	dir := t.TempDir()
+	writeADDBEntry(t, dir, "cache.smspec", "cache", "program")
+	writeFile(t, dir, "stride-mapping.yaml", "- {attack: Poison cache, stride: [Tampering, denial-of-service]}\n")
+
+	var db addb.ADDB
+	assert.Nil(t, db.Init(dir))
+	assert.Equal(t, []string{"tampering", "denial-of-service"}, db.MapAttack("poison cache"))
+	assert.Empty(t, db.MapAttack("Read stale entries"))
+
+	writeFile(t, dir, "stride-mapping.yaml", "- {attack: Poison cache, stride: [poisoning]}\n")
+	var invalid addb.ADDB
+	assert.EqualError(t, invalid.Init(dir), "STRIDE mapping table '"+filepath.Join(dir, "stride-mapping.yaml")+
+		"' maps 'Poison cache' to unknown category 'poisoning'. Supported categories - spoofing, tampering, repudiation, information-disclosure, denial-of-service, elevation-of-privilege")
+}
+
+func TestSTRIDECoverageStat(t *testing.T) {
+	dir := createSTRIDEModel(t)
+
+	harness := output_interceptor{}
+	harness.Hook()
+	err := sendToParseArgs([]string{"stat", "-s", filepath.Join(dir, "model.smspec")})
+	out, _ := harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "\t\tELEMENT  TYPE      S    T    R  I  D    E    UNCLASSIFIED\n"+
+		"\t\tUser     external  !    -    !  -  -    -    -\n"+
+		"\t\tBackend  program   1/1  0/1  !  !  0/1  1/1  -\n"+
+		"\t\tOrders   flow      -    !    -  !  !    -    0/1\n")
+	assert.NotContains(t, out, "attacks considered for") // gaps are marked in the table only
+	assert.NotContains(t, out, "Entity: Backend")        // only STRIDE coverage is shown
+
+	// Not shown without '-s'
+	harness = output_interceptor{}
+	harness.Hook()
+	err = sendToParseArgs([]string{"stat", filepath.Join(dir, "model.smspec")})
+	out, _ = harness.ReadAndRelease()
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Backend")
+	assert.NotContains(t, out, "STRIDE coverage")
+}
+
+func TestSTRIDECoverageReport(t *testing.T) {
+	dir := createSTRIDEModel(t)
+	outDir := t.TempDir()
+
+	err := sendToParseArgs([]string{"report", "-d", outDir, filepath.Join(dir, "model.smspec")})
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(outDir, "report", "Store.sm.md"))
+	assert.Nil(t, err)
+	report := string(content)
+	assert.Contains(t, report, "## STRIDE Coverage")
+	assert.Contains(t, report, "| Backend | program | 1/1 | 0/1 | **none** | **none** | 0/1 | 1/1 | - |")
+	assert.Contains(t, report, "| Orders | flow | - | **none** | - | **none** | **none** | - | 0/1 |")
+	assert.Contains(t, report, "* No *Information Disclosure* attacks considered for flow `Orders`.")
+}
+
+////////////////////////////////////////
+// Helper functions
+
+// Model with attacks categorized by ADM tags and by a mapping table in ADDB
+func createSTRIDEModel(t *testing.T) string {
+	dir := t.TempDir()
+	addbDir := filepath.Join(dir, "addb")
+	writeFile(t, addbDir, "cache.smspec", `---
+id: cache
+type: program
+name: Cache
+description: In-memory cache
+adm:
+  - |
+    Model: Cache
+      Attack: Poison cache
+        When entries are not validated
+...
+`)
+	writeFile(t, addbDir, "stride-mapping.yaml", "- {attack: Poison cache, stride: [tampering]}\n")
+	writeFile(t, dir, "model.smspec", `title: Store
+addb: `+addbDir+`
+externals:
+  - {id: user, type: human, name: User, description: Shopper}
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    dependencies: [addb:cache]
+    adm:
+      - |
+        Model: Backend
+          @stride:elevation-of-privilege @stride:spoofing
+          Attack: Run as root
+            When service runs
+          Defense: Drop privileges
+            When service runs
+          @stride:denial-of-service
+          Attack: Exhaust connections
+            When connections are not pooled
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: user
+    receiver: backend
+    adm:
+      - |
+        Model: Orders
+          Attack: Replay orders
+            When orders are not signed
+`)
+	return dir
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"securitymodel/yamlmodel"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalModel(t *testing.T) {
+	m := yamlmodel.SecurityModel{
+		Title:   "Store",
+		AddbUri: yamlmodel.AddbReference{Repo: "https://example.com/addb.git", Ref: "v1"},
+		Entities: []*yamlmodel.Entity{
+			{Type: yamlmodel.Program, Id: "backend", Name: "Backend", Description: "Business logic\nand storage",
+				Dependencies: []string{"addb:cache", "addb:postgres"}, ADM: []string{}},
+		},
+		Flows: []*yamlmodel.Flow{
+			{Id: "orders", Name: "Orders", Description: "Orders placed by users", Sender: "user", Receiver: "backend",
+				Protocol: []string{"addb:https"}, Mitigations: []string{"Orders are signed by the frontend"}, ADM: []string{"adm/orders.adm"}},
+		},
+	}
+
+	content, err := yamlmodel.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, `title: Store
+addb: {repo: 'https://example.com/addb.git', ref: v1}
+
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: |-
+      Business logic
+      and storage
+    dependencies: ['addb:cache', 'addb:postgres']
+    adm: []
+
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: user
+    receiver: backend
+    protocol: ['addb:https']
+    mitigations:
+      - Orders are signed by the frontend
+    adm: [adm/orders.adm]
+`, string(content))
+}
+
+func TestUpdateRetainsCommentsAndOrder(t *testing.T) {
+	content := []byte(`# Online store
+title: Store
+addb: ../addb
+entities:
+  # Business logic
+  - name: Backend
+    id: backend
+    type: program
+    description: Business logic
+    adm: [adm/backend.adm] # reviewed
+  - id: debugger
+    type: human
+    name: Debugger
+    description: Developer
+    adm: []
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: user
+    receiver: backend
+    adm: []
+`)
+	var m yamlmodel.SecurityModel
+	assert.Nil(t, yaml.Unmarshal(content, &m))
+	m.Entities[0].Description = "Business logic and storage"
+	m.Entities[1] = &yamlmodel.Entity{Id: "cache", Type: yamlmodel.Program, Name: "Cache", Description: "Session cache", ADM: []string{}}
+	m.Flows[0].Mitigations = []string{"Orders are signed"}
+
+	updated, err := yamlmodel.Update(content, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, `# Online store
+title: Store
+addb: ../addb
+entities:
+  # Business logic
+  - name: Backend
+    id: backend
+    type: program
+    description: Business logic and storage
+    adm: [adm/backend.adm] # reviewed
+  - id: cache
+    type: program
+    name: Cache
+    description: Session cache
+    adm: []
+flows:
+  - id: orders
+    name: Orders
+    description: Orders placed by users
+    sender: user
+    receiver: backend
+    mitigations:
+      - Orders are signed
+    adm: []
+`, string(updated))
+
+	// Nothing to update
+	unchanged, err := yamlmodel.Update(updated, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(updated), string(unchanged))
+}
+
+func TestObjectModelToYaml(t *testing.T) {
+	dir := createInlineADMModel(t)
+	writeFile(t, dir, "shared.smspec", `title: Shared
+entities:
+  - {id: auth, type: program, name: Auth, description: Authentication, adm: []}
+`)
+	writeFile(t, dir, "model.smspec", `title: Inline ADM
+addb: `+filepath.Join(dir, "addb")+`
+externals:
+  - {id: user, type: human, name: User, description: Customer}
+entities:
+  - id: backend
+    type: program
+    name: Backend
+    description: Business logic
+    dependencies: ['addb:cache'] # quoted, as written by the encoder
+    mitigations: [Requests are signed]
+    adm: [adm/backend.adm]
+  - {id: login, ref: "shared.smspec#auth"}
+flows:
+  - {id: orders, name: Orders, description: Orders placed, sender: user, receiver: backend, adm: []}
+`)
+
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+	content, err := yamlmodel.Marshal(m.ToYaml(dir))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "dependencies: ['addb:cache']")
+	assert.Contains(t, string(content), "ref: shared.smspec#auth")
+
+	// Written model loads into the same object model
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "written.smspec"), content, 0600))
+	written, errs := loadModelFile(t, filepath.Join(dir, "written.smspec"))
+	assert.Empty(t, errs)
+	assert.Equal(t, m.Title, written.Title)
+	assert.Equal(t, m.AddbPath, written.AddbPath)
+	assert.Equal(t, m.Externals, written.Externals)
+	assert.Equal(t, m.Entities, written.Entities)
+	assert.Equal(t, m.SharedEntities, written.SharedEntities)
+	assert.Equal(t, m.GetADM(), written.GetADM())
+	assert.Equal(t, m.Flows["orders"].GetSender().GetID(), written.Flows["orders"].GetSender().GetID())
+
+	// Nothing changes when written over the original model
+	original, err := os.ReadFile(filepath.Join(dir, "model.smspec"))
+	assert.Nil(t, err)
+	updated, err := yamlmodel.Update(original, m.ToYaml(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, string(original), string(updated))
+}
+
+func TestUpdateParameterizedModel(t *testing.T) {
+	dir := createParameterizedModel(t)
+	original, err := os.ReadFile(filepath.Join(dir, "model.smspec"))
+	assert.Nil(t, err)
+	m, errs := loadModelFile(t, filepath.Join(dir, "model.smspec"))
+	assert.Empty(t, errs)
+
+	// Includes, parameters and conditions were applied when loading the model
+	_, err = yamlmodel.Update(original, m.ToYaml(dir))
+	assert.EqualError(t, err, "cannot write a loaded model over content that uses 'parameters'. It was applied when the model was loaded and would be lost")
+	_, err = yamlmodel.Update([]byte("title: Store\nentities:\n  - {id: waf, type: program, name: WAF, description: Filters traffic, adm: [], when: env == prod}\n"), m.ToYaml(dir))
+	assert.EqualError(t, err, "cannot write a loaded model over content that uses conditions ('when'). It was applied when the model was loaded and would be lost")
+
+	// Models read as YAML retain them
+	var raw yamlmodel.SecurityModel
+	assert.Nil(t, yaml.Unmarshal(original, &raw))
+	raw.Entities[0].Mitigations = []string{"Runs as a non-root user"}
+	updated, err := yamlmodel.Update(original, &raw)
+	assert.Nil(t, err)
+	var written yamlmodel.SecurityModel
+	assert.Nil(t, yaml.Unmarshal(updated, &written))
+	assert.Equal(t, raw, written)
+	assert.Contains(t, string(updated), "description: Serves ${env}-${region} traffic\n")
+}